@@ -0,0 +1,97 @@
+/*
+Command fauna-shell-go is an interactive shell that evaluates FaunaDB query language expressions.
+
+Usage:
+
+	fauna-shell-go [flags] [script ...]
+
+Without arguments, expressions are read from the standard input. When the standard input is a
+terminal, the shell prompts for expressions, reading more lines while the expression is incomplete,
+and keeps a history of the expressions evaluated. Otherwise, and when script files are given, the
+expressions are evaluated in order and the shell exits at the first error.
+
+Expressions are written in the syntax accepted by faunadb.ParseFQL, such as:
+
+	> Paginate(Collections())
+	{ data: [Collection("users"), Collection("posts")] }
+
+Results are printed in the query language syntax, or as FaunaDB tagged JSON with -format json. In the
+interactive shell, the following commands are also available:
+
+	.secret [secret]     use another secret for the next queries, or the original one if omitted
+	.format fql|json     change the format of the results
+	.timing on|off       print the duration and cost of each query
+	.history             list the previous expressions
+	!N                   evaluate the expression N of the history again
+	.help                list the available commands
+	.exit                exit the shell
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+func main() {
+	secret := flag.String("secret", os.Getenv("FAUNA_SECRET"), "FaunaDB secret, defaults to $FAUNA_SECRET")
+	endpoint := flag.String("endpoint", os.Getenv("FAUNA_ENDPOINT"), "FaunaDB endpoint, defaults to $FAUNA_ENDPOINT or the FaunaDB cloud")
+	format := flag.String("format", formatFQL, "format of the results: fql or json")
+	timing := flag.Bool("timing", false, "print the duration and cost of each query")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [script ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if err := run(*secret, *endpoint, *format, *timing, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(secret, endpoint, format string, timing bool, scripts []string) error {
+	if secret == "" {
+		return fmt.Errorf("missing FaunaDB secret, use -secret or $FAUNA_SECRET")
+	}
+
+	if format != formatFQL && format != formatJSON {
+		return fmt.Errorf("invalid format %q, use fql or json", format)
+	}
+
+	var configs []f.ClientConfig
+
+	if endpoint != "" {
+		configs = append(configs, f.Endpoint(endpoint))
+	}
+
+	sh := newShell(f.NewFaunaClient(secret, configs...), os.Stdout, os.Stderr)
+	sh.format = format
+	sh.timing = timing
+
+	if len(scripts) > 0 {
+		for _, script := range scripts {
+			if err := sh.runFile(script); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+		if home := os.Getenv("HOME"); home != "" {
+			sh.loadHistory(filepath.Join(home, ".fauna_shell_go_history"))
+		}
+
+		sh.interactive = true
+	}
+
+	return sh.run("<stdin>", os.Stdin)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+const (
+	formatFQL  = "fql"
+	formatJSON = "json"
+
+	maxLineWidth = 80
+	indentWidth  = 2
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func formatValue(value f.Value, format string) (string, error) {
+	if format == formatJSON {
+		encoded, err := f.MarshalJSON(value)
+		if err != nil {
+			return "", err
+		}
+
+		var indented bytes.Buffer
+
+		if err := json.Indent(&indented, encoded, "", strings.Repeat(" ", indentWidth)); err != nil {
+			return "", err
+		}
+
+		return indented.String(), nil
+	}
+
+	var buffer bytes.Buffer
+	writeValue(&buffer, value, 0)
+
+	return buffer.String(), nil
+}
+
+// writeValue renders a value in the query language syntax. Objects and arrays that don't fit in the
+// remaining line width are rendered with one field or element per line.
+func writeValue(buffer *bytes.Buffer, value f.Value, indent int) {
+	inline := f.FQL(value)
+
+	if indent+len(inline) <= maxLineWidth {
+		buffer.WriteString(inline)
+		return
+	}
+
+	switch v := value.(type) {
+	case f.ObjectV:
+		keys := make([]string, 0, len(v))
+
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+		buffer.WriteString("{\n")
+
+		for _, key := range keys {
+			writeIndent(buffer, indent+indentWidth)
+
+			if identifier.MatchString(key) {
+				buffer.WriteString(key)
+			} else {
+				buffer.WriteString(strconv.Quote(key))
+			}
+
+			buffer.WriteString(": ")
+			writeValue(buffer, v[key], indent+indentWidth)
+			buffer.WriteString(",\n")
+		}
+
+		writeIndent(buffer, indent)
+		buffer.WriteByte('}')
+
+	case f.ArrayV:
+		buffer.WriteString("[\n")
+
+		for _, elem := range v {
+			writeIndent(buffer, indent+indentWidth)
+			writeValue(buffer, elem, indent+indentWidth)
+			buffer.WriteString(",\n")
+		}
+
+		writeIndent(buffer, indent)
+		buffer.WriteByte(']')
+
+	default:
+		buffer.WriteString(inline)
+	}
+}
+
+func writeIndent(buffer *bytes.Buffer, indent int) {
+	buffer.WriteString(strings.Repeat(" ", indent))
+}
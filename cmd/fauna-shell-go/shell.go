@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+const (
+	prompt             = "> "
+	continuationPrompt = "... "
+	maxHistory         = 1000
+	maxHistoryFile     = 2 * maxHistory // Entries the history file grows to before being trimmed
+)
+
+const help = `.secret [secret]     use another secret for the next queries, or the original one if omitted
+.format fql|json     change the format of the results
+.timing on|off       print the duration and cost of each query
+.history             list the previous expressions
+!N                   evaluate the expression N of the history again
+.help                list the available commands
+.exit                exit the shell
+`
+
+type shell struct {
+	root        *f.FaunaClient
+	client      *f.FaunaClient
+	out         io.Writer
+	errOut      io.Writer
+	format      string
+	timing      bool
+	interactive bool
+	history     []string
+	historyFile string
+	fileEntries int // Entries in the history file
+}
+
+func newShell(client *f.FaunaClient, out, errOut io.Writer) *shell {
+	return &shell{root: client, client: client, out: out, errOut: errOut, format: formatFQL}
+}
+
+func (sh *shell) runFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return sh.run(path, file)
+}
+
+// run evaluates the expressions read from in. Expressions may span multiple lines: lines are
+// accumulated until they form a complete expression. Outside of the interactive mode, the first
+// error stops the evaluation and is returned.
+func (sh *shell) run(name string, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var pending []string
+	var startLine, lineNumber int
+
+	sh.prompt(prompt)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNumber++
+
+		if len(pending) == 0 {
+			trimmed := strings.TrimSpace(line)
+
+			if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+				sh.prompt(prompt)
+				continue
+			}
+
+			if sh.interactive && (strings.HasPrefix(trimmed, ".") || strings.HasPrefix(trimmed, "!")) {
+				if quit := sh.command(trimmed); quit {
+					return nil
+				}
+
+				sh.prompt(prompt)
+				continue
+			}
+
+			startLine = lineNumber
+		}
+
+		pending = append(pending, line)
+		src := strings.Join(pending, "\n")
+
+		expr, err := f.ParseFQL(src)
+		if syntaxErr, ok := err.(f.FQLSyntaxError); ok && syntaxErr.Incomplete {
+			sh.prompt(continuationPrompt)
+			continue
+		}
+
+		pending = nil
+
+		if err == nil {
+			sh.addHistory(src)
+			err = sh.eval(expr)
+		} else {
+			err = sh.syntaxError(name, startLine, err)
+		}
+
+		if err != nil {
+			if !sh.interactive {
+				return err
+			}
+
+			fmt.Fprintln(sh.errOut, err)
+		}
+
+		sh.prompt(prompt)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if sh.interactive {
+		fmt.Fprintln(sh.out)
+	}
+
+	if len(pending) > 0 {
+		_, err := f.ParseFQL(strings.Join(pending, "\n"))
+		return sh.syntaxError(name, startLine, err)
+	}
+
+	return nil
+}
+
+func (sh *shell) prompt(text string) {
+	if sh.interactive {
+		fmt.Fprint(sh.out, text)
+	}
+}
+
+func (sh *shell) syntaxError(name string, startLine int, err error) error {
+	if syntaxErr, ok := err.(f.FQLSyntaxError); ok {
+		return fmt.Errorf("%s:%d:%d: %s", name, startLine+syntaxErr.Line-1, syntaxErr.Column, syntaxErr.Message)
+	}
+
+	return err
+}
+
+// command runs a shell command. It returns true if the shell must exit.
+func (sh *shell) command(line string) (quit bool) {
+	fields := strings.Fields(line)
+
+	switch {
+	case fields[0] == ".exit" || fields[0] == ".quit":
+		return true
+
+	case fields[0] == ".help":
+		fmt.Fprint(sh.out, help)
+
+	case fields[0] == ".secret" && len(fields) == 1:
+		sh.client = sh.root
+		fmt.Fprintln(sh.out, "Using the original secret.")
+
+	case fields[0] == ".secret" && len(fields) == 2:
+		sh.client = sh.root.NewSessionClient(fields[1])
+		fmt.Fprintln(sh.out, "Using the new secret.")
+
+	case fields[0] == ".format" && len(fields) == 2 && (fields[1] == formatFQL || fields[1] == formatJSON):
+		sh.format = fields[1]
+
+	case fields[0] == ".timing" && len(fields) == 2 && (fields[1] == "on" || fields[1] == "off"):
+		sh.timing = fields[1] == "on"
+
+	case fields[0] == ".history" && len(fields) == 1:
+		for i, entry := range sh.history {
+			fmt.Fprintf(sh.out, "%5d  %s\n", i+1, strings.Replace(entry, "\n", "\n       ", -1))
+		}
+
+	case strings.HasPrefix(fields[0], "!") && len(fields) == 1:
+		n, err := strconv.Atoi(fields[0][1:])
+		if err != nil || n < 1 || n > len(sh.history) {
+			fmt.Fprintf(sh.errOut, "No expression %s in the history.\n", fields[0][1:])
+			break
+		}
+
+		src := sh.history[n-1]
+		fmt.Fprintln(sh.out, src)
+
+		expr, err := f.ParseFQL(src)
+		if err == nil {
+			sh.addHistory(src)
+			err = sh.eval(expr)
+		}
+
+		if err != nil {
+			fmt.Fprintln(sh.errOut, err)
+		}
+
+	default:
+		fmt.Fprintf(sh.errOut, "Invalid command %q, use .help to list the available commands.\n", line)
+	}
+
+	return false
+}
+
+func (sh *shell) eval(expr f.Expr) error {
+	var result *f.QueryResult
+
+	value, err := sh.client.NewWithObserver(func(queryResult *f.QueryResult) {
+		result = queryResult
+	}).Query(expr)
+
+	if err != nil {
+		for _, location := range f.LocateErrors(err, expr) {
+			err = fmt.Errorf("%s\n  at %s", err, location)
+		}
+	}
+
+	if err == nil {
+		var output string
+
+		if output, err = formatValue(value, sh.format); err == nil {
+			fmt.Fprintln(sh.out, output)
+		}
+	}
+
+	if sh.timing && result != nil {
+		fmt.Fprintln(sh.errOut, timingOf(result))
+	}
+
+	return err
+}
+
+func timingOf(result *f.QueryResult) string {
+	timing := fmt.Sprintf("Query took %s", result.EndTime.Sub(result.StartTime).Round(time.Millisecond))
+
+	var costs []string
+
+	for _, header := range []struct{ name, label string }{
+		{"X-Query-Time", "ms server time"},
+		{"X-Read-Ops", "read ops"},
+		{"X-Write-Ops", "write ops"},
+		{"X-Compute-Ops", "compute ops"},
+	} {
+		if values := result.Headers[header.name]; len(values) > 0 {
+			costs = append(costs, values[0]+" "+header.label)
+		}
+	}
+
+	if len(costs) > 0 {
+		timing += " (" + strings.Join(costs, ", ") + ")"
+	}
+
+	return timing + "."
+}
+
+func (sh *shell) loadHistory(path string) {
+	sh.historyFile = path
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if entry, err := strconv.Unquote(line); err == nil {
+			sh.history = append(sh.history, entry)
+		}
+	}
+
+	sh.fileEntries = len(sh.history)
+	sh.trimHistory()
+}
+
+// addHistory records an expression in the history. Entries are saved quoted, one per line, so that
+// multiline expressions are kept as they were written.
+func (sh *shell) addHistory(src string) {
+	if n := len(sh.history); n > 0 && sh.history[n-1] == src {
+		return
+	}
+
+	sh.history = append(sh.history, src)
+
+	if sh.historyFile != "" {
+		if file, err := os.OpenFile(sh.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600); err == nil {
+			fmt.Fprintln(file, strconv.Quote(src))
+			_ = file.Close()
+			sh.fileEntries++
+		}
+	}
+
+	sh.trimHistory()
+}
+
+// trimHistory keeps the last maxHistory entries of the history. The history file is appended to until
+// it holds maxHistoryFile entries, and then rewritten with the entries kept, so that it is not
+// rewritten for every expression.
+func (sh *shell) trimHistory() {
+	if len(sh.history) > maxHistory {
+		sh.history = sh.history[len(sh.history)-maxHistory:]
+	}
+
+	if sh.historyFile == "" || sh.fileEntries <= maxHistoryFile {
+		return
+	}
+
+	var buffer bytes.Buffer
+
+	for _, entry := range sh.history {
+		fmt.Fprintln(&buffer, strconv.Quote(entry))
+	}
+
+	if ioutil.WriteFile(sh.historyFile, buffer.Bytes(), 0600) == nil {
+		sh.fileEntries = len(sh.history)
+	}
+}
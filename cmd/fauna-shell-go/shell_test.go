@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeServer struct {
+	sync.Mutex
+	secrets   []string
+	queries   []string
+	responses []string
+}
+
+func (server *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.Lock()
+	defer server.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	server.queries = append(server.queries, string(body))
+	server.secrets = append(server.secrets, r.Header.Get("Authorization"))
+
+	response := `{"resource":null}`
+
+	if len(server.responses) > 0 {
+		response, server.responses = server.responses[0], server.responses[1:]
+	}
+
+	if strings.Contains(response, "errors") {
+		w.WriteHeader(400)
+	}
+
+	w.Header().Set("X-Compute-Ops", "1")
+	_, _ = io.WriteString(w, response)
+}
+
+func newTestShell(responses ...string) (*httptest.Server, *fakeServer, *shell, *bytes.Buffer, *bytes.Buffer) {
+	fake := &fakeServer{responses: responses}
+	server := httptest.NewServer(fake)
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	return server, fake, newShell(f.NewFaunaClient("secret", f.Endpoint(server.URL)), out, errOut), out, errOut
+}
+
+func TestRunScript(t *testing.T) {
+	server, fake, sh, out, _ := newTestShell(`{"resource":3}`, `{"resource":{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}}}}}`)
+	defer server.Close()
+
+	script := `
+// Comments and blank lines are skipped.
+Add(1, 2)
+
+Select(
+  ["ref"],
+  Get(Ref(Collection("users"), "1"))
+)
+`
+
+	require.NoError(t, sh.run("script", strings.NewReader(script)))
+	require.Equal(t, "3\nCollection(\"users\")\n", out.String())
+	require.Equal(t, []string{
+		`{"add":[1,2]}`,
+		`{"from":{"get":{"id":"1","ref":{"collection":"users"}}},"select":["ref"]}`,
+	}, fake.queries)
+}
+
+func TestRunScriptStopsAtFirstError(t *testing.T) {
+	server, fake, sh, out, _ := newTestShell(`{"errors":[{"position":["add",1],"code":"invalid argument","description":"Number expected."}]}`)
+	defer server.Close()
+
+	err := sh.run("script", strings.NewReader("Add(1, 'two')\nAdd(1, 2)\n"))
+	require.EqualError(t, err, `Response error 400. Errors: [add/1](invalid argument): Number expected.`+
+		"\n  at [add/1] \"two\"")
+	require.Empty(t, out.String())
+	require.Len(t, fake.queries, 1)
+
+	err = sh.run("script", strings.NewReader("Add(1, 2)\n\nAdd(1,\n  2))\n"))
+	require.EqualError(t, err, `script:4:5: unexpected ")" after expression`)
+
+	err = sh.run("script", strings.NewReader("Add(1,\n  2\n"))
+	require.EqualError(t, err, `script:2:4: expected ",", found end of input`)
+}
+
+func TestInteractiveCommands(t *testing.T) {
+	server, fake, sh, out, errOut := newTestShell(`{"resource":1}`, `{"resource":2}`, `{"resource":3}`, `{"resource":{"a":1}}`)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sh.interactive = true
+	sh.historyFile = filepath.Join(dir, "history")
+
+	input := strings.Join([]string{
+		"Add(1,",
+		"  0)",
+		".secret other",
+		"Add(1, 1)",
+		".secret",
+		".timing on",
+		"!1",
+		".timing off",
+		".format json",
+		"{ a: 1 }",
+		".history",
+		".unknown",
+		".exit",
+		"Add(1, 2)",
+	}, "\n")
+
+	require.NoError(t, sh.run("<stdin>", strings.NewReader(input)))
+	require.Len(t, fake.secrets, 4)
+	require.NotEqual(t, fake.secrets[0], fake.secrets[1])
+	require.Equal(t, fake.secrets[0], fake.secrets[2])
+	require.Equal(t, fake.secrets[0], fake.secrets[3])
+	require.Contains(t, out.String(), "> ... 1\n> ")
+	require.Contains(t, out.String(), "> {\n  \"a\": 1\n}\n")
+	require.Contains(t, out.String(), "    1  Add(1,\n         0)\n    2  Add(1, 1)\n")
+	require.Contains(t, errOut.String(), " (1 compute ops).\n")
+	require.Contains(t, errOut.String(), `Invalid command ".unknown"`)
+
+	saved := newShell(nil, out, errOut)
+	saved.loadHistory(sh.historyFile)
+	require.Equal(t, sh.history, saved.history)
+}
+
+func TestHistoryFileIsCapped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "history")
+
+	var content bytes.Buffer
+	for i := 0; i < maxHistoryFile+10; i++ {
+		fmt.Fprintln(&content, strconv.Quote(strconv.Itoa(i)))
+	}
+	require.NoError(t, ioutil.WriteFile(path, content.Bytes(), 0600))
+
+	lines := func() []string {
+		saved, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		return strings.Split(strings.TrimSuffix(string(saved), "\n"), "\n")
+	}
+
+	sh := newShell(nil, nil, nil)
+	sh.loadHistory(path)
+	require.Len(t, sh.history, maxHistory)
+	require.Len(t, lines(), maxHistory)
+	require.Equal(t, strconv.Quote(strconv.Itoa(maxHistory+10)), lines()[0])
+
+	for i := 0; i < maxHistoryFile-maxHistory; i++ {
+		sh.addHistory(fmt.Sprintf("Add(%d, 1)", i))
+	}
+
+	require.Len(t, sh.history, maxHistory)
+	require.Len(t, lines(), maxHistoryFile)
+	require.Equal(t, "Add(0, 1)", sh.history[0])
+
+	sh.addHistory("Add(1, 2)")
+	require.Len(t, sh.history, maxHistory)
+	require.Len(t, lines(), maxHistory)
+	require.Equal(t, `"Add(1, 1)"`, lines()[0])
+	require.Equal(t, `"Add(1, 2)"`, lines()[maxHistory-1])
+}
+
+func TestFormatValue(t *testing.T) {
+	value := f.ObjectV{
+		"ref": f.RefV{ID: "1", Collection: &f.RefV{ID: "users", Collection: f.NativeCollections()}},
+		"data": f.ObjectV{
+			"name":          f.StringV("Jane"),
+			"tags":          f.ArrayV{f.StringV("a very long tag"), f.StringV("another very long tag"), f.StringV("and one more")},
+			"contact-email": f.StringV("jane@example.com"),
+		},
+	}
+
+	formatted, err := formatValue(value, formatFQL)
+	require.NoError(t, err)
+	require.Equal(t, `{
+  data: {
+    "contact-email": "jane@example.com",
+    name: "Jane",
+    tags: ["a very long tag", "another very long tag", "and one more"],
+  },
+  ref: Ref(Collection("users"), "1"),
+}`, formatted)
+
+	expr, err := f.ParseFQL(formatted)
+	require.NoError(t, err)
+	require.NotNil(t, expr)
+
+	formatted, err = formatValue(f.ArrayV{f.LongV(1), f.DoubleV(2)}, formatFQL)
+	require.NoError(t, err)
+	require.Equal(t, "[1, 2.0]", formatted)
+
+	formatted, err = formatValue(f.ArrayV{f.LongV(1), f.DoubleV(2)}, formatJSON)
+	require.NoError(t, err)
+	require.Equal(t, "[\n  1,\n  2\n]", formatted)
+}
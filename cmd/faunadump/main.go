@@ -0,0 +1,88 @@
+/*
+Command faunadump exports the content of a FaunaDB database to a newline delimited JSON file.
+
+Usage:
+
+	faunadump [flags] file
+
+The schema documents and the documents of every collection are written to the file, one per line,
+as described in the dump package. If the export is interrupted, running the same command again
+resumes it from the progress saved in file.progress.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/fauna/faunadb-go/faunadb/dump"
+)
+
+func main() {
+	secret := flag.String("secret", os.Getenv("FAUNA_SECRET"), "FaunaDB secret, defaults to $FAUNA_SECRET")
+	endpoint := flag.String("endpoint", os.Getenv("FAUNA_ENDPOINT"), "FaunaDB endpoint, defaults to $FAUNA_ENDPOINT or the FaunaDB cloud")
+	collections := flag.String("collections", "", "comma separated list of the collections whose documents are exported, defaults to all")
+	pageSize := flag.Int("page-size", 100, "number of documents read per query")
+	quiet := flag.Bool("quiet", false, "don't print the progress")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] file\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*secret, *endpoint, *collections, *pageSize, *quiet, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(secret, endpoint, collections string, pageSize int, quiet bool, path string) error {
+	if secret == "" {
+		return fmt.Errorf("missing FaunaDB secret, use -secret or $FAUNA_SECRET")
+	}
+
+	var configs []f.ClientConfig
+
+	if endpoint != "" {
+		configs = append(configs, f.Endpoint(endpoint))
+	}
+
+	options := []dump.Option{dump.PageSize(pageSize)}
+
+	if collections != "" {
+		options = append(options, dump.Only(strings.Split(collections, ",")...))
+	}
+
+	if !quiet {
+		options = append(options, dump.OnProgress(func(progress dump.Progress) error {
+			fmt.Fprintf(os.Stderr, "\r%d documents exported", progress.Documents)
+			return nil
+		}))
+	}
+
+	progress, err := dump.DumpFile(f.NewFaunaClient(secret, configs...), path, options...)
+
+	if !quiet {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("export interrupted after %d documents, run again to resume: %s", progress.Documents, err)
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "Exported %d documents at %d.\n", progress.Documents, progress.TS)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+Command faunagen generates Go types and functions from the schema of a FaunaDB database.
+
+Usage:
+
+	faunagen [flags]
+
+The collections, indexes and user defined functions of the database are read, and a sample of the
+documents of each collection is used to infer the type of their data. The generated file declares a
+struct for the data of each collection, typed functions matching each index, and a function calling
+each user defined function, as described in the codegen package. It's written to the standard output
+unless -o is given, for example:
+
+	//go:generate faunagen -package models -o models_gen.go
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/fauna/faunadb-go/faunadb/codegen"
+)
+
+func main() {
+	secret := flag.String("secret", os.Getenv("FAUNA_SECRET"), "FaunaDB secret, defaults to $FAUNA_SECRET")
+	endpoint := flag.String("endpoint", os.Getenv("FAUNA_ENDPOINT"), "FaunaDB endpoint, defaults to $FAUNA_ENDPOINT or the FaunaDB cloud")
+	pkg := flag.String("package", "models", "package name of the generated file")
+	output := flag.String("o", "", "file the generated code is written to, defaults to the standard output")
+	samples := flag.Int("samples", 20, "number of documents of each collection used to infer its type")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*secret, *endpoint, *pkg, *output, *samples); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(secret, endpoint, pkg, output string, samples int) error {
+	if secret == "" {
+		return fmt.Errorf("missing FaunaDB secret, use -secret or $FAUNA_SECRET")
+	}
+
+	var configs []f.ClientConfig
+
+	if endpoint != "" {
+		configs = append(configs, f.Endpoint(endpoint))
+	}
+
+	schema, err := codegen.Inspect(f.NewFaunaClient(secret, configs...), codegen.SampleSize(samples))
+	if err != nil {
+		return err
+	}
+
+	source, err := codegen.Generate(schema, pkg)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+
+	return ioutil.WriteFile(output, source, 0644)
+}
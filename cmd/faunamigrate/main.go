@@ -0,0 +1,144 @@
+/*
+Command faunamigrate applies and reverts FaunaDB schema migrations defined as JSON files.
+
+Usage:
+
+	faunamigrate [flags] status
+	faunamigrate [flags] up [version]
+	faunamigrate [flags] down version
+
+The up command applies all pending migrations, or the ones up to the given version. The down command
+reverts the applied migrations above the given version; use 0 to revert all of them. With -dry-run,
+the steps are printed in the query language syntax instead of being run.
+
+See migrate.LoadDir for the format of migration files.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/fauna/faunadb-go/faunadb/migrate"
+)
+
+func main() {
+	secret := flag.String("secret", os.Getenv("FAUNA_SECRET"), "FaunaDB secret, defaults to $FAUNA_SECRET")
+	endpoint := flag.String("endpoint", os.Getenv("FAUNA_ENDPOINT"), "FaunaDB endpoint, defaults to $FAUNA_ENDPOINT or the FaunaDB cloud")
+	dir := flag.String("dir", "migrations", "directory containing the migration files")
+	collection := flag.String("collection", "migrations", "name of the collection recording applied migrations")
+	dryRun := flag.Bool("dry-run", false, "print the steps to run without running them")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] status | up [version] | down version\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if err := run(*secret, *endpoint, *dir, *collection, *dryRun, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(secret, endpoint, dir, collection string, dryRun bool, args []string) error {
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if secret == "" {
+		return fmt.Errorf("missing FaunaDB secret, use -secret or $FAUNA_SECRET")
+	}
+
+	migrations, err := migrate.LoadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var configs []f.ClientConfig
+
+	if endpoint != "" {
+		configs = append(configs, f.Endpoint(endpoint))
+	}
+
+	migrator, err := migrate.New(f.NewFaunaClient(secret, configs...), migrations, migrate.BookkeepingCollection(collection))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args[0] == "status" && len(args) == 1:
+		return status(migrator)
+
+	case args[0] == "up" && len(args) == 1:
+		return migrateTo(migrator, migrate.Latest, dryRun)
+
+	case (args[0] == "up" || args[0] == "down") && len(args) == 2:
+		target, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+
+		return migrateTo(migrator, target, dryRun)
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+		return nil
+	}
+}
+
+func status(migrator *migrate.Migrator) error {
+	applied, err := migrator.Applied()
+	if err != nil {
+		return err
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		return err
+	}
+
+	for _, record := range applied {
+		fmt.Printf("applied %d %s at %s\n", record.Version, record.Name, record.AppliedAt.Format("2006-01-02T15:04:05Z"))
+	}
+
+	for _, migration := range pending {
+		fmt.Printf("pending %d %s\n", migration.Version, migration.Name)
+	}
+
+	return nil
+}
+
+func migrateTo(migrator *migrate.Migrator, target int64, dryRun bool) error {
+	if dryRun {
+		plan, err := migrator.Plan(target)
+		if err != nil {
+			return err
+		}
+
+		if len(plan) == 0 {
+			fmt.Println("Nothing to migrate.")
+		}
+
+		fmt.Print(plan)
+		return nil
+	}
+
+	done, err := migrator.Migrate(target)
+
+	for _, step := range done {
+		fmt.Printf("%s %d %s\n", step.Direction, step.Migration.Version, step.Migration.Name)
+	}
+
+	if err == nil && len(done) == 0 {
+		fmt.Println("Nothing to migrate.")
+	}
+
+	return err
+}
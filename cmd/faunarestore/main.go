@@ -0,0 +1,116 @@
+/*
+Command faunarestore recreates the schema and the documents of a dump written by faunadump.
+
+Usage:
+
+	faunarestore [flags] file
+
+Documents keep their ids and are replaced if they already exist, so the command can be run again
+after a failure. Collections and databases can be renamed with -rename-collection and
+-rename-database, which take a comma separated list of old=new pairs, for example:
+
+	faunarestore -rename-collection users=people,posts=articles prod.ndjson
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/fauna/faunadb-go/faunadb/dump"
+)
+
+func main() {
+	secret := flag.String("secret", os.Getenv("FAUNA_SECRET"), "FaunaDB secret, defaults to $FAUNA_SECRET")
+	endpoint := flag.String("endpoint", os.Getenv("FAUNA_ENDPOINT"), "FaunaDB endpoint, defaults to $FAUNA_ENDPOINT or the FaunaDB cloud")
+	collections := flag.String("rename-collection", "", "comma separated list of old=new collection names")
+	databases := flag.String("rename-database", "", "comma separated list of old=new database names, new can be empty for the current database")
+	batchSize := flag.Int("batch-size", 100, "number of documents written per query")
+	quiet := flag.Bool("quiet", false, "don't print the progress")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] file\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*secret, *endpoint, *collections, *databases, *batchSize, *quiet, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(secret, endpoint, collections, databases string, batchSize int, quiet bool, path string) error {
+	if secret == "" {
+		return fmt.Errorf("missing FaunaDB secret, use -secret or $FAUNA_SECRET")
+	}
+
+	var configs []f.ClientConfig
+
+	if endpoint != "" {
+		configs = append(configs, f.Endpoint(endpoint))
+	}
+
+	options := []dump.RestoreOption{dump.BatchSize(batchSize)}
+
+	renames, err := parseRenames(collections)
+	if err != nil {
+		return err
+	}
+
+	for from, to := range renames {
+		options = append(options, dump.RenameCollection(from, to))
+	}
+
+	if renames, err = parseRenames(databases); err != nil {
+		return err
+	}
+
+	for from, to := range renames {
+		options = append(options, dump.RenameDatabase(from, to))
+	}
+
+	if !quiet {
+		options = append(options, dump.OnRestore(func(restored dump.Restored) error {
+			fmt.Fprintf(os.Stderr, "\r%d schema documents and %d documents restored", restored.Schema, restored.Documents)
+			return nil
+		}))
+	}
+
+	_, err = dump.RestoreFile(f.NewFaunaClient(secret, configs...), path, options...)
+
+	if !quiet {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	return err
+}
+
+func parseRenames(list string) (map[string]string, error) {
+	renames := make(map[string]string)
+
+	if list == "" {
+		return renames, nil
+	}
+
+	for _, pair := range strings.Split(list, ",") {
+		names := strings.SplitN(pair, "=", 2)
+
+		if len(names) != 2 || names[0] == "" {
+			return nil, fmt.Errorf("invalid rename %q, use old=new", pair)
+		}
+
+		renames[names[0]] = names[1]
+	}
+
+	return renames, nil
+}
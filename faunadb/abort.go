@@ -0,0 +1,178 @@
+package faunadb
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const (
+	abortPayloadPrefix = "faunadb-payload:"
+	abortPayloadVar    = "faunadb_abort_payload"
+)
+
+/*
+AbortWith aborts the execution of the query with a structured payload. The payload is encoded as JSON
+into the abort message, and can be decoded on the client with AbortPayload. This is mostly useful for
+user defined functions returning domain errors to their callers. For example:
+
+	CreateFunction(Obj{
+		"name": "withdraw",
+		"body": Query(Lambda(Arr{"account", "amount"}, If(
+			LT(Select(Arr{"data", "balance"}, Get(Var("account"))), Var("amount")),
+			AbortWith(Obj{"code": "insufficient_funds", "account": Var("account")}),
+			Update(Var("account"), Obj{...}),
+		))),
+	})
+
+The payload can contain any expression. Strings, numbers, booleans and nulls evaluated by the server
+are encoded as their JSON counterparts, while other values, such as refs or timestamps, are encoded as
+strings in their query language representation. Values known when building the expression are encoded
+with their FaunaDB JSON representation and decode back to their original types.
+*/
+func AbortWith(payload interface{}) Expr {
+	parts := jsonParts(wrap(payload), unescapedArr{StringV(abortPayloadPrefix)})
+
+	if len(parts) == 1 {
+		return Abort(parts[0])
+	}
+
+	return Abort(Concat(parts))
+}
+
+/*
+AbortPayload returns the payload of an expression aborted with AbortWith. The payload is searched in
+all query errors of err, including the causes of errors raised by user defined functions. It returns
+false if err doesn't contain any structured abort payload. For example:
+
+	type InsufficientFunds struct {
+		Code    string `fauna:"code"`
+		Account string `fauna:"account"`
+	}
+
+	_, err := client.Query(Call(Function("withdraw"), account, 100))
+
+	if payload, ok := AbortPayload(err); ok {
+		var domainErr InsufficientFunds
+		_ = payload.Get(&domainErr)
+	}
+*/
+func AbortPayload(err error) (payload Value, ok bool) {
+	if faunaErr, isFaunaErr := asFaunaError(err); isFaunaErr {
+		payload, ok = findAbortPayload(faunaErr.Errors())
+	}
+
+	return
+}
+
+func findAbortPayload(queryErrors []QueryError) (Value, bool) {
+	for _, queryError := range queryErrors {
+		if queryError.Code == string(ErrTransactionAborted) && strings.HasPrefix(queryError.Description, abortPayloadPrefix) {
+			var payload Value
+
+			if err := UnmarshalJSON([]byte(strings.TrimPrefix(queryError.Description, abortPayloadPrefix)), &payload); err == nil {
+				return payload, true
+			}
+		}
+
+		if payload, ok := findAbortPayload(queryError.Cause); ok {
+			return payload, true
+		}
+	}
+
+	return nil, false
+}
+
+// jsonParts returns the expressions that, once concatenated, evaluate to the JSON encoding of expr.
+// Adjacent static parts are merged together.
+func jsonParts(expr Expr, parts unescapedArr) unescapedArr {
+	appendStatic := func(str string) {
+		if last := len(parts) - 1; last >= 0 {
+			if prev, ok := parts[last].(StringV); ok {
+				parts[last] = prev + StringV(str)
+				return
+			}
+		}
+
+		parts = append(parts, StringV(str))
+	}
+
+	if _, isCall := asCall(expr); isCall {
+		return append(parts, jsonOf(expr))
+	}
+
+	switch e := expr.(type) {
+	case unescapedObj:
+		fields, _ := e["object"].(unescapedObj)
+		keys := make([]string, 0, len(fields))
+
+		for key := range fields {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+		appendStatic("{")
+
+		for i, key := range keys {
+			if i > 0 {
+				appendStatic(",")
+			}
+
+			encodedKey, _ := json.Marshal(key)
+			appendStatic(string(encodedKey) + ":")
+			parts = jsonParts(fields[key], parts)
+		}
+
+		appendStatic("}")
+
+	case unescapedArr:
+		appendStatic("[")
+
+		for i, elem := range e {
+			if i > 0 {
+				appendStatic(",")
+			}
+
+			parts = jsonParts(elem, parts)
+		}
+
+		appendStatic("]")
+
+	case Value:
+		if encoded, err := MarshalJSON(e); err == nil {
+			appendStatic(string(encoded))
+		} else {
+			parts = append(parts, invalidExpr{err})
+		}
+
+	default:
+		parts = append(parts, expr)
+	}
+
+	return parts
+}
+
+// jsonOf returns an expression that encodes the evaluation of expr as JSON at the server.
+func jsonOf(expr Expr) Expr {
+	value := Var(abortPayloadVar)
+
+	return Let().Bind(abortPayloadVar, expr).In(
+		If(Or(IsNumber(value), IsBoolean(value)), ToString(value),
+			If(IsNull(value), "null",
+				quoteJSON(If(IsString(value), value, Format("%@", value))))),
+	)
+}
+
+// quoteJSON returns an expression quoting a string as a JSON string at the server. Newlines, carriage
+// returns and tabs are escaped. Other control characters would need a replacement each to be escaped,
+// so they are replaced by U+FFFD instead. Replacements are in the syntax of the server's regular
+// expressions.
+func quoteJSON(str Expr) Expr {
+	escaped := ReplaceStrRegex(str, `["\\]`, `\\$0`)
+	escaped = ReplaceStrRegex(escaped, `\n`, `\\n`)
+	escaped = ReplaceStrRegex(escaped, `\r`, `\\r`)
+	escaped = ReplaceStrRegex(escaped, `\t`, `\\t`)
+	escaped = ReplaceStrRegex(escaped, `[\x00-\x1f]`, `\\ufffd`)
+
+	return Concat(Arr{`"`, escaped, `"`})
+}
@@ -0,0 +1,148 @@
+package faunadb
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeAbortWithStaticPayload(t *testing.T) {
+	assertJSON(t,
+		AbortWith(Obj{"code": "insufficient_funds", "amount": 10, "tags": Arr{"a", true, nil}}),
+		`{"abort":"faunadb-payload:{\"amount\":10,\"code\":\"insufficient_funds\",\"tags\":[\"a\",true,null]}"}`,
+	)
+}
+
+func TestSerializeAbortWithDynamicPayload(t *testing.T) {
+	expected, err := json.Marshal(
+		Abort(Concat(Arr{`faunadb-payload:{"code":"not_found","id":`, jsonOf(Var("id")), `}`})),
+	)
+	require.NoError(t, err)
+
+	assertJSON(t, AbortWith(Obj{"code": "not_found", "id": Var("id")}), string(expected))
+}
+
+func TestSerializeJSONOfDynamicValue(t *testing.T) {
+	quoted := func(str string) string {
+		escaped := `{"pattern":"[\"\\\\]","replace":"\\\\$0","replacestrregex":` + str + `}`
+
+		for _, char := range []string{"n", "r", "t"} {
+			escaped = `{"pattern":"\\` + char + `","replace":"\\\\` + char + `","replacestrregex":` + escaped + `}`
+		}
+
+		escaped = `{"pattern":"[\\x00-\\x1f]","replace":"\\\\ufffd","replacestrregex":` + escaped + `}`
+		return `{"concat":["\"",` + escaped + `,"\""]}`
+	}
+
+	value := `{"var":"faunadb_abort_payload"}`
+
+	assertJSON(t,
+		jsonOf(Var("id")),
+		`{"in":{"else":{"else":`+quoted(`{"else":{"format":"%@","values":`+value+`},"if":{"is_string":`+value+`},"then":`+value+`}`)+`,`+
+			`"if":{"is_null":`+value+`},"then":"null"},`+
+			`"if":{"or":[{"is_number":`+value+`},{"is_boolean":`+value+`}]},"then":{"to_string":`+value+`}},`+
+			`"let":[{"faunadb_abort_payload":{"var":"id"}}]}`,
+	)
+}
+
+func TestQuoteJSONEscapesSpecialCharacters(t *testing.T) {
+	raw, err := json.Marshal(quoteJSON(Var("str")))
+	require.NoError(t, err)
+
+	var quoted struct {
+		Concat []json.RawMessage `json:"concat"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &quoted))
+	require.Len(t, quoted.Concat, 3)
+
+	// Collect the replacements, outermost first, and apply them innermost first as the server does.
+	type replacement struct {
+		Pattern string          `json:"pattern"`
+		Replace string          `json:"replace"`
+		Inner   json.RawMessage `json:"replacestrregex"`
+	}
+
+	var replacements []replacement
+
+	for next := quoted.Concat[1]; ; {
+		var r replacement
+		if json.Unmarshal(next, &r) != nil || r.Inner == nil {
+			break
+		}
+
+		replacements = append(replacements, r)
+		next = r.Inner
+	}
+
+	original := "a\\\"b\x00\x01\n\r\t\x1f c"
+	str := original
+
+	require.Len(t, replacements, 5)
+
+	for i := len(replacements) - 1; i >= 0; i-- {
+		// The server's replacements escape characters with a backslash and refer to the match with $0.
+		replace := regexp.MustCompile(`\\(.)|\$0`).ReplaceAllStringFunc(replacements[i].Replace, func(part string) string {
+			if part == "$0" {
+				return "${0}"
+			}
+
+			return strings.Replace(part[1:], "$", "$$", -1)
+		})
+		str = regexp.MustCompile(replacements[i].Pattern).ReplaceAllString(str, replace)
+	}
+
+	require.Equal(t, `"a\\\"b\ufffd\ufffd\n\r\t\ufffd c"`, `"`+str+`"`)
+
+	var decoded string
+	require.NoError(t, json.Unmarshal([]byte(`"`+str+`"`), &decoded))
+	require.Equal(t, "a\\\"b\ufffd\ufffd\n\r\t\ufffd c", decoded)
+}
+
+func TestDecodeAbortPayload(t *testing.T) {
+	json := `
+	{
+		"errors": [
+			{
+				"position": [],
+				"code": "call error",
+				"description": "Calling the function resulted in an error.",
+				"cause": [
+					{
+						"position": ["expr", "else", 1],
+						"code": "transaction aborted",
+						"description": "faunadb-payload:{\"code\":\"not_found\",\"ref\":{\"@ref\":{\"id\":\"42\",\"collection\":{\"@ref\":{\"id\":\"accounts\",\"collection\":{\"@ref\":{\"id\":\"collections\"}}}}}}}"
+					}
+				]
+			}
+		]
+	}
+	`
+
+	err := checkForResponseErrors(httpErrorResponseWith(400, json))
+
+	payload, ok := AbortPayload(err)
+	require.True(t, ok)
+
+	var domainErr struct {
+		Code string `fauna:"code"`
+		Ref  RefV   `fauna:"ref"`
+	}
+
+	require.NoError(t, payload.Get(&domainErr))
+	require.Equal(t, "not_found", domainErr.Code)
+	require.Equal(t, "42", domainErr.Ref.ID)
+	require.Equal(t, "accounts", domainErr.Ref.Collection.ID)
+}
+
+func TestNoAbortPayload(t *testing.T) {
+	err := checkForResponseErrors(httpErrorResponseWith(400, `{"errors": [{"code": "transaction aborted", "description": "plain message"}]}`))
+
+	_, ok := AbortPayload(err)
+	require.False(t, ok)
+
+	_, ok = AbortPayload(nil)
+	require.False(t, ok)
+}
@@ -150,3 +150,18 @@ func BenchmarkExtactValue(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkBuildExpr(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Map(Paginate(Documents(Collection("users")), Size(10)), Lambda("ref", Get(Var("ref"))))
+	}
+}
+
+func BenchmarkBuildExprTracked(b *testing.B) {
+	TrackBuilderCalls(true)
+	defer TrackBuilderCalls(false)
+
+	for i := 0; i < b.N; i++ {
+		Map(Paginate(Documents(Collection("users")), Size(10)), Lambda("ref", Get(Var("ref"))))
+	}
+}
@@ -0,0 +1,236 @@
+package faunadb
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+)
+
+/*
+ResultCache enables an in-memory cache for the results of immutable queries. The cache is bounded to
+maxEntries results and maxBytes bytes of encoded results, evicting the least recently used results
+first. A zero or negative bound disables that limit.
+
+Only read-only expressions whose reads are pinned to a fixed snapshot timestamp are cached, for
+example Get(ref, TS(ts)), Paginate(set, TS(ts)), or any read-only expression wrapped with At(ts, expr).
+Other read-only queries can be explicitly marked as cacheable with the Cacheable query configuration.
+Queries that may write, or whose result depends on the time or the credentials they are evaluated with,
+such as Now or Identify, are never served from the cache.
+
+Results are cached per secret, so clients created with NewSessionClient share the same cache safely.
+Cached results don't update the client's last seen transaction time, nor call its observer.
+*/
+func ResultCache(maxEntries int, maxBytes int) ClientConfig {
+	return func(cli *FaunaClient) { cli.cache = newResultCache(maxEntries, maxBytes) }
+}
+
+// Cacheable marks a read-only query as cacheable, regardless of it being pinned to a snapshot timestamp.
+// It has no effect on queries that may write, or if the client was not configured with ResultCache.
+func Cacheable() QueryConfig {
+	return func(req *faunaRequest) { req.cacheable = true }
+}
+
+// CacheStats describes the usage of a client's result cache.
+type CacheStats struct {
+	Hits    uint64 // Number of cacheable queries served from the cache
+	Misses  uint64 // Number of cacheable queries sent to FaunaDB
+	Entries int    // Number of results currently cached
+	Bytes   int    // Size of the encoded results currently cached
+}
+
+// CacheStats returns the usage statistics of the result cache. It returns a zero value if the client
+// was not configured with ResultCache.
+func (client *FaunaClient) CacheStats() CacheStats {
+	if client.cache == nil {
+		return CacheStats{}
+	}
+
+	return client.cache.stats()
+}
+
+func (client *FaunaClient) cacheKey(expr Expr, req *faunaRequest) (key string, ok bool) {
+	if client.cache == nil {
+		return
+	}
+
+	if !isReadOnly(expr) || isVolatile(expr) || !(req.cacheable || isPinned(expr, false)) {
+		return
+	}
+
+	// Maps are encoded with sorted keys, which makes the json encoding of expressions canonical.
+	body, err := json.Marshal(expr)
+	if err != nil {
+		return
+	}
+
+	return client.basicAuth + "\n" + string(body), true
+}
+
+type cacheEntry struct {
+	key      string
+	response []byte
+}
+
+type resultCache struct {
+	sync.Mutex
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	hits       uint64
+	misses     uint64
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+func newResultCache(maxEntries, maxBytes int) *resultCache {
+	return &resultCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (cache *resultCache) get(key string) (response []byte, ok bool) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	var elem *list.Element
+
+	if elem, ok = cache.entries[key]; ok {
+		cache.hits++
+		cache.lru.MoveToFront(elem)
+		response = elem.Value.(*cacheEntry).response
+	} else {
+		cache.misses++
+	}
+
+	return
+}
+
+func (cache *resultCache) put(key string, response []byte) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	size := len(key) + len(response)
+
+	if cache.maxBytes > 0 && size > cache.maxBytes {
+		return
+	}
+
+	if elem, ok := cache.entries[key]; ok {
+		cache.remove(elem)
+	}
+
+	cache.entries[key] = cache.lru.PushFront(&cacheEntry{key, response})
+	cache.bytes += size
+
+	for (cache.maxEntries > 0 && cache.lru.Len() > cache.maxEntries) ||
+		(cache.maxBytes > 0 && cache.bytes > cache.maxBytes) {
+		cache.remove(cache.lru.Back())
+	}
+}
+
+func (cache *resultCache) remove(elem *list.Element) {
+	entry := cache.lru.Remove(elem).(*cacheEntry)
+	delete(cache.entries, entry.key)
+	cache.bytes -= len(entry.key) + len(entry.response)
+}
+
+func (cache *resultCache) stats() CacheStats {
+	cache.Lock()
+	defer cache.Unlock()
+
+	return CacheStats{cache.hits, cache.misses, cache.lru.Len(), cache.bytes}
+}
+
+// Functions whose result depends on the time or the credentials used to evaluate them. Expressions
+// calling them are never cached, even if marked as cacheable.
+var volatileFunctions = map[string]bool{
+	"has_identity": true, "identify": true, "identity": true, "key_from_secret": true, "new_id": true,
+	"now": true,
+}
+
+// Functions that read documents or sets. Their snapshot time is set by the "ts" parameter.
+var snapshotFunctions = map[string]bool{"exists": true, "get": true, "paginate": true}
+
+// Functions that read their collection parameter if it's a set.
+var collectionFunctions = map[string]string{
+	"all": "all", "any": "any", "append": "collection", "count": "count", "drop": "collection",
+	"filter": "collection", "foreach": "collection", "is_empty": "is_empty", "is_nonempty": "is_nonempty",
+	"map": "collection", "mean": "mean", "prepend": "collection", "reduce": "collection", "sum": "sum",
+	"take": "collection",
+}
+
+// isVolatile reports whether the expression calls a function whose result depends on the time or the
+// credentials used to evaluate it.
+func isVolatile(expr Expr) bool {
+	volatile := false
+
+	walkCalls(expr, func(call exprCall) bool {
+		volatile = volatile || volatileFunctions[call.name]
+		return !volatile
+	})
+
+	return volatile
+}
+
+// isPinned reports whether all reads in the expression are bound to a fixed snapshot timestamp.
+func isPinned(expr Expr, atSnapshot bool) bool {
+	if call, ok := asCall(expr); ok {
+		switch {
+		case volatileFunctions[call.name]:
+			return false
+
+		case call.name == "time":
+			if str, ok := call.args["time"].(StringV); !ok || str == "now" {
+				return false
+			}
+
+		case call.name == "at":
+			if !isFixedTimestamp(call.args["at"]) {
+				return false
+			}
+
+			return isPinned(call.args["expr"], true)
+
+		case snapshotFunctions[call.name]:
+			if !atSnapshot && !isFixedTimestamp(call.args["ts"]) {
+				return false
+			}
+
+		case collectionFunctions[call.name] != "":
+			if !atSnapshot && !isMaterialized(call.args[collectionFunctions[call.name]]) {
+				return false
+			}
+		}
+	}
+
+	for _, sub := range subExprs(expr) {
+		if !isPinned(sub, atSnapshot) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isFixedTimestamp reports whether the timestamp expression always evaluates to the same time.
+func isFixedTimestamp(expr Expr) bool {
+	if _, isNull := expr.(NullV); expr == nil || isNull {
+		return false
+	}
+
+	return isPinned(expr, false)
+}
+
+// isMaterialized reports whether the collection is known to be an array or a page rather than a set.
+func isMaterialized(expr Expr) bool {
+	switch normalizeExpr(expr).(type) {
+	case unescapedArr, ArrayV:
+		return true
+	}
+
+	call, ok := asCall(expr)
+	return ok && call.name == "paginate"
+}
@@ -0,0 +1,164 @@
+package faunadb
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServePinnedReadsFromCache(t *testing.T) {
+	var calls int
+
+	server, _ := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		respondWith(200, `{"resource": {"data": {"name": "Fireball"}}}`)(w, r)
+	})
+	defer server.Close()
+
+	client := NewFaunaClient("secret", Endpoint(server.URL), ResultCache(10, 0))
+	query := Select("data", Get(Ref("collections/spells/42"), TS(1000)))
+
+	for i := 0; i < 3; i++ {
+		value, err := client.Query(query)
+		require.NoError(t, err)
+		require.Equal(t, ObjectV{"data": ObjectV{"name": StringV("Fireball")}}, value)
+	}
+
+	require.Equal(t, 1, calls)
+	require.Equal(t, CacheStats{Hits: 2, Misses: 1, Entries: 1, Bytes: client.cache.bytes}, client.CacheStats())
+}
+
+func TestCacheIsScopedBySecret(t *testing.T) {
+	var calls int
+
+	server, _ := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		respondWith(200, `{"resource": 42}`)(w, r)
+	})
+	defer server.Close()
+
+	client := NewFaunaClient("secret", Endpoint(server.URL), ResultCache(10, 0))
+	query := Paginate(Match(Index("all_spells")), TS(1000))
+
+	_, _ = client.Query(query)
+	_, _ = client.NewSessionClient("other-secret").Query(query)
+	_, _ = client.NewSessionClient("secret").Query(query)
+
+	require.Equal(t, 2, calls)
+}
+
+func TestDoNotCacheErrors(t *testing.T) {
+	var calls int
+
+	server, _ := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		respondWith(404, `{"errors": []}`)(w, r)
+	})
+	defer server.Close()
+
+	client := NewFaunaClient("secret", Endpoint(server.URL), ResultCache(10, 0))
+	query := Get(Ref("collections/spells/42"), TS(1000))
+
+	_, err := client.Query(query)
+	require.Error(t, err)
+	_, err = client.Query(query)
+	require.Error(t, err)
+
+	require.Equal(t, 2, calls)
+	require.Equal(t, 0, client.CacheStats().Entries)
+}
+
+func TestCacheableQueryConfig(t *testing.T) {
+	var calls int
+
+	server, _ := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		respondWith(200, `{"resource": 42}`)(w, r)
+	})
+	defer server.Close()
+
+	client := NewFaunaClient("secret", Endpoint(server.URL), ResultCache(10, 0))
+	read := Count(Match(Index("all_spells")))
+	write := Create(Collection("spells"), Obj{})
+
+	_, _ = client.Query(read)
+	_, _ = client.Query(read)
+	require.Equal(t, 2, calls)
+
+	_, _ = client.Query(read, Cacheable())
+	_, _ = client.Query(read, Cacheable())
+	require.Equal(t, 3, calls)
+
+	_, _ = client.Query(write, Cacheable())
+	_, _ = client.Query(write, Cacheable())
+	require.Equal(t, 5, calls)
+
+	identify := Identify(Ref("collections/users/42"), "secret")
+
+	_, _ = client.Query(identify, Cacheable())
+	_, _ = client.Query(identify, Cacheable())
+	require.Equal(t, 7, calls)
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newResultCache(2, 0)
+	cache.put("a", []byte("1"))
+	cache.put("b", []byte("2"))
+	_, _ = cache.get("a")
+	cache.put("c", []byte("3"))
+
+	_, found := cache.get("b")
+	require.False(t, found)
+	_, found = cache.get("a")
+	require.True(t, found)
+
+	cache = newResultCache(0, 6)
+	cache.put("a", []byte("12"))
+	cache.put("b", []byte("34"))
+	cache.put("c", []byte("56"))
+	cache.put("d", []byte("toolarge"))
+
+	stats := cache.stats()
+	require.Equal(t, 2, stats.Entries)
+	require.Equal(t, 6, stats.Bytes)
+}
+
+func TestIsPinned(t *testing.T) {
+	ref := Ref("collections/spells/42")
+	ts := TimeV(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	pinned := []Expr{
+		Get(ref, TS(1000)),
+		Get(ref, TS(ts)),
+		Get(ref, TS(Time("2020-01-01T00:00:00Z"))),
+		Paginate(MatchTerm(Index("spells_by_element"), "fire"), TS(1000)),
+		Map(Paginate(Documents(Collection("spells")), TS(1000)), Lambda("ref", Var("ref"))),
+		At(1000, Map(Paginate(Documents(Collection("spells"))), Lambda("ref", Get(Var("ref"))))),
+		At(1000, Count(Documents(Collection("spells")))),
+		Count(Arr{1, 2, 3}),
+		Add(1, 2),
+	}
+
+	unpinned := []Expr{
+		Get(ref),
+		Get(ref, TS(Now())),
+		Get(ref, TS(Time("now"))),
+		Paginate(Match(Index("all_spells"))),
+		Map(Paginate(Documents(Collection("spells")), TS(1000)), Lambda("ref", Get(Var("ref")))),
+		Count(Documents(Collection("spells"))),
+		At(Now(), Get(ref)),
+		At(1000, NewId()),
+		Identity(),
+		At(1000, Identify(ref, "secret")),
+	}
+
+	for _, expr := range pinned {
+		require.True(t, isPinned(expr, false), "%v", expr)
+	}
+
+	for _, expr := range unpinned {
+		require.False(t, isPinned(expr, false), "%v", expr)
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 )
@@ -19,6 +22,8 @@ const (
 	requestTimeout    = 60 * time.Second
 	headerTxnTime     = "X-Txn-Time"
 	headerLastSeenTxn = "X-Last-Seen-Txn"
+	headerQueryTags   = "X-Query-Tags"
+	headerTraceParent = "traceparent"
 	headerFaunaDriver = "go"
 )
 
@@ -28,7 +33,12 @@ var resource = ObjKey("resource")
 type ClientConfig func(*FaunaClient)
 
 // Endpoint configures the FaunaDB URL for a FaunaClient.
-func Endpoint(url string) ClientConfig { return func(cli *FaunaClient) { cli.endpoint = url } }
+func Endpoint(url string) ClientConfig {
+	return func(cli *FaunaClient) {
+		cli.endpoint = url
+		cli.fallbackEndpoints = nil
+	}
+}
 
 // HTTP allows the user to override the http.Client used by a FaunaClient.
 func HTTP(http *http.Client) ClientConfig { return func(cli *FaunaClient) { cli.http = http } }
@@ -44,6 +54,23 @@ func EnableTxnTimePassthrough() ClientConfig {
 	return func(cli *FaunaClient) { cli.isTxnTimeEnabled = true }
 }
 
+// DefaultHeaders sets additional HTTP headers sent with ALL queries issued with this client.
+// Headers required by the driver, such as Authorization or the API version, can not be overridden
+// and are ignored.
+func DefaultHeaders(headers map[string]string) ClientConfig {
+	return func(cli *FaunaClient) {
+		if cli.defaultHeaders == nil {
+			cli.defaultHeaders = make(map[string]string, len(headers))
+		}
+
+		for k, v := range headers {
+			if !reservedHeaders[http.CanonicalHeaderKey(k)] {
+				cli.defaultHeaders[k] = v
+			}
+		}
+	}
+}
+
 // QueryTimeoutMS sets the server timeout for ALL queries issued with this client.
 // This is not the http request timeout.
 func QueryTimeoutMS(millis uint64) ClientConfig {
@@ -73,8 +100,130 @@ func TimeoutMS(millis uint64) QueryConfig {
 	}
 }
 
+// Tags attaches tags to a specific query. Tags are sent in the X-Query-Tags header as comma separated
+// key=value pairs and are available to the client's observer through QueryResult.
+// Calling Tags more than once for the same query merges all tags together.
+//
+// Keys and values may only contain letters, digits and underscores, keys being at most 40 characters
+// long and values at most 80. A query can have up to 25 tags. Queries with invalid tags fail with an
+// InvalidTagError without being sent.
+func Tags(tags map[string]string) QueryConfig {
+	return func(req *faunaRequest) {
+		if req.tags == nil {
+			req.tags = make(map[string]string, len(tags))
+		}
+
+		for k, v := range tags {
+			req.tags[k] = v
+		}
+	}
+}
+
+// TraceParent propagates a W3C trace context to a specific query by setting its traceparent header.
+//
+// See: https://www.w3.org/TR/trace-context/#traceparent-header
+func TraceParent(traceParent string) QueryConfig {
+	return Header(headerTraceParent, traceParent)
+}
+
+// Header sets an HTTP header for a specific query. It overrides the client's default headers.
+// Headers required by the driver, such as Authorization or the API version, can not be overridden
+// and are ignored.
+func Header(key, value string) QueryConfig {
+	return func(req *faunaRequest) {
+		if !reservedHeaders[http.CanonicalHeaderKey(key)] {
+			req.headers[key] = value
+		}
+	}
+}
+
+// Headers set by the driver that queries can't override.
+var reservedHeaders = map[string]bool{
+	"Authorization":         true,
+	"Content-Type":          true,
+	"X-Faunadb-Api-Version": true,
+	"X-Fauna-Driver":        true,
+	headerLastSeenTxn:       true,
+}
+
+// Limits on query tags, as enforced by FaunaDB.
+const (
+	maxTags        = 25
+	maxTagKeyLen   = 40
+	maxTagValueLen = 80
+)
+
+var validTag = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// InvalidTagError is returned by queries given tags that can't be sent in the X-Query-Tags header.
+type InvalidTagError struct {
+	Key, Value string
+	Reason     string
+}
+
+func (err InvalidTagError) Error() string {
+	return fmt.Sprintf("faunadb: invalid query tag %q=%q: %s", err.Key, err.Value, err.Reason)
+}
+
 type faunaRequest struct {
-	headers map[string]string
+	headers   map[string]string
+	tags      map[string]string
+	cacheable bool
+	err       error
+}
+
+func newFaunaRequest(configs []QueryConfig) *faunaRequest {
+	req := &faunaRequest{headers: map[string]string{}}
+
+	for _, config := range configs {
+		config(req)
+	}
+
+	if len(req.tags) > 0 {
+		if req.err = validateTags(req.tags); req.err == nil {
+			req.headers[headerQueryTags] = encodeTags(req.tags)
+		}
+	}
+
+	return req
+}
+
+func validateTags(tags map[string]string) error {
+	keys := make([]string, 0, len(tags))
+
+	for k := range tags {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		switch v := tags[k]; {
+		case !validTag.MatchString(k) || !validTag.MatchString(v):
+			return InvalidTagError{k, v, "keys and values must be non-empty and only contain letters, digits and underscores"}
+		case len(k) > maxTagKeyLen:
+			return InvalidTagError{k, v, fmt.Sprintf("keys can be at most %d characters long", maxTagKeyLen)}
+		case len(v) > maxTagValueLen:
+			return InvalidTagError{k, v, fmt.Sprintf("values can be at most %d characters long", maxTagValueLen)}
+		}
+	}
+
+	if len(tags) > maxTags {
+		return InvalidTagError{Reason: fmt.Sprintf("a query can have at most %d tags", maxTags)}
+	}
+
+	return nil
+}
+
+func encodeTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
 }
 
 // ObserverCallback is the callback type for requests.
@@ -92,14 +241,19 @@ This structure should be reused as much as possible. Avoid copying this structur
 If you need to create a client with a different secret, use the NewSessionClient method.
 */
 type FaunaClient struct {
-	basicAuth        string
-	endpoint         string
-	http             *http.Client
-	isTxnTimeEnabled bool
-	lastTxnTime      int64
-	queryTimeoutMs   uint64
-	observer         ObserverCallback
-	headers          map[string]string
+	basicAuth         string
+	endpoint          string
+	fallbackEndpoints []string
+	endpoints         endpointPool
+	http              *http.Client
+	isTxnTimeEnabled  bool
+	lastTxnTime       int64
+	queryTimeoutMs    uint64
+	observer          ObserverCallback
+	headers           map[string]string
+	defaultHeaders    map[string]string
+	middlewares       []Middleware
+	cache             *resultCache
 }
 
 // QueryResult is a structure containing the result context for a given FaunaDB query.
@@ -111,12 +265,15 @@ type QueryResult struct {
 	Headers    map[string][]string
 	StartTime  time.Time
 	EndTime    time.Time
+	Tags       map[string]string
 }
 
 /*
 NewFaunaClient creates a new FaunaClient structure. Possible configuration options:
 	Endpoint: sets a specific FaunaDB url. Default: https://db.fauna.com
+	Endpoints: sets a primary FaunaDB url along with fallback urls.
 	HTTP: sets a specific http.Client. Default: a new net.Client with 60 seconds timeout.
+	DefaultHeaders: sets additional headers sent with every query.
 */
 func NewFaunaClient(secret string, configs ...ClientConfig) *FaunaClient {
 	client := &FaunaClient{basicAuth: basicAuth(secret), isTxnTimeEnabled: true}
@@ -129,6 +286,8 @@ func NewFaunaClient(secret string, configs ...ClientConfig) *FaunaClient {
 		client.endpoint = defaultEndpoint
 	}
 
+	client.endpoints = newEndpointPool(append([]string{client.endpoint}, client.fallbackEndpoints...)...)
+
 	if client.http == nil {
 		client.http = &http.Client{
 			Timeout: requestTimeout,
@@ -154,26 +313,54 @@ func NewFaunaClient(secret string, configs ...ClientConfig) *FaunaClient {
 
 // QueryResult run and return the cost headers associated with this query.
 func (client *FaunaClient) QueryResult(expr Expr) (value Value, headers map[string][]string, err error) {
+	return client.QueryResultWithConfig(expr)
+}
+
+// QueryResultWithConfig is like QueryResult, applying the given query configurations.
+func (client *FaunaClient) QueryResultWithConfig(expr Expr, configs ...QueryConfig) (value Value, headers map[string][]string, err error) {
 	value, err = client.NewWithObserver(func(queryResult *QueryResult) {
 		headers = queryResult.Headers
-	}).Query(expr)
+	}).Query(expr, configs...)
 
 	return
 }
 
 // BatchQueryResult run and return the cost headers associated with this query.
 func (client *FaunaClient) BatchQueryResult(expr []Expr) (value []Value, headers map[string][]string, err error) {
+	return client.BatchQueryResultWithConfig(expr)
+}
+
+// BatchQueryResultWithConfig is like BatchQueryResult, applying the given query configurations.
+func (client *FaunaClient) BatchQueryResultWithConfig(expr []Expr, configs ...QueryConfig) (value []Value, headers map[string][]string, err error) {
 	value, err = client.NewWithObserver(func(queryResult *QueryResult) {
 		headers = queryResult.Headers
-	}).BatchQuery(expr)
+	}).BatchQueryWithConfig(expr, configs...)
 
 	return
 }
 
 // Query is the primary method used to send a query language expression to FaunaDB.
+// The expression is passed through all middlewares registered with Use before being sent.
 func (client *FaunaClient) Query(expr Expr, configs ...QueryConfig) (value Value, err error) {
+	return client.queryChain()(expr, configs...)
+}
+
+func (client *FaunaClient) query(expr Expr, configs ...QueryConfig) (value Value, err error) {
+	req := newFaunaRequest(configs)
+	if req.err != nil {
+		return nil, req.err
+	}
+
+	cacheKey, cacheable := client.cacheKey(expr, req)
+
+	if cacheable {
+		if cached, found := client.cache.get(cacheKey); found {
+			return parseResource(bytes.NewReader(cached))
+		}
+	}
+
 	startTime := time.Now()
-	response, err := client.performRequest(expr, configs)
+	response, err := client.performRequest(expr, req)
 
 	if response != nil {
 		defer func() {
@@ -184,7 +371,16 @@ func (client *FaunaClient) Query(expr Expr, configs ...QueryConfig) (value Value
 
 	if err == nil {
 		if err = checkForResponseErrors(response); err == nil {
-			value, err = client.parseResponse(response, expr, startTime)
+			var body io.Reader = response.Body
+			var raw bytes.Buffer
+
+			if cacheable {
+				body = io.TeeReader(response.Body, &raw)
+			}
+
+			if value, err = client.parseResponse(response, body, expr, req, startTime); err == nil && cacheable {
+				client.cache.put(cacheKey, raw.Bytes())
+			}
 		}
 	}
 
@@ -194,6 +390,11 @@ func (client *FaunaClient) Query(expr Expr, configs ...QueryConfig) (value Value
 // BatchQuery will sends multiple simultaneous queries to FaunaDB. values are returned in the same order
 // as the queries.
 func (client *FaunaClient) BatchQuery(exprs []Expr) (values []Value, err error) {
+	return client.BatchQueryWithConfig(exprs)
+}
+
+// BatchQueryWithConfig is like BatchQuery, applying the given query configurations.
+func (client *FaunaClient) BatchQueryWithConfig(exprs []Expr, configs ...QueryConfig) (values []Value, err error) {
 	arr := make(unescapedArr, len(exprs))
 
 	for i, expr := range exprs {
@@ -202,7 +403,7 @@ func (client *FaunaClient) BatchQuery(exprs []Expr) (values []Value, err error)
 
 	var res Value
 
-	if res, err = client.Query(arr); err == nil {
+	if res, err = client.Query(arr, configs...); err == nil {
 		err = res.Get(&values)
 	}
 
@@ -246,69 +447,102 @@ func (client *FaunaClient) SyncLastTxnTime(newTxnTime int64) {
 
 func (client *FaunaClient) newClient(basicAuth string, observer ObserverCallback) *FaunaClient {
 	return &FaunaClient{
-		basicAuth:        basicAuth,
-		endpoint:         client.endpoint,
-		headers:          client.headers,
-		http:             client.http,
-		isTxnTimeEnabled: client.isTxnTimeEnabled,
-		queryTimeoutMs:   client.queryTimeoutMs,
-		lastTxnTime:      client.lastTxnTime,
-		observer:         observer,
+		basicAuth:         basicAuth,
+		endpoint:          client.endpoint,
+		fallbackEndpoints: client.fallbackEndpoints,
+		endpoints:         client.endpoints,
+		headers:           client.headers,
+		defaultHeaders:    client.defaultHeaders,
+		http:              client.http,
+		isTxnTimeEnabled:  client.isTxnTimeEnabled,
+		queryTimeoutMs:    client.queryTimeoutMs,
+		lastTxnTime:       client.lastTxnTime,
+		observer:          observer,
+		middlewares:       client.middlewares[:len(client.middlewares):len(client.middlewares)],
+		cache:             client.cache,
 	}
 }
 
-func (client *FaunaClient) performRequest(expr Expr, configs []QueryConfig) (response *http.Response, err error) {
-	var request *http.Request
-	if request, err = client.prepareRequest(expr, configs); err == nil {
+func (client *FaunaClient) performRequest(expr Expr, req *faunaRequest) (response *http.Response, err error) {
+	var body []byte
+
+	if body, err = json.Marshal(expr); err != nil {
+		return
+	}
+
+	readOnly := len(client.endpoints) > 1 && isReadOnly(expr)
+	candidates := client.endpoints.candidates(readOnly)
+
+	for i, endpoint := range candidates {
+		var request *http.Request
+
+		if request, err = client.prepareRequest(endpoint.url, body, req); err != nil {
+			return
+		}
+
+		startTime := time.Now()
 		response, err = client.http.Do(request)
+
+		if err == nil && response.StatusCode != http.StatusServiceUnavailable {
+			endpoint.markHealthy(time.Since(startTime))
+			return
+		}
+
+		endpoint.markUnhealthy()
+
+		if !readOnly || i == len(candidates)-1 {
+			return
+		}
+
+		if response != nil {
+			_, _ = io.Copy(ioutil.Discard, response.Body)
+			_ = response.Body.Close()
+		}
 	}
 
 	return
 }
 
-func (client *FaunaClient) prepareRequest(expr Expr, configs []QueryConfig) (request *http.Request, err error) {
-	var body []byte
+func (client *FaunaClient) prepareRequest(endpoint string, body []byte, req *faunaRequest) (request *http.Request, err error) {
+	if request, err = http.NewRequest("POST", endpoint, bytes.NewReader(body)); err == nil {
+		for k, v := range client.defaultHeaders {
+			request.Header.Set(k, v)
+		}
+		request.Header.Set("Authorization", client.basicAuth)
+		for k, v := range client.headers {
+			request.Header.Set(k, v)
+		}
+		for k, v := range req.headers {
+			request.Header.Set(k, v)
+		}
 
-	if body, err = json.Marshal(expr); err == nil {
-		if request, err = http.NewRequest("POST", client.endpoint, bytes.NewReader(body)); err == nil {
-			request.Header.Add("Authorization", client.basicAuth)
-			for k, v := range client.headers {
-				request.Header.Add(k, v)
-			}
+		client.addLastTxnTimeHeader(request)
+	}
 
-			if len(configs) > 0 {
-				req := &faunaRequest{
-					headers: map[string]string{},
-				}
-				for _, config := range configs {
-					config(req)
-				}
-				for k, v := range req.headers {
-					request.Header.Add(k, v)
-				}
-			}
+	return
+}
 
-			client.addLastTxnTimeHeader(request)
+func (client *FaunaClient) parseResponse(response *http.Response, body io.Reader, expr Expr, req *faunaRequest, startTime time.Time) (value Value, err error) {
+	if err = client.storeLastTxnTime(response.Header); err == nil {
+		if value, err = parseResource(body); err == nil {
+			client.callObserver(response, expr, value, req, startTime)
 		}
 	}
 
 	return
 }
 
-func (client *FaunaClient) parseResponse(response *http.Response, expr Expr, startTime time.Time) (value Value, err error) {
+func parseResource(body io.Reader) (value Value, err error) {
 	var parsedResponse Value
 
-	if err = client.storeLastTxnTime(response.Header); err == nil {
-		if parsedResponse, err = parseJSON(response.Body); err == nil {
-			value, err = parsedResponse.At(resource).GetValue()
-			client.callObserver(response, expr, value, startTime)
-		}
+	if parsedResponse, err = parseJSON(body); err == nil {
+		value, err = parsedResponse.At(resource).GetValue()
 	}
 
 	return
 }
 
-func (client *FaunaClient) callObserver(response *http.Response, expr Expr, value Value, startTime time.Time) {
+func (client *FaunaClient) callObserver(response *http.Response, expr Expr, value Value, req *faunaRequest, startTime time.Time) {
 	queryResult := &QueryResult{
 		client,
 		expr,
@@ -317,6 +551,7 @@ func (client *FaunaClient) callObserver(response *http.Response, expr Expr, valu
 		response.Header,
 		startTime,
 		time.Now(),
+		req.tags,
 	}
 
 	client.observer(queryResult)
@@ -325,7 +560,7 @@ func (client *FaunaClient) callObserver(response *http.Response, expr Expr, valu
 func (client *FaunaClient) addLastTxnTimeHeader(request *http.Request) {
 	if client.isTxnTimeEnabled {
 		if lastSeen := atomic.LoadInt64(&client.lastTxnTime); lastSeen != 0 {
-			request.Header.Add(headerLastSeenTxn, strconv.FormatInt(lastSeen, 10))
+			request.Header.Set(headerLastSeenTxn, strconv.FormatInt(lastSeen, 10))
 		}
 	}
 }
@@ -0,0 +1,437 @@
+/*
+Package codegen generates Go code from the schema of a FaunaDB database, so that models stay in sync
+with the database.
+
+Inspect reads the collections, indexes and user defined functions of a database, and infers the type
+of each collection's document data from a sample of its documents. Generate then writes a Go source
+file declaring:
+
+  - a struct for the data of each collection, with fauna tags, and a struct for each nested object;
+  - an IndexDef for each index, a function matching the index with typed terms, and a struct the
+    index's values can be decoded into with the IndexDef's Decode method;
+  - a function calling each user defined function, taking the function's parameters as a struct.
+
+For example, for a users collection, a users_by_email index and a create_user function:
+
+	// User is the data of a document of the users collection.
+	type User struct {
+		Email string `fauna:"email"`
+		Name  string `fauna:"name"`
+	}
+
+	func UsersByEmail(email string) f.Expr { return UsersByEmailIndex.Match(email) }
+
+	func CreateUser(args CreateUserArgs) f.Expr {
+		return f.Call(f.Function("create_user"), args.Email, args.Name)
+	}
+
+Types are inferred from the sampled documents only: fields missing from the sample are missing from
+the structs, and fields holding values of different types are declared as f.Value. Index bindings are
+not generated, so the generated IndexDefs are meant to match and decode existing indexes rather than
+to create them.
+*/
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+const pageSize = 1000
+
+var (
+	dataField  = f.ObjKey("data")
+	afterField = f.ObjKey("after")
+)
+
+// Schema is the part of a database's schema code is generated from.
+type Schema struct {
+	Collections []Collection
+	Indexes     []Index
+	Functions   []Function
+}
+
+// Collection is a collection along with the type of its documents' data.
+type Collection struct {
+	Name string
+	Data *Type // Type of the data of the sampled documents, nil if the collection has no documents
+}
+
+// Index is an index along with its terms and values.
+type Index struct {
+	Name   string
+	Source string // Name of the source collection, empty if the index has several sources
+	Terms  []f.IndexField
+	Values []f.IndexField
+}
+
+// Function is a user defined function.
+type Function struct {
+	Name   string
+	Params []string // Parameters of the function's lambda, nil if they are not a list of names
+}
+
+// Kind is the kind of the values of a field.
+type Kind int
+
+// Kinds of values.
+const (
+	Any Kind = iota // Values of different kinds
+	String
+	Long
+	Double
+	Boolean
+	Time
+	Date
+	Ref
+	Set
+	Bytes
+	Query
+	Object
+	Array
+)
+
+// Type is the type of the values of a field. Null values have no type, represented by a nil *Type.
+type Type struct {
+	Kind   Kind
+	Fields map[string]*Type // Fields of objects
+	Elem   *Type            // Type of the elements of arrays, nil if all arrays are empty
+}
+
+// TypeOf returns the type of a value.
+func TypeOf(value f.Value) *Type {
+	switch v := value.(type) {
+	case f.StringV:
+		return &Type{Kind: String}
+	case f.LongV:
+		return &Type{Kind: Long}
+	case f.DoubleV:
+		return &Type{Kind: Double}
+	case f.BooleanV:
+		return &Type{Kind: Boolean}
+	case f.TimeV:
+		return &Type{Kind: Time}
+	case f.DateV:
+		return &Type{Kind: Date}
+	case f.RefV:
+		return &Type{Kind: Ref}
+	case f.SetRefV:
+		return &Type{Kind: Set}
+	case f.BytesV:
+		return &Type{Kind: Bytes}
+	case f.QueryV:
+		return &Type{Kind: Query}
+	case f.NullV, nil:
+		return nil
+
+	case f.ObjectV:
+		fields := make(map[string]*Type, len(v))
+
+		for key, field := range v {
+			fields[key] = TypeOf(field)
+		}
+
+		return &Type{Kind: Object, Fields: fields}
+
+	case f.ArrayV:
+		var elem *Type
+
+		for _, value := range v {
+			elem = Merge(elem, TypeOf(value))
+		}
+
+		return &Type{Kind: Array, Elem: elem}
+	}
+
+	return &Type{Kind: Any}
+}
+
+// Merge returns a type for the values of both types. Objects are merged field by field, and arrays
+// element by element. Longs and doubles merge into doubles, and other different kinds into Any.
+func Merge(a, b *Type) *Type {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+
+	switch {
+	case a.Kind == Object && b.Kind == Object:
+		fields := make(map[string]*Type, len(a.Fields)+len(b.Fields))
+
+		for key, field := range a.Fields {
+			fields[key] = field
+		}
+
+		for key, field := range b.Fields {
+			fields[key] = Merge(fields[key], field)
+		}
+
+		return &Type{Kind: Object, Fields: fields}
+
+	case a.Kind == Array && b.Kind == Array:
+		return &Type{Kind: Array, Elem: Merge(a.Elem, b.Elem)}
+
+	case a.Kind == b.Kind:
+		return a
+
+	case (a.Kind == Long || a.Kind == Double) && (b.Kind == Long || b.Kind == Double):
+		return &Type{Kind: Double}
+
+	default:
+		return &Type{Kind: Any}
+	}
+}
+
+// field returns the type of the field at the given path, or nil if it's unknown.
+func (t *Type) field(path []string) *Type {
+	for _, key := range path {
+		if t == nil || t.Kind != Object {
+			return nil
+		}
+
+		t = t.Fields[key]
+	}
+
+	return t
+}
+
+// Option configures Inspect.
+type Option func(*inspector)
+
+// SampleSize sets the number of documents of each collection whose data are used to infer the
+// collection's type. It defaults to 20.
+func SampleSize(size int) Option {
+	return func(in *inspector) { in.sampleSize = size }
+}
+
+type inspector struct {
+	client     f.Querier
+	sampleSize int
+}
+
+// Inspect reads the schema of the database the client has access to.
+func Inspect(client f.Querier, options ...Option) (schema Schema, err error) {
+	in := &inspector{client: client, sampleSize: 20}
+
+	for _, option := range options {
+		option(in)
+	}
+
+	if schema.Collections, err = in.collections(); err != nil {
+		return
+	}
+
+	if schema.Indexes, err = in.indexes(); err != nil {
+		return
+	}
+
+	schema.Functions, err = in.functions()
+	return
+}
+
+func (in *inspector) collections() (collections []Collection, err error) {
+	refs, err := in.all(f.Collections(), nil)
+	if err != nil {
+		return
+	}
+
+	for _, value := range refs {
+		var ref f.RefV
+
+		if err = value.Get(&ref); err != nil {
+			return
+		}
+
+		collection := Collection{Name: ref.ID}
+
+		if collection.Data, err = in.sample(ref.ID); err != nil {
+			return
+		}
+
+		collections = append(collections, collection)
+	}
+
+	sort.Slice(collections, func(i, j int) bool { return collections[i].Name < collections[j].Name })
+	return
+}
+
+// sample infers the type of a collection's data from its first documents.
+func (in *inspector) sample(collection string) (data *Type, err error) {
+	res, err := in.client.Query(f.Map(
+		f.Paginate(f.Documents(f.Collection(collection)), f.Size(in.sampleSize)),
+		f.Lambda("ref", f.Select("data", f.Get(f.Var("ref")), f.Default(f.Obj{}))),
+	))
+	if err != nil {
+		return
+	}
+
+	var docs []f.Value
+
+	if err = res.At(dataField).Get(&docs); err != nil {
+		return
+	}
+
+	for _, doc := range docs {
+		data = Merge(data, TypeOf(doc))
+	}
+
+	return
+}
+
+type indexFieldDoc struct {
+	Field   f.Value `fauna:"field"`
+	Binding string  `fauna:"binding"`
+	Reverse bool    `fauna:"reverse"`
+}
+
+func (in *inspector) indexes() (indexes []Index, err error) {
+	docs, err := in.all(f.Indexes(), f.Lambda("ref", f.Get(f.Var("ref"))))
+	if err != nil {
+		return
+	}
+
+	for _, value := range docs {
+		var doc struct {
+			Name   string          `fauna:"name"`
+			Source f.Value         `fauna:"source"`
+			Terms  []indexFieldDoc `fauna:"terms"`
+			Values []indexFieldDoc `fauna:"values"`
+		}
+
+		if err = value.Get(&doc); err != nil {
+			return
+		}
+
+		index := Index{Name: doc.Name}
+
+		if source, ok := doc.Source.(f.RefV); ok {
+			index.Source = source.ID
+		}
+
+		if index.Terms, err = indexFields(doc.Terms); err != nil {
+			return
+		}
+
+		if index.Values, err = indexFields(doc.Values); err != nil {
+			return
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+	return
+}
+
+func indexFields(docs []indexFieldDoc) (fields []f.IndexField, err error) {
+	for _, doc := range docs {
+		field := f.IndexField{Binding: doc.Binding, Reverse: doc.Reverse}
+
+		switch path := doc.Field.(type) {
+		case f.StringV:
+			field.Field = []string{string(path)}
+		case f.ArrayV:
+			if err = path.Get(&field.Field); err != nil {
+				return
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return
+}
+
+func (in *inspector) functions() (functions []Function, err error) {
+	docs, err := in.all(f.Functions(), f.Lambda("ref", f.Get(f.Var("ref"))))
+	if err != nil {
+		return
+	}
+
+	for _, value := range docs {
+		var doc struct {
+			Name string   `fauna:"name"`
+			Body f.QueryV `fauna:"body"`
+		}
+
+		if err = value.Get(&doc); err != nil {
+			return
+		}
+
+		functions = append(functions, Function{Name: doc.Name, Params: lambdaParams(doc.Body)})
+	}
+
+	sort.Slice(functions, func(i, j int) bool { return functions[i].Name < functions[j].Name })
+	return
+}
+
+// lambdaParams returns the parameters of a lambda, or nil if they are not a name or a list of names.
+func lambdaParams(body f.QueryV) []string {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil
+	}
+
+	var query struct {
+		Lambda struct {
+			Params json.RawMessage `json:"lambda"`
+		} `json:"@query"`
+	}
+
+	if err := json.Unmarshal(raw, &query); err != nil {
+		return nil
+	}
+
+	var param string
+
+	if err := json.Unmarshal(query.Lambda.Params, &param); err == nil {
+		return []string{param}
+	}
+
+	var params []string
+
+	if err := json.Unmarshal(query.Lambda.Params, &params); err == nil && params != nil {
+		return params
+	}
+
+	return nil
+}
+
+// all returns the elements of a set, mapped with the given lambda if any, reading them page by page.
+func (in *inspector) all(set, lambda f.Expr) (elems []f.Value, err error) {
+	options := []f.OptionalParameter{f.Size(pageSize)}
+
+	for {
+		var res f.Value
+
+		page := f.Paginate(set, options...)
+
+		if lambda != nil {
+			page = f.Map(page, lambda)
+		}
+
+		if res, err = in.client.Query(page); err != nil {
+			return
+		}
+
+		var data []f.Value
+
+		if err = res.At(dataField).Get(&data); err != nil {
+			return nil, fmt.Errorf("codegen: invalid page %v: %s", res, err)
+		}
+
+		elems = append(elems, data...)
+
+		after, afterErr := res.At(afterField).GetValue()
+		if afterErr != nil {
+			return
+		}
+
+		options = []f.OptionalParameter{f.Size(pageSize), f.After(after)}
+	}
+}
@@ -0,0 +1,198 @@
+package codegen
+
+import (
+	"testing"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/fauna/faunadb-go/faunadb/faunadbmock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	usersRef = `{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}}}}`
+	userRef  = `{"@ref":{"id":"1","collection":` + usersRef + `}}`
+)
+
+func getAll(set f.Expr, options ...f.OptionalParameter) f.Expr {
+	return f.Map(f.Paginate(set, append([]f.OptionalParameter{f.Size(pageSize)}, options...)...), f.Lambda("ref", f.Get(f.Var("ref"))))
+}
+
+func TestInspect(t *testing.T) {
+	mock := faunadbmock.New()
+
+	mock.ExpectQuery(f.Paginate(f.Collections(), f.Size(pageSize))).ReturnJSON(`{"data":[` + usersRef + `]}`)
+	mock.ExpectQuery(f.Map(
+		f.Paginate(f.Documents(f.Collection("users")), f.Size(2)),
+		f.Lambda("ref", f.Select("data", f.Get(f.Var("ref")), f.Default(f.Obj{}))),
+	)).ReturnJSON(`{"data":[
+		{"email":"jane@example.com","age":42,"tags":[],"address":{"city":"Paris"}},
+		{"email":"john@example.com","age":4.5,"tags":["a"],"manager":` + userRef + `,"address":{"zip":null}}
+	]}`)
+	mock.ExpectQuery(getAll(f.Indexes())).ReturnJSON(`{"data":[{"name":"users_by_email"}],"after":[{"@ref":{"id":"users_by_email"}}]}`)
+	mock.ExpectQuery(getAll(f.Indexes(), f.After(f.ArrayV{f.RefV{ID: "users_by_email"}}))).ReturnJSON(`{"data":[{
+		"name":"users_by_tag",
+		"source":` + usersRef + `,
+		"terms":[{"field":["data","tags"]}],
+		"values":[{"field":"ref"},{"binding":"upper","reverse":true}]
+	}]}`)
+	mock.ExpectQuery(getAll(f.Functions())).ReturnJSON(`{"data":[
+		{"name":"create_user","body":{"@query":{"lambda":["email","_"],"expr":null}}},
+		{"name":"now","body":{"@query":{"lambda":"_","expr":null}}}
+	]}`)
+
+	schema, err := Inspect(mock, SampleSize(2))
+	require.NoError(t, err)
+	mock.AssertExpectations(t)
+
+	require.Equal(t, Schema{
+		Collections: []Collection{{Name: "users", Data: &Type{Kind: Object, Fields: map[string]*Type{
+			"email":   {Kind: String},
+			"age":     {Kind: Double},
+			"tags":    {Kind: Array, Elem: &Type{Kind: String}},
+			"manager": {Kind: Ref},
+			"address": {Kind: Object, Fields: map[string]*Type{"city": {Kind: String}, "zip": nil}},
+		}}}},
+		Indexes: []Index{
+			{Name: "users_by_email"},
+			{
+				Name:   "users_by_tag",
+				Source: "users",
+				Terms:  []f.IndexField{f.FieldPath("data", "tags")},
+				Values: []f.IndexField{f.FieldPath("ref"), f.BindingField("upper").Reversed()},
+			},
+		},
+		Functions: []Function{{Name: "create_user", Params: []string{"email", "_"}}, {Name: "now", Params: []string{"_"}}},
+	}, schema)
+}
+
+func TestGenerate(t *testing.T) {
+	schema := Schema{
+		Collections: []Collection{
+			{Name: "users", Data: &Type{Kind: Object, Fields: map[string]*Type{
+				"email":      {Kind: String},
+				"user_id":    {Kind: Long},
+				"born":       {Kind: Date},
+				"tags":       {Kind: Array, Elem: &Type{Kind: String}},
+				"manager":    {Kind: Ref},
+				"extra":      nil,
+				"addresses":  {Kind: Array, Elem: &Type{Kind: Object, Fields: map[string]*Type{"city": {Kind: String}}}},
+				"preference": {Kind: Object, Fields: map[string]*Type{"theme": {Kind: Any}}},
+			}}},
+			{Name: "categories"},
+		},
+		Indexes: []Index{
+			{
+				Name:   "users_by_tag_and_type",
+				Source: "users",
+				Terms:  []f.IndexField{f.FieldPath("data", "tags"), f.FieldPath("data", "type")},
+				Values: []f.IndexField{f.FieldPath("data", "addresses"), f.FieldPath("ref").Reversed(), f.BindingField("upper")},
+			},
+			{Name: "all_categories"},
+		},
+		Functions: []Function{
+			{Name: "create_user", Params: []string{"email", "_"}},
+			{Name: "ping"},
+		},
+	}
+
+	source, err := Generate(schema, "models")
+	require.NoError(t, err)
+	require.Equal(t, `// Code generated by faunagen. DO NOT EDIT.
+
+package models
+
+import (
+	"time"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+// User is the data of a document of the users collection.
+type User struct {
+	Addresses  []UserAddress  `+"`fauna:\"addresses\"`"+`
+	Born       time.Time      `+"`fauna:\"born\"`"+`
+	Email      string         `+"`fauna:\"email\"`"+`
+	Extra      f.Value        `+"`fauna:\"extra\"`"+`
+	Manager    f.RefV         `+"`fauna:\"manager\"`"+`
+	Preference UserPreference `+"`fauna:\"preference\"`"+`
+	Tags       []string       `+"`fauna:\"tags\"`"+`
+	UserID     int64          `+"`fauna:\"user_id\"`"+`
+}
+
+// UserAddress is an element of the addresses field of User.
+type UserAddress struct {
+	City string `+"`fauna:\"city\"`"+`
+}
+
+// UserPreference is the preference field of User.
+type UserPreference struct {
+	Theme f.Value `+"`fauna:\"theme\"`"+`
+}
+
+// Category is the data of a document of the categories collection.
+type Category struct {
+}
+
+// UsersByTagAndTypeIndex is the users_by_tag_and_type index.
+var UsersByTagAndTypeIndex = f.IndexDef{
+	Name:   "users_by_tag_and_type",
+	Source: f.Collection("users"),
+	Terms:  []f.IndexField{f.FieldPath("data", "tags"), f.FieldPath("data", "type")},
+	Values: []f.IndexField{f.FieldPath("data", "addresses"), f.FieldPath("ref").Reversed(), f.BindingField("upper")},
+}
+
+// UsersByTagAndType returns the entries of the users_by_tag_and_type index matching the given terms.
+func UsersByTagAndType(tags string, typeTerm f.Value) f.Expr {
+	return UsersByTagAndTypeIndex.Match(tags, typeTerm)
+}
+
+// UsersByTagAndTypeEntry holds the values of an entry of the users_by_tag_and_type index, decoded with UsersByTagAndTypeIndex.Decode.
+type UsersByTagAndTypeEntry struct {
+	Addresses UserAddress
+	Ref       f.RefV
+	Upper     f.Value
+}
+
+// AllCategoriesIndex is the all_categories index.
+var AllCategoriesIndex = f.IndexDef{
+	Name: "all_categories",
+}
+
+// AllCategories returns the entries of the all_categories index.
+func AllCategories() f.Expr {
+	return AllCategoriesIndex.Match()
+}
+
+// CreateUserArgs holds the arguments of the create_user function.
+type CreateUserArgs struct {
+	Email interface{}
+	Arg1  interface{}
+}
+
+// CreateUser calls the create_user function.
+func CreateUser(args CreateUserArgs) f.Expr {
+	return f.Call(f.Function("create_user"), args.Email, args.Arg1)
+}
+
+// Ping calls the ping function with the given arguments.
+func Ping(args ...interface{}) f.Expr {
+	return f.Call(f.Function("ping"), args...)
+}
+`, string(source))
+}
+
+func TestGenerateConflicts(t *testing.T) {
+	_, err := Generate(Schema{Collections: []Collection{{Name: "users"}}, Functions: []Function{{Name: "user"}}}, "models")
+	require.EqualError(t, err, "codegen: User is generated for both the users collection and the user function")
+}
+
+func TestNames(t *testing.T) {
+	require.Equal(t, "UsersByEmail", goName("users_by_email"))
+	require.Equal(t, "UserID", goName("userId"))
+	require.Equal(t, "X2fa", goName("2fa"))
+	require.Equal(t, "id", paramName("id"))
+	require.Equal(t, "funcTerm", paramName("func"))
+	require.Equal(t, "category", singular("categories"))
+	require.Equal(t, "address", singular("addresses"))
+	require.Equal(t, "status", singular("status"))
+}
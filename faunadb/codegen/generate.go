@@ -0,0 +1,474 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+// Words written in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"api": true, "db": true, "html": true, "http": true, "id": true, "ip": true, "json": true,
+	"sql": true, "ts": true, "ttl": true, "uri": true, "url": true, "uuid": true,
+}
+
+// Generate returns the Go source of a file of the given package declaring types and functions for
+// the schema. See the package documentation for what is generated.
+func Generate(schema Schema, pkg string) ([]byte, error) {
+	g := &generator{declared: make(map[string]string), types: make(map[string]*Type)}
+
+	for _, collection := range schema.Collections {
+		g.collection(collection)
+	}
+
+	for _, index := range schema.Indexes {
+		g.index(index)
+	}
+
+	for _, function := range schema.Functions {
+		g.function(function)
+	}
+
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	var file bytes.Buffer
+
+	fmt.Fprintf(&file, "// Code generated by faunagen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+
+	if g.usesTime || g.usesFauna {
+		file.WriteString("import (\n")
+
+		if g.usesTime {
+			file.WriteString("\t\"time\"\n\n")
+		}
+
+		if g.usesFauna {
+			file.WriteString("\tf \"github.com/fauna/faunadb-go/faunadb\"\n")
+		}
+
+		file.WriteString(")\n")
+	}
+
+	file.Write(g.body.Bytes())
+
+	source, err := format.Source(file.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("codegen: invalid generated code: %s\n%s", err, file.Bytes())
+	}
+
+	return source, nil
+}
+
+type generator struct {
+	body      bytes.Buffer
+	declared  map[string]string // Declared identifiers, along with what they were declared for
+	types     map[string]*Type  // Data types of collections by collection name
+	usesTime  bool
+	usesFauna bool
+	err       error
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+// declare reserves an identifier, failing if it was already declared for something else.
+func (g *generator) declare(name, what string) {
+	if previous, ok := g.declared[name]; ok && g.err == nil {
+		g.err = fmt.Errorf("codegen: %s is generated for both %s and %s", name, previous, what)
+	}
+
+	g.declared[name] = what
+}
+
+func (g *generator) collection(collection Collection) {
+	data := collection.Data
+
+	if data == nil || data.Kind != Object {
+		data = &Type{Kind: Object}
+	}
+
+	g.types[collection.Name] = data
+	g.structType(goName(singular(collection.Name)), data,
+		fmt.Sprintf("is the data of a document of the %s collection", collection.Name),
+		fmt.Sprintf("the %s collection", collection.Name))
+}
+
+// structType declares a struct for an object type, along with the structs of its nested objects.
+func (g *generator) structType(name string, object *Type, doc, what string) {
+	g.declare(name, what)
+
+	keys := make([]string, 0, len(object.Fields))
+
+	for key := range object.Fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var nested []string
+	fields := newNames()
+
+	g.printf("\n// %s %s.\ntype %s struct {\n", name, doc, name)
+
+	for _, key := range keys {
+		fieldType := object.Fields[key]
+
+		if elem := elemOf(fieldType); elem != nil && elem.Kind == Object {
+			nested = append(nested, key)
+		}
+
+		g.printf("\t%s %s `fauna:%q`\n", fields.unique(goName(key)), g.goType(fieldType, nestedName(name, key, fieldType)), key)
+	}
+
+	g.printf("}\n")
+
+	for _, key := range nested {
+		doc := fmt.Sprintf("is the %s field of %s", key, name)
+
+		if object.Fields[key].Kind == Array {
+			doc = fmt.Sprintf("is an element of the %s field of %s", key, name)
+		}
+
+		g.structType(nestedName(name, key, object.Fields[key]), elemOf(object.Fields[key]), doc,
+			fmt.Sprintf("the %s field of %s", key, name))
+	}
+}
+
+// nestedName returns the name of the struct of an object field, or of the elements of an array field.
+func nestedName(parent, key string, t *Type) string {
+	if t != nil && t.Kind == Array {
+		return parent + goName(singular(key))
+	}
+
+	return parent + goName(key)
+}
+
+// elemOf returns the type of the elements of arrays, or the type itself for other types.
+func elemOf(t *Type) *Type {
+	for t != nil && t.Kind == Array {
+		t = t.Elem
+	}
+
+	return t
+}
+
+// goType returns the Go type of the values of a type. Objects are declared as structs with the given
+// name.
+func (g *generator) goType(t *Type, name string) string {
+	if t == nil {
+		g.usesFauna = true
+		return "f.Value"
+	}
+
+	switch t.Kind {
+	case String:
+		return "string"
+	case Long:
+		return "int64"
+	case Double:
+		return "float64"
+	case Boolean:
+		return "bool"
+	case Bytes:
+		return "[]byte"
+	case Time, Date:
+		g.usesTime = true
+		return "time.Time"
+	case Object:
+		return name
+	case Array:
+		return "[]" + g.goType(t.Elem, name)
+	}
+
+	g.usesFauna = true
+
+	switch t.Kind {
+	case Ref:
+		return "f.RefV"
+	case Set:
+		return "f.SetRefV"
+	case Query:
+		return "f.QueryV"
+	default:
+		return "f.Value"
+	}
+}
+
+// indexFieldType returns the Go type of an index term or value. Arrays are indexed by element, so
+// the type of their elements is returned.
+func (g *generator) indexFieldType(index Index, field []string) string {
+	switch {
+	case len(field) == 1 && field[0] == "ref":
+		return g.goType(&Type{Kind: Ref}, "")
+	case len(field) == 1 && field[0] == "ts":
+		return "int64"
+	case len(field) < 2 || field[0] != "data":
+		return g.goType(nil, "")
+	}
+
+	data, ok := g.types[index.Source]
+	if !ok {
+		return g.goType(nil, "")
+	}
+
+	name := goName(singular(index.Source))
+	t := data
+
+	for _, key := range field[1:] {
+		if t = elemOf(t); t == nil || t.Kind != Object {
+			return g.goType(nil, "")
+		}
+
+		name, t = nestedName(name, key, t.Fields[key]), t.Fields[key]
+	}
+
+	return g.goType(elemOf(t), name)
+}
+
+func (g *generator) index(index Index) {
+	name := goName(index.Name)
+	def := name + "Index"
+	what := fmt.Sprintf("the %s index", index.Name)
+
+	g.usesFauna = true
+	g.declare(def, what)
+	g.declare(name, what)
+
+	g.printf("\n// %s is the %s index.\nvar %s = f.IndexDef{\n\tName: %q,\n", def, index.Name, def, index.Name)
+
+	if index.Source != "" {
+		g.printf("\tSource: f.Collection(%q),\n", index.Source)
+	}
+
+	if len(index.Terms) > 0 {
+		g.printf("\tTerms: %s,\n", indexFieldsSource(index.Terms))
+	}
+
+	if len(index.Values) > 0 {
+		g.printf("\tValues: %s,\n", indexFieldsSource(index.Values))
+	}
+
+	g.printf("}\n")
+
+	params := newNames()
+	var args, terms []string
+
+	for _, term := range index.Terms {
+		param := params.unique(paramName(indexFieldName(term)))
+		args = append(args, param+" "+g.indexFieldType(index, term.Field))
+		terms = append(terms, param)
+	}
+
+	if len(terms) == 0 {
+		g.printf("\n// %s returns the entries of the %s index.\n", name, index.Name)
+	} else {
+		g.printf("\n// %s returns the entries of the %s index matching the given terms.\n", name, index.Name)
+	}
+
+	g.printf("func %s(%s) f.Expr {\n\treturn %s.Match(%s)\n}\n", name, strings.Join(args, ", "), def, strings.Join(terms, ", "))
+
+	if len(index.Values) == 0 {
+		return
+	}
+
+	entry := name + "Entry"
+	g.declare(entry, what)
+
+	fields := newNames()
+
+	g.printf("\n// %s holds the values of an entry of the %s index, decoded with %s.Decode.\n", entry, index.Name, def)
+	g.printf("type %s struct {\n", entry)
+
+	for _, value := range index.Values {
+		g.printf("\t%s %s\n", fields.unique(goName(indexFieldName(value))), g.indexFieldType(index, value.Field))
+	}
+
+	g.printf("}\n")
+}
+
+func indexFieldName(field f.IndexField) string {
+	if field.Binding != "" {
+		return field.Binding
+	}
+
+	if len(field.Field) == 0 {
+		return "value"
+	}
+
+	return field.Field[len(field.Field)-1]
+}
+
+func indexFieldsSource(fields []f.IndexField) string {
+	sources := make([]string, len(fields))
+
+	for i, field := range fields {
+		if field.Binding != "" {
+			sources[i] = fmt.Sprintf("f.BindingField(%q)", field.Binding)
+		} else {
+			path := make([]string, len(field.Field))
+
+			for j, key := range field.Field {
+				path[j] = strconv.Quote(key)
+			}
+
+			sources[i] = fmt.Sprintf("f.FieldPath(%s)", strings.Join(path, ", "))
+		}
+
+		if field.Reverse {
+			sources[i] += ".Reversed()"
+		}
+	}
+
+	return fmt.Sprintf("[]f.IndexField{%s}", strings.Join(sources, ", "))
+}
+
+func (g *generator) function(function Function) {
+	name := goName(function.Name)
+	what := fmt.Sprintf("the %s function", function.Name)
+
+	g.usesFauna = true
+	g.declare(name, what)
+
+	if function.Params == nil {
+		g.printf("\n// %s calls the %s function with the given arguments.\n", name, function.Name)
+		g.printf("func %s(args ...interface{}) f.Expr {\n\treturn f.Call(f.Function(%q), args...)\n}\n", name, function.Name)
+		return
+	}
+
+	argsType := name + "Args"
+	g.declare(argsType, what)
+
+	fields := newNames()
+	args := make([]string, len(function.Params))
+
+	g.printf("\n// %s holds the arguments of the %s function.\ntype %s struct {\n", argsType, function.Name, argsType)
+
+	for i, param := range function.Params {
+		if param == "_" {
+			param = fmt.Sprintf("arg%d", i)
+		}
+
+		field := fields.unique(goName(param))
+		args[i] = "args." + field
+
+		g.printf("\t%s interface{}\n", field)
+	}
+
+	g.printf("}\n")
+
+	g.printf("\n// %s calls the %s function.\n", name, function.Name)
+	g.printf("func %s(args %s) f.Expr {\n\treturn f.Call(%s)\n}\n", name, argsType,
+		strings.Join(append([]string{fmt.Sprintf("f.Function(%q)", function.Name)}, args...), ", "))
+}
+
+// names makes identifiers unique within a scope, such as the fields of a struct.
+type names map[string]bool
+
+func newNames() names { return make(names) }
+
+func (n names) unique(name string) string {
+	unique := name
+
+	for i := 2; n[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+
+	n[unique] = true
+	return unique
+}
+
+// goName converts a FaunaDB name, such as users_by_email, to an exported Go identifier.
+func goName(name string) string {
+	var buffer bytes.Buffer
+
+	for _, word := range words(name) {
+		if initialisms[strings.ToLower(word)] {
+			buffer.WriteString(strings.ToUpper(word))
+			continue
+		}
+
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		buffer.WriteString(string(runes))
+	}
+
+	ident := buffer.String()
+
+	if ident == "" || !unicode.IsLetter([]rune(ident)[0]) {
+		ident = "X" + ident
+	}
+
+	return ident
+}
+
+// words splits a name into words, separated by other characters than letters and digits, or starting
+// with an upper case letter following a lower case one.
+func words(name string) (words []string) {
+	var word []rune
+	var previous rune
+
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) || unicode.IsUpper(r) && unicode.IsLower(previous) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+			}
+
+			word = nil
+		}
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+		}
+
+		previous = r
+	}
+
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	return
+}
+
+// paramName converts a FaunaDB name to an unexported Go identifier.
+func paramName(name string) string {
+	ident := goName(name)
+
+	if initialisms[strings.ToLower(ident)] {
+		ident = strings.ToLower(ident)
+	} else {
+		runes := []rune(ident)
+		runes[0] = unicode.ToLower(runes[0])
+		ident = string(runes)
+	}
+
+	if token.Lookup(ident).IsKeyword() {
+		ident += "Term"
+	}
+
+	return ident
+}
+
+// singular returns the singular of an English plural, such as a collection name.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"), strings.HasSuffix(name, "ches"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && !strings.HasSuffix(name, "us"):
+		return name[:len(name)-1]
+	default:
+		return name
+	}
+}
@@ -0,0 +1,185 @@
+package faunadb
+
+import (
+	"fmt"
+	"reflect"
+)
+
+const (
+	conflictCode = "faunadb_conflict"
+	currentTSVar = "faunadb_current_ts"
+)
+
+/*
+ErrConflict describes the conflict that aborted a query evaluating UpdateIfUnchanged or
+ReplaceIfUnchanged, when the document was changed since the expected timestamp. The client returns
+such errors as an ErrConflict wrapping the BadRequest returned for other aborted queries, so they
+match both with errors.Is and errors.As. For example:
+
+	_, err := client.Query(UpdateIfUnchanged(ref, doc.TS, Obj{"data": Obj{"balance": 10}}))
+
+	var conflict ErrConflict
+
+	if errors.As(err, &conflict) {
+		// The document was changed at conflict.Actual, read it again
+	}
+*/
+type ErrConflict struct {
+	Expected int64 // Timestamp the document was expected to have
+	Actual   int64 // Timestamp of the document when the query was evaluated
+	Err      error
+}
+
+func (err ErrConflict) Error() string {
+	return fmt.Sprintf("faunadb: conflict, expected document at ts %d but found ts %d", err.Expected, err.Actual)
+}
+
+// Unwrap returns the error returned by FaunaDB.
+func (err ErrConflict) Unwrap() error { return err.Err }
+
+// Is reports whether the target is an ErrConflict, so that errors.Is(err, ErrConflict{}) matches any conflict.
+func (err ErrConflict) Is(target error) bool { _, ok := target.(ErrConflict); return ok }
+
+// IsConflict reports whether err, or any error it wraps, was caused by UpdateIfUnchanged or
+// ReplaceIfUnchanged finding a changed document.
+func IsConflict(err error) bool {
+	_, ok := AsConflict(err)
+	return ok
+}
+
+// AsConflict returns the conflict described by err, or any error it wraps, if it was caused by
+// UpdateIfUnchanged or ReplaceIfUnchanged finding a changed document.
+func AsConflict(err error) (conflict ErrConflict, ok bool) {
+	for wrapped := err; wrapped != nil; {
+		if conflict, ok = wrapped.(ErrConflict); ok {
+			return
+		}
+
+		wrapper, isWrapper := wrapped.(interface{ Unwrap() error })
+		if !isWrapper {
+			break
+		}
+
+		wrapped = wrapper.Unwrap()
+	}
+
+	payload, found := AbortPayload(err)
+	if !found {
+		return
+	}
+
+	var code string
+
+	if payload.At(ObjKey("code")).Get(&code) != nil || code != conflictCode {
+		return
+	}
+
+	conflict = ErrConflict{Err: err}
+	_ = payload.At(ObjKey("expected")).Get(&conflict.Expected)
+	_ = payload.At(ObjKey("actual")).Get(&conflict.Actual)
+
+	return conflict, true
+}
+
+// UpdateIfUnchanged updates the document only if its timestamp is still ts, which is usually the ts
+// field of the document when it was read. Otherwise, the query is aborted with an ErrConflict.
+func UpdateIfUnchanged(ref, ts, params interface{}) Expr {
+	return ifUnchanged(ref, ts, Update(ref, params))
+}
+
+// ReplaceIfUnchanged replaces the document only if its timestamp is still ts, which is usually the ts
+// field of the document when it was read. Otherwise, the query is aborted with an ErrConflict.
+func ReplaceIfUnchanged(ref, ts, params interface{}) Expr {
+	return ifUnchanged(ref, ts, Replace(ref, params))
+}
+
+func ifUnchanged(ref, ts interface{}, write Expr) Expr {
+	current := Var(currentTSVar)
+
+	return Let().Bind(currentTSVar, Select("ts", Get(ref))).In(
+		If(Equals(current, ts),
+			write,
+			AbortWith(Obj{"code": conflictCode, "expected": ts, "actual": current}),
+		),
+	)
+}
+
+/*
+UpdateWithRetry implements an optimistic read-modify-write loop. It reads the document, decodes its
+data into dest if it's a pointer, and calls mutate with the document to obtain the parameters given
+to Update. The
+update is written with UpdateIfUnchanged, and the whole process is retried if the document was
+changed concurrently, up to maxAttempts times. It returns the updated document, with its data decoded
+into dest, or the error of the last conflict if all attempts failed. For example:
+
+	var account Account
+
+	_, err := client.UpdateWithRetry(ref, &account, 5, func(doc Document[Value]) (interface{}, error) {
+		if account.Balance < amount {
+			return nil, ErrInsufficientFunds
+		}
+
+		return Obj{"data": Obj{"balance": account.Balance - amount}}, nil
+	})
+
+Errors returned by mutate stop the loop and are returned as is.
+*/
+func (client *FaunaClient) UpdateWithRetry(ref interface{}, dest interface{}, maxAttempts int, mutate func(doc Document[Value]) (params interface{}, err error)) (doc Document[Value], err error) {
+	return UpdateWithRetryOf(client, ref, dest, maxAttempts, mutate)
+}
+
+// UpdateWithRetryOf is like FaunaClient.UpdateWithRetry, issuing its queries with the given querier.
+func UpdateWithRetryOf(client Querier, ref interface{}, dest interface{}, maxAttempts int, mutate func(doc Document[Value]) (params interface{}, err error)) (doc Document[Value], err error) {
+	for attempt := 1; ; attempt++ {
+		var res Value
+
+		if res, err = client.Query(Get(ref)); err != nil {
+			return
+		}
+
+		doc = Document[Value]{}
+
+		if err = res.Get(&doc); err != nil {
+			return
+		}
+
+		if err = decodeData(doc, dest); err != nil {
+			return
+		}
+
+		var params interface{}
+
+		if params, err = mutate(doc); err != nil {
+			return
+		}
+
+		res, err = client.Query(UpdateIfUnchanged(ref, doc.TS, params))
+
+		if err == nil {
+			doc = Document[Value]{}
+
+			if err = res.Get(&doc); err == nil {
+				err = decodeData(doc, dest)
+			}
+
+			return
+		}
+
+		if !IsConflict(err) || attempt >= maxAttempts {
+			return
+		}
+	}
+}
+
+// decodeData decodes the data of a document into dest, if dest is a pointer.
+func decodeData(doc Document[Value], dest interface{}) error {
+	if doc.Data == nil || reflect.ValueOf(dest).Kind() != reflect.Ptr {
+		return nil
+	}
+
+	if err := doc.Data.Get(dest); err != nil {
+		return DecodeError{path: pathFromKeys("data"), err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package faunadb
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const conflictResponse = `{"errors":[{"position":[],"code":"transaction aborted",` +
+	`"description":"faunadb-payload:{\"actual\":20,\"code\":\"faunadb_conflict\",\"expected\":10}"}]}`
+
+func TestSerializeUpdateIfUnchanged(t *testing.T) {
+	query, err := json.Marshal(UpdateIfUnchanged(RefCollection(Collection("users"), "42"), 10, Obj{"data": Obj{"age": 43}}))
+	require.NoError(t, err)
+
+	require.Contains(t, string(query), `"let":[{"faunadb_current_ts":{"from":{"get":{"id":"42","ref":{"collection":"users"}}},"select":"ts"}}]`)
+	require.Contains(t, string(query), `"if":{"equals":[{"var":"faunadb_current_ts"},10]}`)
+	require.Equal(t, 1, strings.Count(string(query), `"get"`))
+	require.Contains(t, string(query), `"then":{"params":{"object":{"data":{"object":{"age":43}}}},"update":{"id":"42","ref":{"collection":"users"}}}`)
+	require.Contains(t, string(query), `,\"code\":\"faunadb_conflict\",\"expected\":10}`)
+
+	query, err = json.Marshal(ReplaceIfUnchanged(RefCollection(Collection("users"), "42"), 10, Obj{"data": Obj{"age": 43}}))
+	require.NoError(t, err)
+	require.Contains(t, string(query), `"then":{"params":{"object":{"data":{"object":{"age":43}}}},"replace":{"id":"42","ref":{"collection":"users"}}}`)
+}
+
+func TestConflictError(t *testing.T) {
+	server, client := newTestServer(respondWith(400, conflictResponse))
+	defer server.Close()
+
+	_, err := client.Query(UpdateIfUnchanged(RefCollection(Collection("users"), "42"), 10, Obj{}))
+
+	require.IsType(t, ErrConflict{}, err)
+	require.True(t, errors.Is(err, ErrConflict{}))
+	require.True(t, errors.Is(err, BadRequest{}))
+	require.True(t, IsConflict(err))
+	require.True(t, IsAborted(err))
+
+	var conflict ErrConflict
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, int64(10), conflict.Expected)
+	require.Equal(t, int64(20), conflict.Actual)
+	require.IsType(t, BadRequest{}, conflict.Unwrap())
+	require.EqualError(t, conflict, "faunadb: conflict, expected document at ts 10 but found ts 20")
+
+	wrapped, ok := AsConflict(err)
+	require.True(t, ok)
+	require.Equal(t, conflict, wrapped)
+}
+
+func TestOtherAbortsAreNotConflicts(t *testing.T) {
+	server, client := newTestServer(respondWith(400,
+		`{"errors":[{"position":[],"code":"transaction aborted","description":"faunadb-payload:{\"code\":\"insufficient_funds\"}"}]}`))
+	defer server.Close()
+
+	_, err := client.Query(Abort("insufficient funds"))
+
+	require.IsType(t, BadRequest{}, err)
+	require.False(t, IsConflict(err))
+	require.False(t, errors.Is(err, ErrConflict{}))
+}
+
+func TestUpdateWithRetry(t *testing.T) {
+	var queries []string
+
+	responses := []string{
+		`{"resource":` + repositoryDoc + `}`,
+		conflictResponse,
+		`{"resource":` + repositoryDoc + `}`,
+		`{"resource":` + repositoryDoc + `}`,
+	}
+
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		queries = append(queries, string(body))
+
+		response := responses[0]
+		responses = responses[1:]
+
+		if response == conflictResponse {
+			w.WriteHeader(400)
+		}
+
+		_, _ = io.WriteString(w, response)
+	})
+	defer server.Close()
+
+	var user repositoryUser
+	attempts := 0
+
+	doc, err := client.UpdateWithRetry(RefCollection(Collection("users"), "42"), &user, 3, func(doc Document[Value]) (interface{}, error) {
+		attempts++
+		require.Equal(t, int64(10), doc.TS)
+		return Obj{"data": Obj{"age": user.Age + 1}}, nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts)
+	require.Equal(t, "42", doc.Ref.ID)
+	require.Equal(t, 42, user.Age)
+	require.Len(t, queries, 4)
+	require.Equal(t, `{"get":{"id":"42","ref":{"collection":"users"}}}`, queries[2])
+	require.Contains(t, queries[3], `"update":{"id":"42","ref":{"collection":"users"}}`)
+	require.Contains(t, queries[3], `"params":{"object":{"data":{"object":{"age":43}}}}`)
+}
+
+func TestUpdateWithRetryGivesUp(t *testing.T) {
+	responses := []string{`{"resource":` + repositoryDoc + `}`, conflictResponse}
+	requests := 0
+
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		response := responses[requests%2]
+		requests++
+
+		if response == conflictResponse {
+			w.WriteHeader(400)
+		}
+
+		_, _ = io.WriteString(w, response)
+	})
+	defer server.Close()
+
+	_, err := client.UpdateWithRetry(RefCollection(Collection("users"), "42"), nil, 2, func(doc Document[Value]) (interface{}, error) {
+		return Obj{}, nil
+	})
+
+	require.True(t, IsConflict(err))
+	require.Equal(t, 4, requests)
+}
@@ -497,3 +497,14 @@ func decodeJSON(raw string, target interface{}) (err error) {
 
 	return
 }
+
+func TestUnmarshalJSONExpr(t *testing.T) {
+	var expr Expr
+
+	require.NoError(t, UnmarshalJSONExpr([]byte(
+		`{"map":{"lambda":"x","expr":{"add":[{"var":"x"},1.5]}},"collection":{"object":{"a":{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}}}}}}}`,
+	), &expr))
+
+	require.Equal(t, `Map({ a: Collection("users") }, Lambda("x", Add(Var("x"), 1.5)))`, FQL(expr))
+	require.Error(t, UnmarshalJSONExpr([]byte(`{"get":`), &expr))
+}
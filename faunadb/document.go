@@ -0,0 +1,47 @@
+package faunadb
+
+import "time"
+
+/*
+Document is the envelope of a document, as returned by Get, Create or Update. Value.Get decodes the
+document's data into Data, of type T. For example:
+
+	var doc Document[User]
+
+	value, _ := client.Query(Get(Ref(Collection("users"), "42")))
+	_ = value.Get(&doc)
+
+	fmt.Println(doc.Ref.ID, doc.TS, doc.Data.Name)
+
+A Document[Value] keeps the document's data as a Value.
+*/
+type Document[T any] struct {
+	Ref  RefV       `fauna:"ref"`  // Ref of the document
+	TS   int64      `fauna:"ts"`   // Time of the document's last change, in microseconds since the epoch
+	Data T          `fauna:"data"` // Data of the document
+	TTL  *time.Time `fauna:"ttl"`  // Time the document expires, nil if it never expires
+}
+
+/*
+Page is a page of results, as returned by Paginate. Value.Get decodes the page's data into Data, as
+a slice of T. For example:
+
+	var page Page[User]
+
+	value, _ := client.Query(Map(
+		Paginate(Documents(Collection("users"))),
+		Lambda("ref", Select("data", Get(Var("ref")))),
+	))
+	_ = value.Get(&page)
+
+	if page.After != nil {
+		// Use After(page.After) to fetch the next page
+	}
+
+Documents can be decoded along with their refs and timestamps with a Page[Document[T]].
+*/
+type Page[T any] struct {
+	Data   []T   `fauna:"data"`   // Data of the page
+	Before Value `fauna:"before"` // Cursor of the previous page, nil if this is the first page
+	After  Value `fauna:"after"`  // Cursor of the next page, nil if this is the last page
+}
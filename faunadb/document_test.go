@@ -0,0 +1,90 @@
+package faunadb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type documentUser struct {
+	Name string `fauna:"name"`
+	Age  int    `fauna:"age"`
+}
+
+const (
+	userRef  = `{"@ref":{"id":"42","collection":{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}}}}}}`
+	userJSON = `{"ref":` + userRef + `,"ts":1603756800000000,"data":{"name":"Jane","age":42},"ttl":{"@ts":"2030-01-01T00:00:00Z"}}`
+)
+
+func decodedValue(t *testing.T, json string) Value {
+	var value Value
+	require.NoError(t, UnmarshalJSON([]byte(json), &value))
+	return value
+}
+
+func TestDecodeDocument(t *testing.T) {
+	var doc Document[documentUser]
+
+	require.NoError(t, decodedValue(t, userJSON).Get(&doc))
+	require.Equal(t, "42", doc.Ref.ID)
+	require.Equal(t, "users", doc.Ref.Collection.ID)
+	require.Equal(t, int64(1603756800000000), doc.TS)
+	require.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), *doc.TTL)
+	require.Equal(t, documentUser{"Jane", 42}, doc.Data)
+}
+
+func TestDecodeDocumentIntoPointer(t *testing.T) {
+	var doc Document[*documentUser]
+
+	require.NoError(t, decodedValue(t, userJSON).Get(&doc))
+	require.Equal(t, &documentUser{"Jane", 42}, doc.Data)
+}
+
+func TestDecodeDocumentAsValue(t *testing.T) {
+	var doc Document[Value]
+
+	require.NoError(t, decodedValue(t, `{"ref":`+userRef+`,"ts":1,"data":{"name":"Jane"}}`).Get(&doc))
+	require.Equal(t, ObjectV{"name": StringV("Jane")}, doc.Data)
+	require.Nil(t, doc.TTL)
+}
+
+func TestDecodeDocumentErrors(t *testing.T) {
+	err := decodedValue(t, `{"ref":`+userRef+`,"ts":1,"data":{"age":"old"}}`).Get(&Document[documentUser]{})
+	require.EqualError(t, err, `Error while decoding fauna value at: data / age. Can not assign value of type "faunadb.StringV" to a value of type "int"`)
+
+	err = decodedValue(t, `{"ref":"42","ts":1}`).Get(&Document[Value]{})
+	require.EqualError(t, err, `Error while decoding fauna value at: ref. Can not assign value of type "faunadb.StringV" to a value of type "faunadb.RefV"`)
+}
+
+func TestDecodePage(t *testing.T) {
+	var page Page[documentUser]
+
+	value := decodedValue(t, `{"data":[{"name":"Jane","age":42},{"name":"John","age":24}],"after":[`+userRef+`]}`)
+
+	require.NoError(t, value.Get(&page))
+	require.Equal(t, []documentUser{{"Jane", 42}, {"John", 24}}, page.Data)
+	require.Nil(t, page.Before)
+	require.Equal(t, ArrayV{RefV{"42", &RefV{"users", NativeCollections(), NativeCollections(), nil}, &RefV{"users", NativeCollections(), NativeCollections(), nil}, nil}}, page.After)
+}
+
+func TestDecodePageOfDocuments(t *testing.T) {
+	var page Page[Document[documentUser]]
+
+	require.NoError(t, decodedValue(t, `{"data":[`+userJSON+`],"before":[null]}`).Get(&page))
+	require.Len(t, page.Data, 1)
+	require.Equal(t, "42", page.Data[0].Ref.ID)
+	require.Equal(t, documentUser{"Jane", 42}, page.Data[0].Data)
+	require.Equal(t, ArrayV{NullV{}}, page.Before)
+	require.Nil(t, page.After)
+}
+
+func TestDecodeNestedDocument(t *testing.T) {
+	var result struct {
+		User Document[Value] `fauna:"user"`
+	}
+
+	require.NoError(t, decodedValue(t, `{"user":`+userJSON+`}`).Get(&result))
+	require.Equal(t, "42", result.User.Ref.ID)
+	require.Equal(t, ObjectV{"name": StringV("Jane"), "age": LongV(42)}, result.User.Data)
+}
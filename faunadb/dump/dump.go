@@ -0,0 +1,310 @@
+/*
+Package dump exports the content of a FaunaDB database to newline delimited JSON, and restores it.
+
+Every line of a dump is a document encoded with faunadb.MarshalJSON, so values keep their FaunaDB
+types, such as refs, timestamps, dates and bytes. The schema documents come first: collections,
+indexes, functions and roles, followed by the documents of every collection. For example:
+
+	{"history_days":30,"name":"users","ref":{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}}}},"ts":1603756800000000}
+	{"data":{"name":"Jane"},"ref":{"@ref":{"id":"1","collection":{"@ref":{"id":"users",...}}}},"ts":1603756800000000}
+
+All documents are read at the same snapshot time, so the dump is consistent even if the database is
+written to while it's exported. Keys, tokens and child databases are not exported.
+
+A dump can be resumed after a failure: the Progress reported after every page tells where to resume,
+and is saved next to the dump file by DumpFile.
+
+Restore recreates the schema and the documents of a dump in another database, keeping the ids of the
+documents. Collections and databases can be renamed along the way, in which case the refs stored in
+documents are updated as well.
+*/
+package dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+const defaultPageSize = 100
+
+var (
+	dataField  = f.ObjKey("data")
+	afterField = f.ObjKey("after")
+)
+
+// Schema document sets, in the order they are exported.
+var schemaSets = []struct {
+	name string
+	set  f.Expr
+}{
+	{"collections", f.Collections()},
+	{"indexes", f.Indexes()},
+	{"functions", f.Functions()},
+	{"roles", f.Roles()},
+}
+
+// Progress describes how much of a dump was written. It's used to resume an interrupted dump.
+type Progress struct {
+	TS         int64           `json:"ts"`                   // Snapshot time, in microseconds since the epoch
+	Offset     int64           `json:"offset"`               // Number of bytes written
+	Documents  int64           `json:"documents"`            // Number of documents written
+	Schema     bool            `json:"schema"`               // True once all schema documents were written
+	Done       []string        `json:"done,omitempty"`       // Collections, or schema sets until Schema is true, fully written
+	Collection string          `json:"collection,omitempty"` // Collection or schema set being written
+	After      json.RawMessage `json:"after,omitempty"`      // Cursor of the next page of the collection being written
+}
+
+func (progress Progress) isDone(collection string) bool {
+	for _, done := range progress.Done {
+		if done == collection {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Option configures a dump.
+type Option func(*dumper)
+
+// PageSize sets the number of documents read per query. Defaults to 100.
+func PageSize(size int) Option {
+	return func(d *dumper) { d.pageSize = size }
+}
+
+// Only restricts the documents exported to the ones of the given collections. Schema documents are
+// always exported.
+func Only(collections ...string) Option {
+	return func(d *dumper) {
+		d.only = make(map[string]bool, len(collections))
+
+		for _, collection := range collections {
+			d.only[collection] = true
+		}
+	}
+}
+
+// Resume continues a dump from the given progress. The writer must contain exactly the first
+// progress.Offset bytes of the interrupted dump.
+func Resume(progress Progress) Option {
+	return func(d *dumper) { d.progress = progress }
+}
+
+// OnProgress sets a function called after every page written. The dump stops if it returns an error.
+func OnProgress(fn func(Progress) error) Option {
+	return func(d *dumper) { d.onProgress = append(d.onProgress, fn) }
+}
+
+type dumper struct {
+	client     f.Querier
+	out        io.Writer
+	pageSize   int
+	only       map[string]bool
+	progress   Progress
+	checkpoint Progress // Last progress reported, consistent with the output
+	onProgress []func(Progress) error
+}
+
+/*
+Dump writes all the documents of the database to w, one per line, and returns the final progress.
+The snapshot time is taken when the dump starts, or from the progress given with Resume.
+
+If the dump fails, it returns the last progress reported, which can be given to Resume to continue
+the dump after truncating the output to progress.Offset bytes.
+*/
+func Dump(client f.Querier, w io.Writer, options ...Option) (Progress, error) {
+	d := &dumper{client: client, out: w, pageSize: defaultPageSize}
+
+	for _, option := range options {
+		option(d)
+	}
+
+	d.checkpoint = d.progress
+
+	if err := d.run(); err != nil {
+		return d.checkpoint, err
+	}
+
+	return d.progress, nil
+}
+
+func (d *dumper) run() (err error) {
+	if d.progress.TS == 0 {
+		var res f.Value
+
+		if res, err = d.client.Query(f.ToMicros(f.Now())); err != nil {
+			return
+		}
+
+		if err = res.Get(&d.progress.TS); err != nil {
+			return
+		}
+	}
+
+	if !d.progress.Schema {
+		for _, schema := range schemaSets {
+			if err = d.dumpSet(schema.name, schema.set); err != nil {
+				return
+			}
+		}
+
+		d.progress.Schema = true
+		d.progress.Done = nil
+
+		if err = d.report(); err != nil {
+			return
+		}
+	}
+
+	collections, err := d.collections()
+	if err != nil {
+		return
+	}
+
+	for _, collection := range collections {
+		if d.only != nil && !d.only[collection] {
+			continue
+		}
+
+		if err = d.dumpSet(collection, f.Documents(f.Collection(collection))); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// dumpSet writes the documents of a set, page by page, and reports the progress after every page.
+// Sets are identified by name in the progress.
+func (d *dumper) dumpSet(name string, set f.Expr) (err error) {
+	if d.progress.isDone(name) {
+		return
+	}
+
+	var after f.Value
+
+	if d.progress.Collection == name && len(d.progress.After) > 0 {
+		if err = f.UnmarshalJSON(d.progress.After, &after); err != nil {
+			return fmt.Errorf("dump: invalid cursor for %s: %s", name, err)
+		}
+	}
+
+	d.progress.Collection = name
+
+	for {
+		options := []f.OptionalParameter{f.TS(d.progress.TS), f.Size(d.pageSize)}
+
+		if after != nil {
+			options = append(options, f.After(after))
+		}
+
+		var res f.Value
+
+		res, err = d.client.Query(f.Map(
+			f.Paginate(set, options...),
+			f.Lambda("ref", f.Get(f.Var("ref"), f.TS(d.progress.TS))),
+		))
+		if err != nil {
+			return
+		}
+
+		var docs []f.Value
+
+		if err = res.At(dataField).Get(&docs); err != nil {
+			return
+		}
+
+		if err = d.write(docs); err != nil {
+			return
+		}
+
+		next, afterErr := res.At(afterField).GetValue()
+		if afterErr != nil {
+			break
+		}
+
+		after = next
+
+		if d.progress.After, err = f.MarshalJSON(after); err != nil {
+			return
+		}
+
+		if err = d.report(); err != nil {
+			return
+		}
+	}
+
+	d.progress.Done = append(d.progress.Done, name)
+	d.progress.Collection = ""
+	d.progress.After = nil
+
+	return d.report()
+}
+
+func (d *dumper) write(docs []f.Value) error {
+	for _, doc := range docs {
+		line, err := f.MarshalJSON(doc)
+		if err != nil {
+			return err
+		}
+
+		n, err := d.out.Write(append(line, '\n'))
+		d.progress.Offset += int64(n)
+
+		if err != nil {
+			return err
+		}
+
+		d.progress.Documents++
+	}
+
+	return nil
+}
+
+func (d *dumper) report() error {
+	d.checkpoint = d.progress
+	d.checkpoint.Done = append([]string(nil), d.progress.Done...)
+
+	for _, fn := range d.onProgress {
+		if err := fn(d.progress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collections returns the names of the collections existing at the snapshot time.
+func (d *dumper) collections() (names []string, err error) {
+	options := []f.OptionalParameter{f.TS(d.progress.TS), f.Size(d.pageSize)}
+
+	for {
+		var res f.Value
+
+		if res, err = d.client.Query(f.Paginate(f.Collections(), options...)); err != nil {
+			return
+		}
+
+		var refs []f.RefV
+
+		if err = res.At(dataField).Get(&refs); err != nil {
+			return
+		}
+
+		for _, ref := range refs {
+			names = append(names, ref.ID)
+		}
+
+		after, afterErr := res.At(afterField).GetValue()
+		if afterErr != nil {
+			break
+		}
+
+		options = []f.OptionalParameter{f.TS(d.progress.TS), f.Size(d.pageSize), f.After(after)}
+	}
+
+	return
+}
@@ -0,0 +1,183 @@
+package dump
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	usersRef  = `{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}}}}`
+	usersDoc  = `{"ref":` + usersRef + `,"ts":10,"name":"users","history_days":30}`
+	firstDoc  = `{"ref":{"@ref":{"id":"1","collection":` + usersRef + `}},"ts":20,"data":{"born":{"@date":"1990-01-01"}}}`
+	secondDoc = `{"ref":{"@ref":{"id":"2","collection":` + usersRef + `}},"ts":30,"data":{"avatar":{"@bytes":"AQI="}}}`
+	firstPage = `{"resource":{"data":[` + firstDoc + `],"after":[{"@ref":{"id":"2","collection":` + usersRef + `}}]}}`
+	lastPage  = `{"resource":{"data":[` + secondDoc + `]}}`
+	emptyPage = `{"resource":{"data":[]}}`
+)
+
+// fakeServer answers queries by looking for a fragment of their body. Responses containing errors are
+// sent with a 400 status.
+type fakeServer struct {
+	sync.Mutex
+	queries []string
+	answer  func(query string) string
+}
+
+func (server *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.Lock()
+	defer server.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	server.queries = append(server.queries, string(body))
+	response := server.answer(string(body))
+
+	if strings.Contains(response, "errors") {
+		w.WriteHeader(400)
+	}
+
+	_, _ = io.WriteString(w, response)
+}
+
+func newFakeServer(answer func(query string) string) (*httptest.Server, *fakeServer, *f.FaunaClient) {
+	fake := &fakeServer{answer: answer}
+	server := httptest.NewServer(fake)
+	return server, fake, f.NewFaunaClient("secret", f.Endpoint(server.URL))
+}
+
+func answerDatabase(failLastPage *bool) func(string) string {
+	return func(query string) string {
+		switch {
+		case strings.Contains(query, `"to_micros"`):
+			return `{"resource":1000}`
+		case strings.Contains(query, `"paginate":{"collections":null}`) && strings.Contains(query, `"map"`):
+			return `{"resource":{"data":[` + usersDoc + `]}}`
+		case strings.Contains(query, `"paginate":{"collections":null}`):
+			return `{"resource":{"data":[` + usersRef + `]}}`
+		case strings.Contains(query, `"documents"`) && strings.Contains(query, `"after"`):
+			if *failLastPage {
+				*failLastPage = false
+				return `{"errors":[{"code":"unavailable","description":"Try again."}]}`
+			}
+
+			return lastPage
+		case strings.Contains(query, `"documents"`):
+			return firstPage
+		default:
+			return emptyPage
+		}
+	}
+}
+
+func TestDump(t *testing.T) {
+	failLastPage := false
+	server, fake, client := newFakeServer(answerDatabase(&failLastPage))
+	defer server.Close()
+
+	var out bytes.Buffer
+	var reported []Progress
+
+	progress, err := Dump(client, &out, PageSize(1), OnProgress(func(progress Progress) error {
+		reported = append(reported, progress)
+		return nil
+	}))
+	require.NoError(t, err)
+	require.Equal(t, normalizeLines(t, usersDoc+"\n"+firstDoc+"\n"+secondDoc+"\n"), normalizeLines(t, out.String()))
+	require.Equal(t, Progress{TS: 1000, Offset: int64(out.Len()), Documents: 3, Schema: true, Done: []string{"users"}}, progress)
+	require.Len(t, reported, 7)
+
+	for _, query := range fake.queries[1:] {
+		require.Contains(t, query, `"ts":1000`)
+	}
+
+	require.Contains(t, fake.queries[len(fake.queries)-1], `"after":[{"@ref"`)
+}
+
+func TestDumpOnly(t *testing.T) {
+	failLastPage := false
+	server, _, client := newFakeServer(answerDatabase(&failLastPage))
+	defer server.Close()
+
+	var out bytes.Buffer
+
+	_, err := Dump(client, &out, Only("posts"))
+	require.NoError(t, err)
+	require.Equal(t, normalizeLines(t, usersDoc+"\n"), normalizeLines(t, out.String()))
+}
+
+func TestDumpFileResumes(t *testing.T) {
+	failLastPage := true
+	server, fake, client := newFakeServer(answerDatabase(&failLastPage))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "dump")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "db.ndjson")
+
+	progress, err := DumpFile(client, path)
+	require.Error(t, err)
+	require.Equal(t, "users", progress.Collection)
+	require.NotEmpty(t, progress.After)
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, normalizeLines(t, usersDoc+"\n"+firstDoc+"\n"), normalizeLines(t, string(content)))
+	require.FileExists(t, ProgressFile(path))
+
+	// Simulate a partially written page.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, _ = io.WriteString(file, `{"ref":`)
+	require.NoError(t, file.Close())
+
+	queries := len(fake.queries)
+
+	progress, err = DumpFile(client, path)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), progress.Documents)
+	require.NotContains(t, strings.Join(fake.queries[queries:], "\n"), `"to_micros"`)
+
+	content, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, normalizeLines(t, usersDoc+"\n"+firstDoc+"\n"+secondDoc+"\n"), normalizeLines(t, string(content)))
+
+	_, err = os.Stat(ProgressFile(path))
+	require.True(t, os.IsNotExist(err))
+}
+
+// normalizeLines decodes and encodes every line of a dump, so that they can be compared regardless
+// of the order of object keys.
+func normalizeLines(t *testing.T, content string) string {
+	var normalized bytes.Buffer
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+
+		require.True(t, strings.HasSuffix(line, "\n"), "incomplete line %q", line)
+
+		var value f.Value
+		require.NoError(t, f.UnmarshalJSON([]byte(line), &value))
+
+		encoded, err := f.MarshalJSON(value)
+		require.NoError(t, err)
+
+		normalized.Write(encoded)
+		normalized.WriteByte('\n')
+	}
+
+	return normalized.String()
+}
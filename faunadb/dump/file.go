@@ -0,0 +1,84 @@
+package dump
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+// ProgressFile returns the path of the file where DumpFile saves the progress of a dump.
+func ProgressFile(path string) string { return path + ".progress" }
+
+/*
+DumpFile writes all the documents of the database to the file at path. The progress is saved in
+ProgressFile(path) after every page, and removed once the dump completes. If a progress file exists,
+the interrupted dump is resumed at the same snapshot time instead of being started over.
+*/
+func DumpFile(client f.Querier, path string, options ...Option) (progress Progress, err error) {
+	progressPath := ProgressFile(path)
+
+	if content, readErr := ioutil.ReadFile(progressPath); readErr == nil {
+		if err = json.Unmarshal(content, &progress); err != nil {
+			return
+		}
+	} else if !os.IsNotExist(readErr) {
+		return progress, readErr
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+
+	defer file.Close()
+
+	if err = file.Truncate(progress.Offset); err != nil {
+		return
+	}
+
+	if _, err = file.Seek(progress.Offset, 0); err != nil {
+		return
+	}
+
+	save := func(progress Progress) error {
+		if err := file.Sync(); err != nil {
+			return err
+		}
+
+		return writeProgress(progressPath, progress)
+	}
+
+	options = append(options, Resume(progress), OnProgress(save))
+
+	if progress, err = Dump(client, file, options...); err != nil {
+		return
+	}
+
+	if err = file.Sync(); err != nil {
+		return
+	}
+
+	if err = os.Remove(progressPath); os.IsNotExist(err) {
+		err = nil
+	}
+
+	return
+}
+
+// writeProgress replaces the progress file atomically, so that it's never left partially written.
+func writeProgress(path string, progress Progress) error {
+	content, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,391 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/fauna/faunadb-go/faunadb/migrate"
+)
+
+const defaultBatchSize = 100
+
+// Kinds of schema documents, by the id of their native collection.
+var schemaKinds = map[string]migrate.Kind{
+	"classes":     migrate.Collection,
+	"collections": migrate.Collection,
+	"functions":   migrate.Function,
+	"indexes":     migrate.Index,
+	"roles":       migrate.Role,
+}
+
+// Kinds of schema documents, in the order they are restored.
+var restoreOrder = []migrate.Kind{migrate.Collection, migrate.Index, migrate.Function, migrate.Role}
+
+// Fields of schema documents that are not restored. Names are taken from the refs, which may be
+// remapped, while other fields are set by FaunaDB.
+var skippedFields = map[string]bool{"ref": true, "ts": true, "name": true, "active": true, "partitions": true}
+
+// Restored counts the documents restored so far.
+type Restored struct {
+	Schema    int64 // Number of schema documents created or updated
+	Documents int64 // Number of documents created or replaced
+}
+
+// RestoreError is returned when a line of a dump can't be restored.
+type RestoreError struct {
+	Line int // Line of the dump, starting at 1
+	Err  error
+}
+
+func (err RestoreError) Error() string {
+	return fmt.Sprintf("dump: line %d: %s", err.Line, err.Err)
+}
+
+// Unwrap returns the error returned by FaunaDB or by the decoding of the line.
+func (err RestoreError) Unwrap() error { return err.Err }
+
+// RestoreOption configures a restore.
+type RestoreOption func(*restorer)
+
+// BatchSize sets the number of documents written per query. Defaults to 100.
+func BatchSize(size int) RestoreOption {
+	return func(r *restorer) { r.batchSize = size }
+}
+
+// RenameCollection restores the collection named from as the collection named to. All refs to the
+// collection and to its documents are updated accordingly, including the ones stored in documents.
+func RenameCollection(from, to string) RestoreOption {
+	return func(r *restorer) { r.collections[from] = to }
+}
+
+// RenameDatabase updates the refs scoped to the database named from, so that they are scoped to the
+// database named to instead. Refs are scoped to the current database if to is empty.
+func RenameDatabase(from, to string) RestoreOption {
+	return func(r *restorer) { r.databases[from] = to }
+}
+
+// OnRestore sets a function called after every query written. The restore stops if it returns an error.
+func OnRestore(fn func(Restored) error) RestoreOption {
+	return func(r *restorer) { r.onRestore = append(r.onRestore, fn) }
+}
+
+type schemaDocument struct {
+	line   int
+	kind   migrate.Kind
+	name   string
+	params f.ObjectV
+}
+
+type restorer struct {
+	client      f.Querier
+	batchSize   int
+	collections map[string]string
+	databases   map[string]string
+	onRestore   []func(Restored) error
+	restored    Restored
+	schema      []schemaDocument
+	schemaDone  bool
+	batch       []f.Expr
+	batchLine   int
+}
+
+/*
+Restore recreates the documents of a dump read from r, as written by Dump. Documents keep their ids,
+and are replaced if they already exist, so that a restore can be run again after a failure.
+
+Schema documents are created, or updated if they exist, before any other document, in dependency
+order: collections, indexes, functions, then roles. Functions run with a custom role are created
+without it, and updated once the roles exist. Other documents are written in batches with BatchQuery.
+*/
+func Restore(client f.Querier, r io.Reader, options ...RestoreOption) (Restored, error) {
+	res := &restorer{
+		client:      client,
+		batchSize:   defaultBatchSize,
+		collections: make(map[string]string),
+		databases:   make(map[string]string),
+	}
+
+	for _, option := range options {
+		option(res)
+	}
+
+	err := res.run(r)
+	return res.restored, err
+}
+
+// RestoreFile recreates the documents of the dump stored at path. See Restore.
+func RestoreFile(client f.Querier, path string, options ...RestoreOption) (Restored, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Restored{}, err
+	}
+
+	defer file.Close()
+
+	return Restore(client, file, options...)
+}
+
+func (r *restorer) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		if err := r.restore(line, scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if err := r.flushSchema(); err != nil {
+		return err
+	}
+
+	return r.flushBatch()
+}
+
+func (r *restorer) restore(line int, content []byte) error {
+	doc, err := r.decode(content)
+	if err != nil {
+		return RestoreError{line, err}
+	}
+
+	var ref f.RefV
+
+	if err := doc.At(f.ObjKey("ref")).Get(&ref); err != nil {
+		return RestoreError{line, err}
+	}
+
+	params := make(f.ObjectV, len(doc))
+
+	if kind, isSchema := schemaKind(ref); isSchema {
+		if r.schemaDone {
+			return RestoreError{line, fmt.Errorf("schema document %s found after other documents", ref.ID)}
+		}
+
+		for key, value := range doc {
+			if !skippedFields[key] {
+				params[key] = value
+			}
+		}
+
+		r.schema = append(r.schema, schemaDocument{line, kind, ref.ID, params})
+		return nil
+	}
+
+	if ref.Collection == nil || ref.Collection.Collection == nil {
+		return RestoreError{line, fmt.Errorf("unsupported document %s", f.FQL(ref))}
+	}
+
+	if err := r.flushSchema(); err != nil {
+		return err
+	}
+
+	for key, value := range doc {
+		if key != "ref" && key != "ts" {
+			params[key] = value
+		}
+	}
+
+	if len(r.batch) == 0 {
+		r.batchLine = line
+	}
+
+	r.batch = append(r.batch, f.If(f.Exists(ref), f.Replace(ref, params), f.Create(ref, params)))
+
+	if len(r.batch) >= r.batchSize {
+		return r.flushBatch()
+	}
+
+	return nil
+}
+
+// decode parses a line of a dump, remapping its refs if needed.
+func (r *restorer) decode(content []byte) (doc f.ObjectV, err error) {
+	if len(r.collections) > 0 || len(r.databases) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+
+		var raw interface{}
+
+		if err = decoder.Decode(&raw); err != nil {
+			return
+		}
+
+		if content, err = json.Marshal(r.remap(raw)); err != nil {
+			return
+		}
+	}
+
+	var value f.Value
+
+	if err = f.UnmarshalJSON(content, &value); err == nil {
+		err = value.Get(&doc)
+	}
+
+	return
+}
+
+// remap renames the collections and databases referenced by the refs of a JSON value.
+func (r *restorer) remap(raw interface{}) interface{} {
+	switch value := raw.(type) {
+	case []interface{}:
+		for i, elem := range value {
+			value[i] = r.remap(elem)
+		}
+
+	case map[string]interface{}:
+		for key, elem := range value {
+			value[key] = r.remap(elem)
+		}
+
+		if ref, isRef := value["@ref"].(map[string]interface{}); isRef && len(value) == 1 {
+			r.remapRef(ref)
+		}
+	}
+
+	return raw
+}
+
+func (r *restorer) remapRef(ref map[string]interface{}) {
+	id, _ := ref["id"].(string)
+
+	switch nativeCollectionOf(ref) {
+	case "collections", "classes":
+		if to, ok := r.collections[id]; ok {
+			ref["id"] = to
+		}
+
+	case "databases":
+		if to, ok := r.databases[id]; ok {
+			ref["id"] = to
+		}
+	}
+
+	if db, isObj := ref["database"].(map[string]interface{}); isObj {
+		if dbRef, isRef := db["@ref"].(map[string]interface{}); isRef && dbRef["id"] == "" {
+			delete(ref, "database")
+		}
+	}
+}
+
+// nativeCollectionOf returns the id of the native collection of a JSON ref, if any.
+func nativeCollectionOf(ref map[string]interface{}) string {
+	collection, _ := ref["collection"].(map[string]interface{})
+	collectionRef, _ := collection["@ref"].(map[string]interface{})
+
+	if _, nested := collectionRef["collection"]; nested {
+		return ""
+	}
+
+	id, _ := collectionRef["id"].(string)
+	return id
+}
+
+func schemaKind(ref f.RefV) (migrate.Kind, bool) {
+	if ref.Collection == nil || ref.Collection.Collection != nil {
+		return "", false
+	}
+
+	kind, ok := schemaKinds[ref.Collection.ID]
+	return kind, ok
+}
+
+// flushSchema restores the schema documents in dependency order, one per query since FaunaDB doesn't
+// allow using a schema document in the same transaction that creates it.
+func (r *restorer) flushSchema() error {
+	if r.schemaDone {
+		return nil
+	}
+
+	r.schemaDone = true
+
+	var roleUpdates []schemaDocument
+
+	for _, kind := range restoreOrder {
+		for _, doc := range r.schema {
+			if doc.kind != kind {
+				continue
+			}
+
+			params := doc.params
+
+			if _, customRole := params["role"].(f.RefV); customRole && doc.kind == migrate.Function {
+				roleUpdates = append(roleUpdates, doc)
+				params = withoutField(params, "role")
+			}
+
+			if err := r.write(doc.line, migrate.Ensure(doc.kind, doc.name, params)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, doc := range roleUpdates {
+		update := f.Update(doc.kind.Ref(doc.name), f.Obj{"role": doc.params["role"]})
+
+		if err := r.write(doc.line, update); err != nil {
+			return err
+		}
+	}
+
+	r.schema = nil
+	return nil
+}
+
+func (r *restorer) write(line int, expr f.Expr) error {
+	if _, err := r.client.Query(expr); err != nil {
+		return RestoreError{line, err}
+	}
+
+	r.restored.Schema++
+	return r.report()
+}
+
+func (r *restorer) flushBatch() error {
+	if len(r.batch) == 0 {
+		return nil
+	}
+
+	if _, err := r.client.BatchQuery(r.batch); err != nil {
+		return RestoreError{r.batchLine, err}
+	}
+
+	r.restored.Documents += int64(len(r.batch))
+	r.batch = nil
+
+	return r.report()
+}
+
+func (r *restorer) report() error {
+	for _, fn := range r.onRestore {
+		if err := fn(r.restored); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func withoutField(obj f.ObjectV, field string) f.ObjectV {
+	copied := make(f.ObjectV, len(obj))
+
+	for key, value := range obj {
+		if key != field {
+			copied[key] = value
+		}
+	}
+
+	return copied
+}
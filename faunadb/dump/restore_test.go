@@ -0,0 +1,103 @@
+package dump
+
+import (
+	"strings"
+	"testing"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/stretchr/testify/require"
+)
+
+const restoreDump = `
+{"ref":{"@ref":{"id":"reader","collection":{"@ref":{"id":"roles"}}}},"ts":1,"name":"reader","privileges":[{"resource":` + usersRef + `,"actions":{"read":true}}]}
+{"ref":{"@ref":{"id":"whoami","collection":{"@ref":{"id":"functions"}}}},"ts":1,"name":"whoami","role":{"@ref":{"id":"reader","collection":{"@ref":{"id":"roles"}}}},"body":{"@query":{"api_version":"4","lambda":"x","expr":{"var":"x"}}}}
+{"ref":{"@ref":{"id":"users_by_name","collection":{"@ref":{"id":"indexes"}}}},"ts":1,"name":"users_by_name","source":` + usersRef + `,"active":true,"partitions":1}
+` + usersDoc + `
+` + firstDoc + `
+{"ref":{"@ref":{"id":"2","collection":` + usersRef + `}},"ts":30,"data":{"friend":{"@ref":{"id":"1","collection":` + usersRef + `}}},"ttl":{"@ts":"2030-01-01T00:00:00Z"}}
+{"ref":{"@ref":{"id":"3","collection":` + usersRef + `}},"ts":30,"data":{}}
+`
+
+func TestRestore(t *testing.T) {
+	server, fake, client := newFakeServer(func(query string) string {
+		if strings.HasPrefix(query, "[") {
+			return `{"resource":[]}`
+		}
+
+		return `{"resource":null}`
+	})
+	defer server.Close()
+
+	var reported []Restored
+
+	restored, err := Restore(client, strings.NewReader(restoreDump), BatchSize(2), RenameCollection("users", "people"),
+		OnRestore(func(restored Restored) error {
+			reported = append(reported, restored)
+			return nil
+		}))
+	require.NoError(t, err)
+	require.Equal(t, Restored{Schema: 5, Documents: 3}, restored)
+	require.Len(t, reported, 7)
+	require.Len(t, fake.queries, 7)
+
+	// Collections, indexes, functions without their custom role, roles, then role updates.
+	require.Contains(t, fake.queries[0], `"create_collection":{"merge"`)
+	require.Contains(t, fake.queries[0], `"name":"people"`)
+	require.NotContains(t, fake.queries[0], `"users"`)
+	require.Contains(t, fake.queries[1], `"create_index"`)
+	require.Contains(t, fake.queries[1], `"source":{"@ref":{"collection":{"@ref":{"id":"collections"}},"id":"people"}}`)
+	require.NotContains(t, fake.queries[1], `"active"`)
+	require.NotContains(t, fake.queries[1], `"partitions"`)
+	require.Contains(t, fake.queries[2], `"create_function"`)
+	require.NotContains(t, fake.queries[2], `"role"`)
+	require.Contains(t, fake.queries[3], `"create_role"`)
+	require.Contains(t, fake.queries[4], `"update":{"function":"whoami"}`)
+	require.Contains(t, fake.queries[4], `"role":{"@ref":{"collection":{"@ref":{"id":"roles"}},"id":"reader"}}`)
+
+	// Documents keep their ids and their refs are remapped.
+	require.True(t, strings.HasPrefix(fake.queries[5], "["))
+	require.Contains(t, fake.queries[5], `"create":{"@ref":{"collection":{"@ref":{"collection":{"@ref":{"id":"collections"}},"id":"people"}},"id":"1"}}`)
+	require.Contains(t, fake.queries[5], `"friend":{"@ref":{"collection":{"@ref":{"collection":{"@ref":{"id":"collections"}},"id":"people"}},"id":"1"}}`)
+	require.Contains(t, fake.queries[5], `"ttl":{"@ts":"2030-01-01T00:00:00Z"}`)
+	require.NotContains(t, fake.queries[5], `"users"`)
+	require.Contains(t, fake.queries[6], `"id":"3"`)
+}
+
+func TestRestoreErrors(t *testing.T) {
+	server, _, client := newFakeServer(func(query string) string {
+		if strings.Contains(query, `"create_index"`) {
+			return `{"errors":[{"code":"validation failed","description":"Invalid source."}]}`
+		}
+
+		return `{"resource":[]}`
+	})
+	defer server.Close()
+
+	_, err := Restore(client, strings.NewReader(restoreDump))
+	require.EqualError(t, err, "dump: line 4: Response error 400. Errors: [](validation failed): Invalid source.")
+
+	_, err = Restore(client, strings.NewReader(firstDoc+"\n"+usersDoc+"\n"))
+	require.EqualError(t, err, "dump: line 2: schema document users found after other documents")
+
+	_, err = Restore(client, strings.NewReader(usersDoc+"\n{\n"))
+	require.Error(t, err)
+	require.Equal(t, 2, err.(RestoreError).Line)
+}
+
+func TestRenameDatabase(t *testing.T) {
+	line := `{"ref":{"@ref":{"id":"1","collection":` + usersRef + `}},"data":{` +
+		`"prod":{"@ref":{"id":"1","collection":{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}},"database":{"@ref":{"id":"prod","collection":{"@ref":{"id":"databases"}}}}}}}},` +
+		`"other":{"@ref":{"id":"1","collection":{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}},"database":{"@ref":{"id":"other","collection":{"@ref":{"id":"databases"}}}}}}}}}}`
+
+	for to, expected := range map[string]string{
+		"staging": `Ref(Collection("users", Database("staging")), "1")`,
+		"":        `Ref(Collection("users"), "1")`,
+	} {
+		r := &restorer{databases: map[string]string{"prod": to}}
+
+		doc, err := r.decode([]byte(line))
+		require.NoError(t, err)
+		require.Equal(t, expected, f.FQL(doc["data"].(f.ObjectV)["prod"]))
+		require.Equal(t, `Ref(Collection("users", Database("other")), "1")`, f.FQL(doc["data"].(f.ObjectV)["other"]))
+	}
+}
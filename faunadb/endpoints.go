@@ -0,0 +1,100 @@
+package faunadb
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const endpointCooldown = 30 * time.Second
+
+/*
+Endpoints configures a FaunaClient to use multiple FaunaDB URLs. Queries are sent to the primary
+endpoint while it's healthy. An endpoint is considered unhealthy for a cooldown period after a
+network error or an HTTP 503 response, during which queries are sent to the fallbacks in the order
+they are given. Unhealthy endpoints are only used as a last resort when no healthy endpoint remains.
+
+Read-only expressions are routed to the healthy endpoint with the lowest observed latency and are
+retried on the next endpoint when a request fails. Expressions that may write are never retried
+since they might have been applied; the failing endpoint is marked unhealthy for subsequent queries.
+
+The last seen transaction time is shared across all endpoints, so switching endpoints never causes
+reads to observe data older than what the client has already seen.
+*/
+func Endpoints(primary string, fallbacks ...string) ClientConfig {
+	return func(cli *FaunaClient) {
+		cli.endpoint = primary
+		cli.fallbackEndpoints = fallbacks
+	}
+}
+
+type endpoint struct {
+	url            string
+	unhealthyUntil int64 // Unix nanoseconds
+	latency        int64 // Moving average in nanoseconds, zero if unknown
+}
+
+func (e *endpoint) isHealthy(now time.Time) bool {
+	return atomic.LoadInt64(&e.unhealthyUntil) <= now.UnixNano()
+}
+
+func (e *endpoint) markHealthy(latency time.Duration) {
+	atomic.StoreInt64(&e.unhealthyUntil, 0)
+
+	for {
+		old := atomic.LoadInt64(&e.latency)
+		avg := int64(latency)
+
+		if old != 0 {
+			avg = (old*4 + avg) / 5
+		}
+
+		if atomic.CompareAndSwapInt64(&e.latency, old, avg) {
+			break
+		}
+	}
+}
+
+func (e *endpoint) markUnhealthy() {
+	atomic.StoreInt64(&e.unhealthyUntil, time.Now().Add(endpointCooldown).UnixNano())
+}
+
+type endpointPool []*endpoint
+
+func newEndpointPool(urls ...string) endpointPool {
+	pool := make(endpointPool, len(urls))
+
+	for i, url := range urls {
+		pool[i] = &endpoint{url: url}
+	}
+
+	return pool
+}
+
+// candidates returns the endpoints to try for a request, in order.
+func (pool endpointPool) candidates(readOnly bool) []*endpoint {
+	if len(pool) == 1 {
+		return pool
+	}
+
+	now := time.Now()
+	healthy := make([]*endpoint, 0, len(pool))
+	var unhealthy []*endpoint
+
+	for _, e := range pool {
+		if e.isHealthy(now) {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+
+	if readOnly {
+		sort.SliceStable(healthy, func(i, j int) bool {
+			a, b := atomic.LoadInt64(&healthy[i].latency), atomic.LoadInt64(&healthy[j].latency)
+			return a != 0 && (b == 0 || a < b)
+		})
+	}
+
+	return append(healthy, unhealthy...)
+}
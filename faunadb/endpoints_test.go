@@ -0,0 +1,119 @@
+package faunadb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailOverReadsToHealthyEndpoint(t *testing.T) {
+	primary := httptest.NewServer(respondWith(503, `{"errors": []}`))
+	defer primary.Close()
+
+	fallback := httptest.NewServer(respondWith(200, `{"resource": "fallback"}`))
+	defer fallback.Close()
+
+	client := NewFaunaClient("secret", Endpoints(primary.URL, fallback.URL))
+
+	value, err := client.Query(Get(Ref("collections/spells/42")))
+	require.NoError(t, err)
+	require.Equal(t, StringV("fallback"), value)
+	require.False(t, client.endpoints[0].isHealthy(time.Now()))
+	require.True(t, client.endpoints[1].isHealthy(time.Now()))
+}
+
+func TestDoNotRetryWritesOnFailure(t *testing.T) {
+	var fallbackCalls int
+
+	primary := httptest.NewServer(respondWith(503, `{"errors": []}`))
+	defer primary.Close()
+
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackCalls++
+		respondWith(200, `{"resource": "fallback"}`)(w, r)
+	}))
+	defer fallback.Close()
+
+	client := NewFaunaClient("secret", Endpoints(primary.URL, fallback.URL))
+	write := Create(Collection("spells"), Obj{})
+
+	_, err := client.Query(write)
+	require.IsType(t, Unavailable{}, err)
+	require.Equal(t, 0, fallbackCalls)
+
+	value, err := client.Query(write)
+	require.NoError(t, err)
+	require.Equal(t, StringV("fallback"), value)
+	require.Equal(t, 1, fallbackCalls)
+}
+
+func TestFailOverOnNetworkErrors(t *testing.T) {
+	primary := httptest.NewServer(respondWith(200, `{"resource": "primary"}`))
+	primary.Close()
+
+	fallback := httptest.NewServer(respondWith(200, `{"resource": "fallback"}`))
+	defer fallback.Close()
+
+	client := NewFaunaClient("secret", Endpoints(primary.URL, fallback.URL))
+
+	value, err := client.Query(Paginate(Collections()))
+	require.NoError(t, err)
+	require.Equal(t, StringV("fallback"), value)
+}
+
+func TestKeepLastSeenTxnTimeAcrossEndpoints(t *testing.T) {
+	var lastSeen string
+
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerTxnTime, "1000")
+		respondWith(200, `{"resource": "primary"}`)(w, r)
+	}))
+	defer primary.Close()
+
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastSeen = r.Header.Get(headerLastSeenTxn)
+		respondWith(200, `{"resource": "fallback"}`)(w, r)
+	}))
+	defer fallback.Close()
+
+	client := NewFaunaClient("secret", Endpoints(primary.URL, fallback.URL))
+
+	_, err := client.Query(NewId())
+	require.NoError(t, err)
+
+	client.endpoints[0].markUnhealthy()
+
+	_, err = client.Query(NewId())
+	require.NoError(t, err)
+	require.Equal(t, "1000", lastSeen)
+}
+
+func TestEndpointCandidatesOrder(t *testing.T) {
+	pool := newEndpointPool("primary", "near", "far", "down")
+	pool[1].markHealthy(10 * time.Millisecond)
+	pool[2].markHealthy(50 * time.Millisecond)
+	pool[0].markHealthy(20 * time.Millisecond)
+	pool[3].markUnhealthy()
+
+	require.Equal(t, []string{"primary", "near", "far", "down"}, urlsOf(pool.candidates(false)))
+	require.Equal(t, []string{"near", "primary", "far", "down"}, urlsOf(pool.candidates(true)))
+}
+
+func TestEndpointOverridesFallbacks(t *testing.T) {
+	client := NewFaunaClient("secret", Endpoints("http://primary", "http://fallback"), Endpoint("http://single"))
+	require.Equal(t, []string{"http://single"}, urlsOf(client.endpoints))
+	require.Equal(t, client.endpoints, client.NewSessionClient("other").endpoints)
+}
+
+func urlsOf(endpoints []*endpoint) []string {
+	urls := make([]string, len(endpoints))
+
+	for i, e := range endpoints {
+		urls[i] = e.url
+	}
+
+	return urls
+}
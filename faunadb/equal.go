@@ -0,0 +1,233 @@
+package faunadb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Change is a difference between two values, as returned by Diff. Old is nil for a field or element
+// added, and New is nil for a field or element removed.
+type Change struct {
+	Path Field // Path of the changed value, usable with Value.At
+	Old  Value
+	New  Value
+}
+
+func (change Change) String() string {
+	switch {
+	case change.Old == nil:
+		return fmt.Sprintf("%s: added %s", change.Path, describe(change.New))
+	case change.New == nil:
+		return fmt.Sprintf("%s: removed %s", change.Path, describe(change.Old))
+	default:
+		return fmt.Sprintf("%s: changed from %s to %s", change.Path, describe(change.Old), describe(change.New))
+	}
+}
+
+// String returns the path of the field, such as "data / tags / 0".
+func (f Field) String() string { return f.path.String() }
+
+func describe(value Value) string {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(raw)
+}
+
+/*
+Equal reports whether two values are structurally equal. Unlike == and reflect.DeepEqual, Equal
+compares refs by their id, collection and database regardless of the deprecated Class field, queries
+by their lambda regardless of the formatting of their JSON, times by the instant they represent, and
+objects, arrays and sets element by element. Numbers of different types are not equal, as in FaunaDB.
+*/
+func Equal(a, b Value) bool {
+	switch x := a.(type) {
+	case nil:
+		return b == nil
+
+	case ObjectV:
+		y, ok := b.(ObjectV)
+		return ok && equalObjects(x, y)
+
+	case ArrayV:
+		y, ok := b.(ArrayV)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for i := range x {
+			if !Equal(x[i], y[i]) {
+				return false
+			}
+		}
+
+		return true
+
+	case RefV:
+		y, ok := b.(RefV)
+		return ok && equalRefs(&x, &y)
+
+	case SetRefV:
+		y, ok := b.(SetRefV)
+		return ok && equalObjects(x.Parameters, y.Parameters)
+
+	case QueryV:
+		y, ok := b.(QueryV)
+		return ok && bytes.Equal(canonicalJSON(x.lambda), canonicalJSON(y.lambda))
+
+	case TimeV:
+		y, ok := b.(TimeV)
+		return ok && time.Time(x).Equal(time.Time(y))
+
+	case DateV:
+		y, ok := b.(DateV)
+		return ok && time.Time(x).Equal(time.Time(y))
+
+	case BytesV:
+		y, ok := b.(BytesV)
+		return ok && bytes.Equal(x, y)
+
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+func equalObjects(x, y map[string]Value) bool {
+	if len(x) != len(y) {
+		return false
+	}
+
+	for key, value := range x {
+		other, ok := y[key]
+		if !ok || !Equal(value, other) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalRefs(x, y *RefV) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+
+	return x.ID == y.ID && equalRefs(refCollection(x), refCollection(y)) && equalRefs(x.Database, y.Database)
+}
+
+func refCollection(ref *RefV) *RefV {
+	if ref.Collection != nil {
+		return ref.Collection
+	}
+
+	return ref.Class
+}
+
+// canonicalJSON re-encodes a JSON document with no insignificant whitespace and with the keys of
+// objects sorted.
+func canonicalJSON(raw []byte) []byte {
+	var decoded interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&decoded); err != nil {
+		return raw
+	}
+
+	canonical, err := json.Marshal(decoded)
+	if err != nil {
+		return raw
+	}
+
+	return canonical
+}
+
+/*
+Diff returns the changes turning a into b, or nil if they are Equal. Objects and arrays are compared
+recursively, reporting a change for each field or element that differs, in the order of their paths.
+Any other values that differ, including values of different types, are reported as a single change.
+For example:
+
+	for _, change := range f.Diff(before, after) {
+		fmt.Println(change) // data / name: changed from "Jane" to "John"
+	}
+*/
+func Diff(a, b Value) []Change {
+	var changes []Change
+	diff(nil, a, b, &changes)
+	return changes
+}
+
+func diff(p path, a, b Value, changes *[]Change) {
+	switch x := a.(type) {
+	case ObjectV:
+		if y, ok := b.(ObjectV); ok {
+			diffObjects(p, x, y, changes)
+			return
+		}
+
+	case ArrayV:
+		if y, ok := b.(ArrayV); ok {
+			diffArrays(p, x, y, changes)
+			return
+		}
+	}
+
+	if !Equal(a, b) {
+		*changes = append(*changes, Change{Field{p}, a, b})
+	}
+}
+
+func diffObjects(p path, x, y ObjectV, changes *[]Change) {
+	keys := make([]string, 0, len(x)+len(y))
+
+	for key := range x {
+		keys = append(keys, key)
+	}
+
+	for key := range y {
+		if _, ok := x[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		diff(appendSegment(p, objectSegment(key)), x[key], y[key], changes)
+	}
+}
+
+func diffArrays(p path, x, y ArrayV, changes *[]Change) {
+	size := len(x)
+
+	if len(y) > size {
+		size = len(y)
+	}
+
+	for i := 0; i < size; i++ {
+		var a, b Value
+
+		if i < len(x) {
+			a = x[i]
+		}
+
+		if i < len(y) {
+			b = y[i]
+		}
+
+		diff(appendSegment(p, arraySegment(i)), a, b, changes)
+	}
+}
+
+// appendSegment returns a new path, so that paths of sibling changes don't share their segments.
+func appendSegment(p path, seg segment) path {
+	return append(append(make(path, 0, len(p)+1), p...), seg)
+}
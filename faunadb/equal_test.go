@@ -0,0 +1,65 @@
+package faunadb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqual(t *testing.T) {
+	decoded := decodedValue(t, `{"ref":`+userRef+`,"ts":10,"fn":{"@query":{"lambda": "x", "expr": {"var": "x"}}}}`)
+	users := &RefV{"users", NativeCollections(), nil, nil}
+	built := ObjectV{
+		"ref": RefV{"42", users, nil, nil},
+		"ts":  LongV(10),
+		"fn":  decodedValue(t, `{"@query":{"expr":{"var":"x"},"lambda":"x"}}`),
+	}
+
+	require.True(t, Equal(decoded, built))
+	require.True(t, Equal(nil, nil))
+	require.True(t, Equal(NullV{}, NullV{}))
+	require.True(t, Equal(
+		TimeV(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)),
+		TimeV(time.Date(2020, 1, 1, 13, 0, 0, 0, time.FixedZone("CET", 3600)))))
+	require.True(t, Equal(SetRefV{map[string]Value{"match": RefV{"idx", NativeIndexes(), nil, nil}}},
+		SetRefV{map[string]Value{"match": RefV{"idx", NativeIndexes(), NativeIndexes(), nil}}}))
+	require.True(t, Equal(BytesV{1, 2}, BytesV{1, 2}))
+
+	require.False(t, Equal(LongV(1), DoubleV(1)))
+	require.False(t, Equal(NullV{}, nil))
+	require.False(t, Equal(RefV{"42", users, nil, nil}, RefV{"42", NativeIndexes(), nil, nil}))
+	require.False(t, Equal(RefV{"42", users, nil, nil}, RefV{"42", users, nil, &RefV{"db", NativeDatabases(), nil, nil}}))
+	require.False(t, Equal(ObjectV{"a": LongV(1)}, ObjectV{"b": LongV(1)}))
+	require.False(t, Equal(ArrayV{LongV(1)}, ArrayV{LongV(1), LongV(2)}))
+	require.False(t, Equal(decodedValue(t, `{"@query":{"lambda":"x","expr":1}}`), decodedValue(t, `{"@query":{"lambda":"y","expr":1}}`)))
+}
+
+func TestDiff(t *testing.T) {
+	before := decodedValue(t, `{"data":{"name":"Jane","age":42,"tags":["a","b"],"address":{"city":"Paris"}}}`)
+	after := decodedValue(t, `{"data":{"name":"John","age":42,"tags":["a"],"address":"Lyon","admin":true}}`)
+
+	require.Nil(t, Diff(before, before))
+
+	changes := Diff(before, after)
+
+	var descriptions []string
+
+	for _, change := range changes {
+		descriptions = append(descriptions, change.String())
+	}
+
+	require.Equal(t, []string{
+		`data / address: changed from {"object":{"city":"Paris"}} to "Lyon"`,
+		`data / admin: added true`,
+		`data / name: changed from "Jane" to "John"`,
+		`data / tags / 1: removed "b"`,
+	}, descriptions)
+
+	value, err := before.At(changes[3].Path).GetValue()
+	require.NoError(t, err)
+	require.Equal(t, StringV("b"), value)
+
+	require.Equal(t, []Change{{Field{}, LongV(1), StringV("1")}}, Diff(LongV(1), StringV("1")))
+	require.Equal(t, "<root>: changed from 1 to \"1\"", Diff(LongV(1), StringV("1"))[0].String())
+}
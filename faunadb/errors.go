@@ -3,6 +3,7 @@ package faunadb
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -36,12 +37,149 @@ type Unavailable struct{ FaunaError }
 // A UnknownError wraps any unknown http error response.
 type UnknownError struct{ FaunaError }
 
-// QueryError describes query errors returned by the server.
+func (err BadRequest) Unwrap() error       { return err.FaunaError }
+func (err Unauthorized) Unwrap() error     { return err.FaunaError }
+func (err PermissionDenied) Unwrap() error { return err.FaunaError }
+func (err NotFound) Unwrap() error         { return err.FaunaError }
+func (err InternalError) Unwrap() error    { return err.FaunaError }
+func (err Unavailable) Unwrap() error      { return err.FaunaError }
+func (err UnknownError) Unwrap() error     { return err.FaunaError }
+
+// Is reports whether the target is a BadRequest, so that errors.Is(err, BadRequest{}) matches any HTTP 400 error.
+func (err BadRequest) Is(target error) bool { _, ok := target.(BadRequest); return ok }
+
+// Is reports whether the target is an Unauthorized, so that errors.Is(err, Unauthorized{}) matches any HTTP 401 error.
+func (err Unauthorized) Is(target error) bool { _, ok := target.(Unauthorized); return ok }
+
+// Is reports whether the target is a PermissionDenied, so that errors.Is(err, PermissionDenied{}) matches any HTTP 403 error.
+func (err PermissionDenied) Is(target error) bool { _, ok := target.(PermissionDenied); return ok }
+
+// Is reports whether the target is a NotFound, so that errors.Is(err, NotFound{}) matches any HTTP 404 error.
+func (err NotFound) Is(target error) bool { _, ok := target.(NotFound); return ok }
+
+// Is reports whether the target is an InternalError, so that errors.Is(err, InternalError{}) matches any HTTP 500 error.
+func (err InternalError) Is(target error) bool { _, ok := target.(InternalError); return ok }
+
+// Is reports whether the target is an Unavailable, so that errors.Is(err, Unavailable{}) matches any HTTP 503 error.
+func (err Unavailable) Is(target error) bool { _, ok := target.(Unavailable); return ok }
+
+// Is reports whether the target is an UnknownError, so that errors.Is(err, UnknownError{}) matches any other HTTP error.
+func (err UnknownError) Is(target error) bool { _, ok := target.(UnknownError); return ok }
+
+/*
+ErrorCode identifies the kind of a QueryError. Error codes are also sentinel errors: a FaunaError
+matches an error code with errors.Is when any of its query errors, or their causes, has that code.
+For example:
+
+	_, err := client.Query(Get(ref))
+
+	if errors.Is(err, ErrInstanceNotFound) {
+		// ...
+	}
+*/
+type ErrorCode string
+
+func (code ErrorCode) Error() string { return string(code) }
+
+// Error codes returned by the server.
+//
+// See: https://docs.fauna.com/fauna/current/api/fql/errors
+const (
+	ErrInvalidArgument       ErrorCode = "invalid argument"
+	ErrInvalidExpression     ErrorCode = "invalid expression"
+	ErrInvalidRef            ErrorCode = "invalid ref"
+	ErrInvalidToken          ErrorCode = "invalid token"
+	ErrInvalidWriteTime      ErrorCode = "invalid write time"
+	ErrAuthenticationFailed  ErrorCode = "authentication failed"
+	ErrUnauthorized          ErrorCode = "unauthorized"
+	ErrPermissionDenied      ErrorCode = "permission denied"
+	ErrMissingIdentity       ErrorCode = "missing identity"
+	ErrInstanceNotFound      ErrorCode = "instance not found"
+	ErrInstanceAlreadyExists ErrorCode = "instance already exists"
+	ErrInstanceNotUnique     ErrorCode = "instance not unique"
+	ErrValidationFailed      ErrorCode = "validation failed"
+	ErrValueNotFound         ErrorCode = "value not found"
+	ErrSchemaNotFound        ErrorCode = "schema not found"
+	ErrTransactionAborted    ErrorCode = "transaction aborted"
+	ErrContendedTransaction  ErrorCode = "contended transaction"
+	ErrCallError             ErrorCode = "call error"
+	ErrStackOverflow         ErrorCode = "stack overflow"
+	ErrFeatureNotAvailable   ErrorCode = "feature not available"
+)
+
+// IsNotFound reports whether err, or any error it wraps, is an HTTP 404 error or has the ErrInstanceNotFound code.
+func IsNotFound(err error) bool {
+	return hasStatus(err, 404) || HasErrorCode(err, ErrInstanceNotFound)
+}
+
+// IsNotUnique reports whether err, or any error it wraps, has the ErrInstanceNotUnique code.
+func IsNotUnique(err error) bool { return HasErrorCode(err, ErrInstanceNotUnique) }
+
+// IsValidationFailed reports whether err, or any error it wraps, has the ErrValidationFailed code.
+func IsValidationFailed(err error) bool { return HasErrorCode(err, ErrValidationFailed) }
+
+// IsPermissionDenied reports whether err, or any error it wraps, is an HTTP 403 error or has the ErrPermissionDenied code.
+func IsPermissionDenied(err error) bool {
+	return hasStatus(err, 403) || HasErrorCode(err, ErrPermissionDenied)
+}
+
+// IsAborted reports whether err, or any error it wraps, has the ErrTransactionAborted code.
+func IsAborted(err error) bool { return HasErrorCode(err, ErrTransactionAborted) }
+
+// IsContention reports whether err, or any error it wraps, is an HTTP 409 error or has the ErrContendedTransaction code.
+// Such queries failed because of concurrent writes and can usually be retried.
+func IsContention(err error) bool {
+	return hasStatus(err, 409) || HasErrorCode(err, ErrContendedTransaction)
+}
+
+// HasErrorCode reports whether err, or any error it wraps, is a FaunaError containing a query error with the given code.
+// The causes of errors raised by user defined functions are also inspected.
+func HasErrorCode(err error, code ErrorCode) bool {
+	faunaErr, ok := asFaunaError(err)
+	return ok && hasErrorCode(faunaErr.Errors(), code)
+}
+
+func hasErrorCode(queryErrors []QueryError, code ErrorCode) bool {
+	for _, queryError := range queryErrors {
+		if queryError.Code == string(code) || hasErrorCode(queryError.Cause, code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasStatus(err error, status int) bool {
+	faunaErr, ok := asFaunaError(err)
+	return ok && faunaErr.Status() == status
+}
+
+func asFaunaError(err error) (FaunaError, bool) {
+	for err != nil {
+		if faunaErr, ok := err.(FaunaError); ok {
+			return faunaErr, true
+		}
+
+		wrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+
+		err = wrapper.Unwrap()
+	}
+
+	return nil, false
+}
+
+// QueryError describes query errors returned by the server. Errors raised while evaluating a user
+// defined function are reported with the ErrCallError code, and the errors raised by the function's
+// body are kept in Cause, with positions relative to the function's body.
 type QueryError struct {
 	Position    []string            `fauna:"position"`
 	Code        string              `fauna:"code"`
 	Description string              `fauna:"description"`
 	Failures    []ValidationFailure `fauna:"failures"`
+	Cause       []QueryError        `fauna:"cause"`
 }
 
 // ValidationFailure describes validation errors on a submitted query.
@@ -60,6 +198,12 @@ type errorResponse struct {
 func (err errorResponse) Status() int          { return err.status }
 func (err errorResponse) Errors() []QueryError { return err.errors }
 
+// Is reports whether the target is an ErrorCode matching any of the query errors or their causes.
+func (err errorResponse) Is(target error) bool {
+	code, ok := target.(ErrorCode)
+	return ok && hasErrorCode(err.errors, code)
+}
+
 func (err errorResponse) Error() string {
 	return fmt.Sprintf("Response error %d. %s", err.status, err.queryErrors())
 }
@@ -72,12 +216,28 @@ func (err *errorResponse) queryErrors() string {
 	errors := make([]string, len(err.errors))
 
 	for i, queryError := range err.errors {
-		errors[i] = fmt.Sprintf("[%s](%s): %s", strings.Join(queryError.Position, "/"), queryError.Code, queryError.Description)
+		errors[i] = queryError.String()
 	}
 
 	return fmt.Sprintf("Errors: %s", strings.Join(errors, ", "))
 }
 
+func (queryError QueryError) String() string {
+	str := fmt.Sprintf("[%s](%s): %s", strings.Join(queryError.Position, "/"), queryError.Code, queryError.Description)
+
+	if len(queryError.Cause) > 0 {
+		causes := make([]string, len(queryError.Cause))
+
+		for i, cause := range queryError.Cause {
+			causes[i] = cause.String()
+		}
+
+		str = fmt.Sprintf("%s Caused by: %s", str, strings.Join(causes, ", "))
+	}
+
+	return str
+}
+
 func checkForResponseErrors(response *http.Response) error {
 	if response.StatusCode >= 200 && response.StatusCode < 300 {
 		return nil
@@ -87,6 +247,10 @@ func checkForResponseErrors(response *http.Response) error {
 
 	switch response.StatusCode {
 	case 400:
+		if conflict, ok := AsConflict(BadRequest{err}); ok {
+			return conflict
+		}
+
 		return BadRequest{err}
 	case 401:
 		return Unauthorized{err}
@@ -108,7 +272,7 @@ func parseErrorResponse(response *http.Response) FaunaError {
 
 	if response.Body != nil {
 		if value, err := parseJSON(response.Body); err == nil {
-			if err := value.At(errorsField).Get(&errors); err == nil {
+			if err := stringifyPaths(value).At(errorsField).Get(&errors); err == nil {
 				return errorResponse{true, response.StatusCode, errors}
 			}
 		}
@@ -116,3 +280,42 @@ func parseErrorResponse(response *http.Response) FaunaError {
 
 	return errorResponse{false, response.StatusCode, errors}
 }
+
+// stringifyPaths converts the array indexes found in error positions and failure fields to strings.
+func stringifyPaths(value Value) Value {
+	switch v := value.(type) {
+	case ArrayV:
+		arr := make(ArrayV, len(v))
+
+		for i, elem := range v {
+			arr[i] = stringifyPaths(elem)
+		}
+
+		return arr
+
+	case ObjectV:
+		obj := make(ObjectV, len(v))
+
+		for key, elem := range v {
+			if path, ok := elem.(ArrayV); ok && (key == "position" || key == "field") {
+				segments := make(ArrayV, len(path))
+
+				for i, segment := range path {
+					if index, ok := segment.(LongV); ok {
+						segments[i] = StringV(strconv.FormatInt(int64(index), 10))
+					} else {
+						segments[i] = segment
+					}
+				}
+
+				obj[key] = segments
+			} else {
+				obj[key] = stringifyPaths(elem)
+			}
+		}
+
+		return obj
+	}
+
+	return value
+}
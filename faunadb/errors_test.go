@@ -2,6 +2,7 @@ package faunadb
 
 import (
 	"bytes"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"testing"
@@ -94,6 +95,53 @@ func TestParseErrorResponse(t *testing.T) {
 	require.EqualError(t, err, "Response error 401. Errors: [data/token](invalid token): Invalid token.")
 }
 
+func TestParseNestedErrorCauses(t *testing.T) {
+	json := `
+	{
+		"errors": [
+			{
+				"position": ["do", 1],
+				"code": "call error",
+				"description": "Calling the function resulted in an error.",
+				"cause": [
+					{
+						"position": ["expr", "map", 0],
+						"code": "instance not found",
+						"description": "Document not found."
+					}
+				]
+			}
+		]
+	}
+	`
+
+	err := checkForResponseErrors(httpErrorResponseWith(400, json))
+
+	expectedError := BadRequest{
+		errorResponseWith(400,
+			[]QueryError{
+				{
+					Position:    []string{"do", "1"},
+					Code:        "call error",
+					Description: "Calling the function resulted in an error.",
+					Cause: []QueryError{
+						{
+							Position:    []string{"expr", "map", "0"},
+							Code:        "instance not found",
+							Description: "Document not found.",
+						},
+					},
+				},
+			},
+		),
+	}
+
+	require.Equal(t, expectedError, err)
+	require.EqualError(t, err, "Response error 400. Errors: [do/1](call error): Calling the function resulted in an error. "+
+		"Caused by: [expr/map/0](instance not found): Document not found.")
+	require.True(t, IsNotFound(err))
+}
+
 func TestUnparseableResponse(t *testing.T) {
 	json := "can't parse this as an error"
 	err := checkForResponseErrors(httpErrorResponseWith(503, json))
@@ -102,6 +150,61 @@ func TestUnparseableResponse(t *testing.T) {
 	require.EqualError(t, err, "Response error 503. Unparseable server response.")
 }
 
+func TestStatusErrorsUnwrapToErrorResponse(t *testing.T) {
+	err := checkForResponseErrors(httpErrorResponseWith(404, emptyErrorBody))
+
+	unwrapper, ok := err.(interface{ Unwrap() error })
+	require.True(t, ok)
+	require.Equal(t, errorResponseWith(404, noErrors), unwrapper.Unwrap())
+}
+
+func TestStatusErrorsMatchTheirOwnType(t *testing.T) {
+	err := checkForResponseErrors(httpErrorResponseWith(400, emptyErrorBody)).(BadRequest)
+
+	require.True(t, err.Is(BadRequest{}))
+	require.False(t, err.Is(NotFound{}))
+	require.False(t, NotFound{}.Is(BadRequest{}))
+}
+
+func TestErrorResponseMatchesErrorCodes(t *testing.T) {
+	err := errorResponseWith(400, []QueryError{
+		{Code: "validation failed"},
+		{Code: "instance not unique"},
+	})
+
+	require.True(t, err.Is(ErrValidationFailed))
+	require.True(t, err.Is(ErrInstanceNotUnique))
+	require.False(t, err.Is(ErrInstanceNotFound))
+	require.EqualError(t, ErrInstanceNotFound, "instance not found")
+}
+
+func TestErrorClassificationHelpers(t *testing.T) {
+	notFound := checkForResponseErrors(httpErrorResponseWith(404, `{"errors": [{"code": "instance not found"}]}`))
+	contended := checkForResponseErrors(httpErrorResponseWith(409, `{"errors": [{"code": "contended transaction"}]}`))
+	aborted := checkForResponseErrors(httpErrorResponseWith(400, `{"errors": [{"code": "transaction aborted"}]}`))
+	denied := checkForResponseErrors(httpErrorResponseWith(403, emptyErrorBody))
+	notUnique := checkForResponseErrors(httpErrorResponseWith(400, `{"errors": [{"code": "instance not unique"}]}`))
+	invalid := checkForResponseErrors(httpErrorResponseWith(400, `{"errors": [{"code": "validation failed"}]}`))
+
+	require.True(t, IsNotFound(notFound))
+	require.True(t, IsNotFound(wrappedError{notFound}))
+	require.False(t, IsNotFound(aborted))
+	require.False(t, IsNotFound(nil))
+	require.True(t, IsContention(contended))
+	require.False(t, IsContention(notFound))
+	require.True(t, IsAborted(aborted))
+	require.True(t, IsPermissionDenied(denied))
+	require.True(t, IsNotUnique(notUnique))
+	require.True(t, IsValidationFailed(invalid))
+	require.True(t, HasErrorCode(wrappedError{invalid}, ErrValidationFailed))
+	require.False(t, HasErrorCode(errors.New("validation failed"), ErrValidationFailed))
+}
+
+type wrappedError struct{ err error }
+
+func (w wrappedError) Error() string { return "wrapped: " + w.err.Error() }
+func (w wrappedError) Unwrap() error { return w.err }
+
 func httpErrorResponseWith(status int, errorBody string) *http.Response {
 	return &http.Response{
 		StatusCode: status,
@@ -0,0 +1,395 @@
+/*
+Package eval evaluates pure FaunaDB expressions locally, without a cluster.
+
+Expressions made only of pure functions, such as arithmetic, string functions, Time, Date, Epoch,
+Select, Merge, and Map, Filter, Reduce or Union over arrays, are evaluated against Values the same way FaunaDB evaluates them,
+returning the same results and errors. This is useful to unit test expressions, or the body of user
+defined functions, without a database. For example:
+
+	value, err := eval.Eval(f.Concat(f.Arr{"Hello", "World"}, f.Separator(" ")))
+	// value == f.StringV("Hello World")
+
+	value, err = eval.Apply(f.Lambda(f.Arr{"a", "b"}, f.Add(f.Var("a"), f.Var("b"))), 1, 2)
+	// value == f.LongV(3)
+
+Errors raised while evaluating an expression are returned as an Error holding the code and
+description FaunaDB would return. Expressions reading or writing the database, such as Get, Create,
+Join or the Union of sets, and expressions depending on the transaction, such as Now or Time("now"),
+can't be evaluated locally and return an UnsupportedError.
+*/
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/fauna/faunadb-go/faunadb/internal/funcs"
+)
+
+// Error is an error raised while evaluating an expression. It has the code, description and position
+// of the error FaunaDB would return for the same expression.
+type Error struct {
+	Position    []string
+	Code        f.ErrorCode
+	Description string
+}
+
+func (err Error) Error() string {
+	return fmt.Sprintf("[%s](%s): %s", strings.Join(err.Position, "/"), err.Code, err.Description)
+}
+
+// Is reports whether the target is the error's code, so that errors.Is(err, f.ErrInvalidArgument)
+// matches the same errors locally and against FaunaDB.
+func (err Error) Is(target error) bool {
+	code, ok := target.(f.ErrorCode)
+	return ok && code == err.Code
+}
+
+// UnsupportedError is returned when an expression calls a function that can't be evaluated locally.
+type UnsupportedError struct {
+	Function string
+	Position []string
+}
+
+func (err UnsupportedError) Error() string {
+	return fmt.Sprintf("eval: unsupported function %s at [%s]", err.Function, strings.Join(err.Position, "/"))
+}
+
+// Eval evaluates an expression.
+func Eval(expr f.Expr) (f.Value, error) { return EvalWith(nil, expr) }
+
+// EvalWith evaluates an expression with the given variables in scope, as if the expression was
+// wrapped in a Let binding them.
+func EvalWith(vars map[string]interface{}, expr f.Expr) (f.Value, error) {
+	root := &scope{vars: make(map[string]f.Value, len(vars))}
+
+	for name, expr := range vars {
+		value, err := root.eval(expr, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		root.vars[name] = value
+	}
+
+	return root.eval(expr, nil)
+}
+
+// Apply calls a lambda with the given arguments, the way FaunaDB calls user defined functions: a
+// single argument is given as is, and multiple arguments as an array to be destructured by the
+// lambda's parameters. The lambda can be a Lambda expression, a Lambda wrapped with Query, or a
+// QueryV such as the body of a function read from the database.
+func Apply(lambda f.Expr, args ...interface{}) (f.Value, error) {
+	root := &scope{}
+
+	fn, err := root.lambda(lambda, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var arg interface{} = f.Arr(args)
+
+	if len(args) == 1 {
+		arg = args[0]
+	}
+
+	value, err := root.eval(arg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return fn.apply(value)
+}
+
+var exprType = reflect.TypeOf((*f.Expr)(nil)).Elem()
+
+type scope struct {
+	vars   map[string]f.Value
+	parent *scope
+}
+
+func (sc *scope) lookup(name string) (f.Value, bool) {
+	for ; sc != nil; sc = sc.parent {
+		if value, ok := sc.vars[name]; ok {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+func at(pos []string, segments ...string) []string {
+	return append(append(make([]string, 0, len(pos)+len(segments)), pos...), segments...)
+}
+
+func (sc *scope) eval(expr interface{}, pos []string) (f.Value, error) {
+	switch e := expr.(type) {
+	case nil:
+		return f.NullV{}, nil
+	case f.Obj:
+		return sc.evalObject(reflect.ValueOf(map[string]interface{}(e)), pos)
+	case f.Arr:
+		return sc.evalArray(reflect.ValueOf([]interface{}(e)), pos)
+	case f.Value:
+		return e, nil
+	case string:
+		return f.StringV(e), nil
+	case bool:
+		return f.BooleanV(e), nil
+	case time.Time:
+		return f.TimeV(e), nil
+	case []byte:
+		return f.BytesV(e), nil
+	}
+
+	value := reflect.ValueOf(expr)
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return f.LongV(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return f.LongV(int64(value.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return f.DoubleV(value.Float()), nil
+	case reflect.Slice, reflect.Array:
+		return sc.evalArray(value, pos)
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			break
+		}
+
+		if value.Type().Elem() != exprType {
+			return sc.evalObject(value, pos)
+		}
+
+		if inner := value.MapIndex(reflect.ValueOf("object")); inner.IsValid() && value.Len() == 1 {
+			if fields := reflect.ValueOf(inner.Interface()); fields.Kind() == reflect.Map {
+				return sc.evalObject(fields, at(pos, "object"))
+			}
+		}
+
+		return sc.evalCall(value, pos)
+	}
+
+	return sc.evalEncoded(expr, pos)
+}
+
+// evalEncoded evaluates values of other types, such as structs, the way they are encoded in queries.
+func (sc *scope) evalEncoded(value interface{}, pos []string) (f.Value, error) {
+	raw, err := json.Marshal(f.Arr{value})
+	if err != nil {
+		return nil, err
+	}
+
+	var expr f.Expr
+
+	if err := f.UnmarshalJSONExpr(raw, &expr); err != nil {
+		return nil, err
+	}
+
+	arr, err := sc.eval(expr, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	return arr.(f.ArrayV)[0], nil
+}
+
+func (sc *scope) evalArray(value reflect.Value, pos []string) (f.Value, error) {
+	arr := make(f.ArrayV, value.Len())
+
+	for i := range arr {
+		elem, err := sc.eval(value.Index(i).Interface(), at(pos, strconv.Itoa(i)))
+		if err != nil {
+			return nil, err
+		}
+
+		arr[i] = elem
+	}
+
+	return arr, nil
+}
+
+func (sc *scope) evalObject(value reflect.Value, pos []string) (f.Value, error) {
+	obj := make(f.ObjectV, value.Len())
+
+	for _, key := range value.MapKeys() {
+		elem, err := sc.eval(value.MapIndex(key).Interface(), at(pos, key.String()))
+		if err != nil {
+			return nil, err
+		}
+
+		obj[key.String()] = elem
+	}
+
+	return obj, nil
+}
+
+func (sc *scope) evalCall(value reflect.Value, pos []string) (f.Value, error) {
+	c := call{args: make(map[string]interface{}, value.Len()), scope: sc, pos: pos}
+
+	keys := make([]string, 0, value.Len())
+
+	for _, key := range value.MapKeys() {
+		keys = append(keys, key.String())
+		c.args[key.String()] = value.MapIndex(key).Interface()
+	}
+
+	c.name = funcs.Name(keys)
+
+	if c.name == "lambda" {
+		return sc.newLambda(value.Interface(), c)
+	}
+
+	function, ok := functions[c.name]
+	if !ok {
+		return nil, UnsupportedError{Function: c.name, Position: pos}
+	}
+
+	return function(c)
+}
+
+// lambdaV is a lambda along with the scope it was created in. It embeds the QueryV FaunaDB returns
+// for lambdas, so lambdas can be returned as values.
+type lambdaV struct {
+	f.QueryV
+	params f.Value
+	body   interface{}
+	scope  *scope
+	pos    []string
+}
+
+func (sc *scope) newLambda(expr interface{}, c call) (f.Value, error) {
+	params, err := c.value("lambda")
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var query f.Value
+
+	if err := f.UnmarshalJSON([]byte(`{"@query":`+string(raw)+`}`), &query); err != nil {
+		return nil, err
+	}
+
+	return lambdaV{query.(f.QueryV), params, c.args["expr"], sc, at(c.pos, "expr")}, nil
+}
+
+// lambda evaluates an expression expected to be a lambda.
+func (sc *scope) lambda(expr interface{}, pos []string) (fn lambdaV, err error) {
+	value, err := sc.eval(expr, pos)
+	if err != nil {
+		return
+	}
+
+	switch v := value.(type) {
+	case lambdaV:
+		return v, nil
+
+	case f.QueryV:
+		var raw []byte
+		var query struct {
+			Lambda json.RawMessage `json:"@query"`
+		}
+
+		if raw, err = json.Marshal(v); err == nil {
+			err = json.Unmarshal(raw, &query)
+		}
+
+		var body f.Expr
+
+		if err == nil {
+			err = f.UnmarshalJSONExpr(query.Lambda, &body)
+		}
+
+		if err != nil {
+			return
+		}
+
+		return (&scope{}).lambda(body, pos)
+	}
+
+	err = typeError(pos, "Lambda", value)
+	return
+}
+
+func (fn lambdaV) apply(arg f.Value) (f.Value, error) {
+	inner := &scope{vars: make(map[string]f.Value), parent: fn.scope}
+
+	switch params := fn.params.(type) {
+	case f.StringV:
+		if params != "_" {
+			inner.vars[string(params)] = arg
+		}
+
+	case f.ArrayV:
+		args, ok := arg.(f.ArrayV)
+
+		if !ok {
+			return nil, typeError(fn.pos, "Array", arg)
+		}
+
+		if len(args) != len(params) {
+			return nil, Error{fn.pos, f.ErrInvalidArgument,
+				fmt.Sprintf("Lambda expects an array with %d elements. Array contains %d.", len(params), len(args))}
+		}
+
+		for i, param := range params {
+			if name, ok := param.(f.StringV); ok && name != "_" {
+				inner.vars[string(name)] = args[i]
+			}
+		}
+
+	default:
+		return nil, Error{fn.pos, f.ErrInvalidExpression, "Lambda parameters must be a string or an array of strings."}
+	}
+
+	return inner.eval(fn.body, fn.pos)
+}
+
+// typeName returns the name FaunaDB uses for the type of a value in error descriptions.
+func typeName(value f.Value) string {
+	switch value.(type) {
+	case f.StringV:
+		return "String"
+	case f.LongV:
+		return "Integer"
+	case f.DoubleV:
+		return "Double"
+	case f.BooleanV:
+		return "Boolean"
+	case f.NullV:
+		return "Null"
+	case f.ArrayV:
+		return "Array"
+	case f.ObjectV:
+		return "Object"
+	case f.RefV:
+		return "Ref"
+	case f.SetRefV:
+		return "Set"
+	case f.TimeV:
+		return "Time"
+	case f.DateV:
+		return "Date"
+	case f.BytesV:
+		return "Bytes"
+	case f.QueryV, lambdaV:
+		return "Lambda"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func typeError(pos []string, expected string, value f.Value) error {
+	return Error{pos, f.ErrInvalidArgument, fmt.Sprintf("%s expected, %s provided.", expected, typeName(value))}
+}
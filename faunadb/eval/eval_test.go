@@ -0,0 +1,223 @@
+package eval
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEval(t *testing.T) {
+	page := f.Obj{"data": f.Arr{1, 2, 3}, "after": f.Arr{4}}
+
+	for _, test := range []struct {
+		expr     f.Expr
+		expected f.Value
+	}{
+		{f.Add(1, 2, 3), f.LongV(6)},
+		{f.Add(1, 2.5), f.DoubleV(3.5)},
+		{f.Subtract(10, 2, 3), f.LongV(5)},
+		{f.Multiply(2, 3.0), f.DoubleV(6)},
+		{f.Divide(7, 2), f.LongV(3)},
+		{f.Divide(7.0, 2), f.DoubleV(3.5)},
+		{f.Modulo(7, 4), f.LongV(3)},
+		{f.Max(1, 5, 3), f.LongV(5)},
+		{f.Min(4, 2.5), f.DoubleV(2.5)},
+		{f.Min(0, math.MinInt64), f.LongV(math.MinInt64)},
+		{f.Abs(-3), f.LongV(3)},
+		{f.Add(math.MaxInt64, math.MinInt64), f.LongV(-1)},
+		{f.Multiply(math.MinInt64, 1), f.LongV(math.MinInt64)},
+		{f.Floor(2.7), f.DoubleV(2)},
+		{f.Pow(2, 10), f.DoubleV(1024)},
+		{f.Sign(-0.5), f.DoubleV(-1)},
+		{f.Ln(1), f.DoubleV(0)},
+		{f.Exp(0), f.DoubleV(1)},
+		{f.Hypot(3, 4.0), f.DoubleV(5)},
+		{f.Round(1.2345), f.DoubleV(1.23)},
+		{f.Round(-1.5, f.Precision(0)), f.DoubleV(-2)},
+		{f.Round(1250, f.Precision(-2)), f.LongV(1300)},
+		{f.Round(42), f.LongV(42)},
+		{f.Trunc(1.789, f.Precision(1)), f.DoubleV(1.7)},
+		{f.Trunc(1299, f.Precision(-2)), f.LongV(1200)},
+
+		{f.Equals(1, 1, 1), f.BooleanV(true)},
+		{f.Equals(f.Obj{"a": 1}, f.Obj{"a": 1}), f.BooleanV(true)},
+		{f.Equals(1, 1.0), f.BooleanV(false)},
+		{f.LT(1, 2, 3), f.BooleanV(true)},
+		{f.LTE(1, 1.5, 1.5), f.BooleanV(true)},
+		{f.GT("b", "a"), f.BooleanV(true)},
+		{f.GTE(1, 2), f.BooleanV(false)},
+		{f.And(true, false, f.Abort("not evaluated")), f.BooleanV(false)},
+		{f.Or(false, true), f.BooleanV(true)},
+		{f.Not(true), f.BooleanV(false)},
+
+		{f.Concat(f.Arr{"Hello", "World"}, f.Separator(" ")), f.StringV("Hello World")},
+		{f.Concat("alone"), f.StringV("alone")},
+		{f.Format("%s is %d years old, %.2f%%", "Jane", 42, 0.5), f.StringV("Jane is 42 years old, 0.50%")},
+		{f.Format("%2$s %1$s", "World", "Hello"), f.StringV("Hello World")},
+		{f.Format("%@", f.Obj{"a": 1}), f.StringV(`{ a: 1 }`)},
+		{f.SubString("Fauna", 1), f.StringV("auna")},
+		{f.SubString("Fauna", -3, f.StrLength(2)), f.StringV("un")},
+		{f.ReplaceStr("a-b-c", "-", "+"), f.StringV("a+b+c")},
+		{f.ReplaceStrRegex("a1b22", "[0-9]+", "#"), f.StringV("a#b#")},
+		{f.ReplaceStrRegex("a1b22", "[0-9]+", "#", f.OnlyFirst()), f.StringV("a#b22")},
+		{f.FindStr("fauna", "un"), f.LongV(2)},
+		{f.LowerCase("FaUnA"), f.StringV("fauna")},
+		{f.UpperCase("fauna"), f.StringV("FAUNA")},
+		{f.TitleCase("hELLO"), f.StringV("Hello")},
+		{f.TitleCase("onE fish  tWO\tfiSH"), f.StringV("One Fish  Two\tFish")},
+		{f.TitleCase("o'NEIL jean-luc 2nd"), f.StringV("O'neil Jean-luc 2nd")},
+		{f.Trim("  x  "), f.StringV("x")},
+		{f.LTrim("  x  "), f.StringV("x  ")},
+		{f.Length("héllo"), f.LongV(5)},
+		{f.StartsWith("fauna", "fa"), f.BooleanV(true)},
+		{f.ContainsStr("fauna", "xyz"), f.BooleanV(false)},
+		{f.Repeat("ab", 3), f.StringV("ababab")},
+		{f.Space(2), f.StringV("  ")},
+		{f.ToString(1.0), f.StringV("1.0")},
+		{f.ToString(false), f.StringV("false")},
+		{f.ToNumber("42"), f.LongV(42)},
+		{f.ToNumber("4.5"), f.DoubleV(4.5)},
+
+		{f.Time("2020-01-02T03:04:05.5+01:00"), f.TimeV(time.Date(2020, 1, 2, 2, 4, 5, 5e8, time.UTC))},
+		{f.Date("2020-01-02"), f.DateV(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC))},
+		{f.Epoch(1500, f.TimeUnitMillisecond), f.TimeV(time.Date(1970, 1, 1, 0, 0, 1, 5e8, time.UTC))},
+		{f.Epoch(-1, "seconds"), f.TimeV(time.Date(1969, 12, 31, 23, 59, 59, 0, time.UTC))},
+
+		{f.Select(f.Arr{"a", 1}, f.Obj{"a": f.Arr{"x", "y"}}), f.StringV("y")},
+		{f.Select("b", f.Obj{"a": 1}, f.Default("none")), f.StringV("none")},
+		{f.Contains(f.Arr{"a", "b"}, f.Obj{"a": f.Obj{"b": nil}}), f.BooleanV(true)},
+		{f.Merge(f.Obj{"a": 1, "b": 2}, f.Obj{"b": nil, "c": 3}), f.ObjectV{"a": f.LongV(1), "c": f.LongV(3)}},
+		{f.Merge(f.Obj{"a": 1}, f.Arr{f.Obj{"a": 2}, f.Obj{"a": 3}}), f.ObjectV{"a": f.LongV(3)}},
+		{
+			f.Merge(f.Obj{"a": 1}, f.Obj{"a": 2}, f.ConflictResolver(f.Lambda(f.Arr{"key", "left", "right"}, f.Add(f.Var("left"), f.Var("right"))))),
+			f.ObjectV{"a": f.LongV(3)},
+		},
+
+		{f.Map(f.Arr{1, 2}, f.Lambda("x", f.Multiply(f.Var("x"), 10))), f.ArrayV{f.LongV(10), f.LongV(20)}},
+		{f.Map(f.Arr{f.Arr{1, 2}, f.Arr{3, 4}}, f.Lambda(f.Arr{"a", "_"}, f.Var("a"))), f.ArrayV{f.LongV(1), f.LongV(3)}},
+		{
+			f.Filter(page, f.Lambda("x", f.GT(f.Var("x"), 1))),
+			f.ObjectV{"data": f.ArrayV{f.LongV(2), f.LongV(3)}, "after": f.ArrayV{f.LongV(4)}},
+		},
+		{f.Reduce(f.Lambda(f.Arr{"acc", "x"}, f.Add(f.Var("acc"), f.Var("x"))), 10, f.Arr{1, 2, 3}), f.LongV(16)},
+		{f.Foreach(f.Arr{1}, f.Lambda("x", f.Var("x"))), f.ArrayV{f.LongV(1)}},
+		{f.Take(2, f.Arr{1, 2, 3}), f.ArrayV{f.LongV(1), f.LongV(2)}},
+		{f.Drop(5, f.Arr{1, 2, 3}), f.ArrayV{}},
+		{f.Prepend(f.Arr{0}, f.Arr{1}), f.ArrayV{f.LongV(0), f.LongV(1)}},
+		{f.Append(f.Arr{0}, f.Arr{1}), f.ArrayV{f.LongV(1), f.LongV(0)}},
+		{f.Distinct(f.Arr{1, 2, 1}), f.ArrayV{f.LongV(1), f.LongV(2)}},
+		{f.Union(f.Arr{1, 2, 2}, f.Arr{3, 2, 1, 2, 2}), f.ArrayV{f.LongV(1), f.LongV(2), f.LongV(2), f.LongV(3), f.LongV(2)}},
+		{f.Count(f.Arr{1, 2}), f.LongV(2)},
+		{f.Sum(f.Arr{1, 2.5}), f.DoubleV(3.5)},
+		{f.Mean(f.Arr{1, 2}), f.DoubleV(1.5)},
+		{f.Any(f.Arr{false, true}), f.BooleanV(true)},
+		{f.All(f.Arr{false, true}), f.BooleanV(false)},
+		{f.IsEmpty(f.Arr{}), f.BooleanV(true)},
+		{f.IsNonEmpty(page), f.BooleanV(true)},
+		{f.IsNumber(1.5), f.BooleanV(true)},
+		{f.IsInteger(1.5), f.BooleanV(false)},
+		{f.IsNull(nil), f.BooleanV(true)},
+
+		{f.If(f.Equals(1, 1), "yes", "no"), f.StringV("yes")},
+		{f.Let().Bind("x", 1).Bind("y", f.Add(f.Var("x"), 1)).In(f.Arr{f.Var("x"), f.Var("y")}), f.ArrayV{f.LongV(1), f.LongV(2)}},
+		{f.Do(1, 2), f.LongV(2)},
+		{
+			f.Let().Bind("fn", f.Query(f.Lambda("x", f.Add(f.Var("x"), 1)))).In(f.Map(f.Arr{1}, f.Var("fn"))),
+			f.ArrayV{f.LongV(2)},
+		},
+		{
+			f.Let().Bind("y", 10).In(f.Map(f.Arr{1}, f.Lambda("x", f.Add(f.Var("x"), f.Var("y"))))),
+			f.ArrayV{f.LongV(11)},
+		},
+		{f.Obj{"sum": f.Add(1, 1), "nested": f.Arr{f.Obj{"a": true}}}, f.ObjectV{"sum": f.LongV(2), "nested": f.ArrayV{f.ObjectV{"a": f.BooleanV(true)}}}},
+	} {
+		value, err := Eval(test.expr)
+		require.NoError(t, err, f.FQL(test.expr))
+		require.Equal(t, test.expected, value, f.FQL(test.expr))
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, test := range []struct {
+		expr     f.Expr
+		expected string
+		code     f.ErrorCode
+	}{
+		{f.Add(1, "a"), "[add/1](invalid argument): Number expected, String provided.", f.ErrInvalidArgument},
+		{f.Divide(1, 0), "[](invalid argument): Illegal division by zero.", f.ErrInvalidArgument},
+		{f.Add(math.MaxInt64, 1), "[](invalid argument): Integer overflow.", f.ErrInvalidArgument},
+		{f.Subtract(math.MinInt64, 1), "[](invalid argument): Integer overflow.", f.ErrInvalidArgument},
+		{f.Multiply(math.MaxInt64/2, 3), "[](invalid argument): Integer overflow.", f.ErrInvalidArgument},
+		{f.Multiply(-1, math.MinInt64), "[](invalid argument): Integer overflow.", f.ErrInvalidArgument},
+		{f.Abs(math.MinInt64), "[](invalid argument): Integer overflow.", f.ErrInvalidArgument},
+		{f.Sum(f.Arr{math.MaxInt64, 1}), "[](invalid argument): Integer overflow.", f.ErrInvalidArgument},
+		{f.Add(f.Arr{}), "[](invalid argument): Non-empty array expected.", f.ErrInvalidArgument},
+		{f.Select(f.Arr{"a", "b"}, f.Obj{"a": f.Obj{}}), "[](value not found): Value not found at path [a,b].", f.ErrValueNotFound},
+		{f.Var("x"), "[](invalid expression): Variable 'x' is not defined.", f.ErrInvalidExpression},
+		{f.Abort("boom"), "[](transaction aborted): boom", f.ErrTransactionAborted},
+		{f.If(1, 2, 3), "[if](invalid argument): Boolean expected, Integer provided.", f.ErrInvalidArgument},
+		{f.Not(1), "[not](invalid argument): Boolean expected, Integer provided.", f.ErrInvalidArgument},
+		{f.LT(1, "a"), "[](invalid argument): Cannot compare Integer with String.", f.ErrInvalidArgument},
+		{f.Map(f.Arr{f.Arr{1}}, f.Lambda(f.Arr{"a", "b"}, f.Var("a"))), "[map/expr](invalid argument): Lambda expects an array with 2 elements. Array contains 1.", f.ErrInvalidArgument},
+		{f.Map(f.Arr{1}, 2), "[map](invalid argument): Lambda expected, Integer provided.", f.ErrInvalidArgument},
+		{f.Map(f.Arr{1}, f.Lambda("x", f.Concat(f.Var("x")))), "[map/expr/concat](invalid argument): String expected, Integer provided.", f.ErrInvalidArgument},
+		{f.ToNumber("x"), "[](invalid argument): Cannot cast String to Number.", f.ErrInvalidArgument},
+		{f.Time("yesterday"), "[](invalid argument): Cannot cast 'yesterday' to Time.", f.ErrInvalidArgument},
+		{f.Epoch(1, "fortnight"), "[unit](invalid argument): Invalid time unit 'fortnight'.", f.ErrInvalidArgument},
+		{f.Union(f.Arr{1}, 2), "[union/1](invalid argument): Array expected, Integer provided.", f.ErrInvalidArgument},
+	} {
+		value, err := Eval(test.expr)
+		require.Nil(t, value, f.FQL(test.expr))
+		require.EqualError(t, err, test.expected, f.FQL(test.expr))
+		require.True(t, err.(Error).Is(test.code))
+	}
+}
+
+func TestUnsupportedFunctions(t *testing.T) {
+	_, err := Eval(f.Add(1, f.Select("ts", f.Get(f.RefCollection(f.Collection("users"), "42")))))
+
+	unsupported, ok := err.(UnsupportedError)
+	require.True(t, ok)
+	require.Equal(t, "get", unsupported.Function)
+	require.Equal(t, []string{"add", "1", "from"}, unsupported.Position)
+	require.EqualError(t, err, "eval: unsupported function get at [add/1/from]")
+}
+
+func TestEvalWith(t *testing.T) {
+	value, err := EvalWith(map[string]interface{}{"user": f.Obj{"name": "Jane"}}, f.Select("name", f.Var("user")))
+	require.NoError(t, err)
+	require.Equal(t, f.StringV("Jane"), value)
+}
+
+func TestApply(t *testing.T) {
+	value, err := Apply(f.Lambda(f.Arr{"a", "b"}, f.Add(f.Var("a"), f.Var("b"))), 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, f.LongV(3), value)
+
+	value, err = Apply(f.Query(f.Lambda("name", f.Concat(f.Arr{"Hello", f.Var("name")}, f.Separator(" ")))), "Jane")
+	require.NoError(t, err)
+	require.Equal(t, f.StringV("Hello Jane"), value)
+
+	var body f.Value
+	require.NoError(t, f.UnmarshalJSON([]byte(`{"@query":{"lambda":"x","expr":{"multiply":[{"var":"x"},2.0]}}}`), &body))
+
+	value, err = Apply(body, 21)
+	require.NoError(t, err)
+	require.Equal(t, f.DoubleV(42), value)
+
+	_, err = Apply(f.Add(1, 2), 1)
+	require.EqualError(t, err, "[](invalid argument): Lambda expected, Integer provided.")
+}
+
+func TestEvalReturnsLambdas(t *testing.T) {
+	value, err := Eval(f.Query(f.Lambda("x", f.Var("x"))))
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(value)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"@query":{"lambda":"x","expr":{"var":"x"}}}`, string(raw))
+}
@@ -0,0 +1,1835 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+// call is a function call being evaluated, with its unevaluated arguments.
+type call struct {
+	name  string
+	args  map[string]interface{}
+	scope *scope
+	pos   []string
+}
+
+func (c call) has(key string) bool {
+	_, ok := c.args[key]
+	return ok
+}
+
+func (c call) value(key string) (f.Value, error) { return c.scope.eval(c.args[key], at(c.pos, key)) }
+
+func (c call) errorf(code f.ErrorCode, format string, args ...interface{}) error {
+	return Error{c.pos, code, fmt.Sprintf(format, args...)}
+}
+
+// elements returns the unevaluated elements of a variadic argument, along with their positions. An
+// argument that is not an array literal is evaluated, and its elements are returned if it's an array.
+func (c call) elements(key string) (exprs []interface{}, positions [][]string, err error) {
+	pos := at(c.pos, key)
+	arg := c.args[key]
+
+	if _, isValue := arg.(f.Value); !isValue {
+		if value := reflect.ValueOf(arg); value.Kind() == reflect.Slice {
+			for i := 0; i < value.Len(); i++ {
+				exprs = append(exprs, value.Index(i).Interface())
+				positions = append(positions, at(pos, strconv.Itoa(i)))
+			}
+
+			return
+		}
+	}
+
+	value, err := c.scope.eval(arg, pos)
+	if err != nil {
+		return
+	}
+
+	arr, ok := value.(f.ArrayV)
+	if !ok {
+		arr = f.ArrayV{value}
+	}
+
+	for _, elem := range arr {
+		exprs = append(exprs, elem)
+		positions = append(positions, pos)
+	}
+
+	return
+}
+
+// values evaluates the elements of a variadic argument, checking each of them with check if not nil.
+func (c call) values(key string, check func(pos []string, value f.Value) error) (values []f.Value, err error) {
+	exprs, positions, err := c.elements(key)
+	if err != nil {
+		return
+	}
+
+	for i, expr := range exprs {
+		var value f.Value
+
+		if value, err = c.scope.eval(expr, positions[i]); err != nil {
+			return
+		}
+
+		if check != nil {
+			if err = check(positions[i], value); err != nil {
+				return
+			}
+		}
+
+		values = append(values, value)
+	}
+
+	return
+}
+
+func (c call) numbers(key string) ([]f.Value, error) {
+	values, err := c.values(key, checkNumber)
+
+	if err == nil && len(values) == 0 {
+		err = c.errorf(f.ErrInvalidArgument, "Non-empty array expected.")
+	}
+
+	return values, err
+}
+
+func checkNumber(pos []string, value f.Value) error {
+	switch value.(type) {
+	case f.LongV, f.DoubleV:
+		return nil
+	default:
+		return typeError(pos, "Number", value)
+	}
+}
+
+func (c call) number(key string) (f.Value, error) {
+	value, err := c.value(key)
+	if err == nil {
+		err = checkNumber(at(c.pos, key), value)
+	}
+
+	return value, err
+}
+
+func (c call) integer(key string) (int64, error) {
+	value, err := c.value(key)
+	if err != nil {
+		return 0, err
+	}
+
+	n, ok := value.(f.LongV)
+	if !ok {
+		return 0, typeError(at(c.pos, key), "Integer", value)
+	}
+
+	return int64(n), nil
+}
+
+func (c call) str(key string) (string, error) {
+	value, err := c.value(key)
+	if err != nil {
+		return "", err
+	}
+
+	str, ok := value.(f.StringV)
+	if !ok {
+		return "", typeError(at(c.pos, key), "String", value)
+	}
+
+	return string(str), nil
+}
+
+func (c call) boolean(key string) (bool, error) {
+	value, err := c.value(key)
+	if err != nil {
+		return false, err
+	}
+
+	boolean, ok := value.(f.BooleanV)
+	if !ok {
+		return false, typeError(at(c.pos, key), "Boolean", value)
+	}
+
+	return bool(boolean), nil
+}
+
+// collection evaluates an array, or a page, along with a function rebuilding a collection of the same
+// kind from new elements.
+func (c call) collection(key string) (f.ArrayV, func(f.ArrayV) f.Value, error) {
+	value, err := c.value(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return collectionOf(at(c.pos, key), value)
+}
+
+func collectionOf(pos []string, value f.Value) (f.ArrayV, func(f.ArrayV) f.Value, error) {
+	switch v := value.(type) {
+	case f.ArrayV:
+		return v, func(arr f.ArrayV) f.Value { return arr }, nil
+
+	case f.ObjectV:
+		if data, ok := v["data"].(f.ArrayV); ok {
+			rebuild := func(arr f.ArrayV) f.Value {
+				page := make(f.ObjectV, len(v))
+
+				for key, elem := range v {
+					page[key] = elem
+				}
+
+				page["data"] = arr
+				return page
+			}
+
+			return data, rebuild, nil
+		}
+	}
+
+	return nil, nil, typeError(pos, "Array", value)
+}
+
+func (c call) array(key string) (f.ArrayV, error) {
+	value, err := c.value(key)
+	if err != nil {
+		return nil, err
+	}
+
+	arr, ok := value.(f.ArrayV)
+	if !ok {
+		return nil, typeError(at(c.pos, key), "Array", value)
+	}
+
+	return arr, nil
+}
+
+func (c call) object(key string) (f.ObjectV, error) {
+	value, err := c.value(key)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := value.(f.ObjectV)
+	if !ok {
+		return nil, typeError(at(c.pos, key), "Object", value)
+	}
+
+	return obj, nil
+}
+
+func (c call) lambda(key string) (lambdaV, error) { return c.scope.lambda(c.args[key], at(c.pos, key)) }
+
+var functions map[string]func(c call) (f.Value, error)
+
+func init() {
+	functions = map[string]func(c call) (f.Value, error){
+		"abort": evalAbort, "do": evalDo, "if": evalIf, "let": evalLet, "var": evalVar, "query": evalQuery,
+
+		"add":      arithmetic(addLongs, func(a, b float64) float64 { return a + b }),
+		"subtract": arithmetic(subtractLongs, func(a, b float64) float64 { return a - b }),
+		"multiply": arithmetic(multiplyLongs, func(a, b float64) float64 { return a * b }),
+		"divide":   arithmetic(func(a, b int64) (int64, string) { return safeDivide(a, b, false) }, func(a, b float64) float64 { return a / b }),
+		"modulo":   arithmetic(func(a, b int64) (int64, string) { return safeDivide(a, b, true) }, math.Mod),
+		"max":      arithmetic(func(a, b int64) (int64, string) { return maxInt(a, b), "" }, math.Max),
+		"min":      arithmetic(func(a, b int64) (int64, string) { return minInt(a, b), "" }, math.Min),
+		"abs":      unaryMath(absLong, math.Abs),
+		"ceil":     unaryMath(nil, math.Ceil),
+		"floor":    unaryMath(nil, math.Floor),
+		"sign":     unaryMath(func(n int64) (int64, string) { return sign(n), "" }, signFloat),
+		"sqrt":     evalSqrt,
+		"pow":      evalPow,
+		"ln":       doubleMath(math.Log),
+		"exp":      doubleMath(math.Exp),
+		"hypot":    evalHypot,
+		"round":    precisionMath(math.Round),
+		"trunc":    precisionMath(math.Trunc),
+
+		"equals": evalEquals,
+		"lt":     comparison(func(order int) bool { return order < 0 }),
+		"lte":    comparison(func(order int) bool { return order <= 0 }),
+		"gt":     comparison(func(order int) bool { return order > 0 }),
+		"gte":    comparison(func(order int) bool { return order >= 0 }),
+		"and":    logical(false),
+		"or":     logical(true),
+		"not":    evalNot,
+
+		"concat":          evalConcat,
+		"format":          evalFormat,
+		"substring":       evalSubString,
+		"replacestr":      evalReplaceStr,
+		"replacestrregex": evalReplaceStrRegex,
+		"findstr":         evalFindStr,
+		"lowercase":       stringFunction(strings.ToLower),
+		"uppercase":       stringFunction(strings.ToUpper),
+		"titlecase":       stringFunction(titleCase),
+		"trim":            stringFunction(strings.TrimSpace),
+		"ltrim":           stringFunction(func(s string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) }),
+		"rtrim":           stringFunction(func(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) }),
+		"length":          evalLength,
+		"startswith":      stringPredicate(strings.HasPrefix),
+		"endswith":        stringPredicate(strings.HasSuffix),
+		"containsstr":     stringPredicate(strings.Contains),
+		"repeat":          evalRepeat,
+		"space":           evalSpace,
+		"to_string":       evalToString,
+		"to_number":       evalToNumber,
+
+		"time":  evalTime,
+		"date":  evalDate,
+		"epoch": evalEpoch,
+
+		"select":   evalSelect,
+		"contains": evalContains,
+		"merge":    evalMerge,
+
+		"map":         evalMap,
+		"foreach":     evalForeach,
+		"filter":      evalFilter,
+		"reduce":      evalReduce,
+		"take":        sliceFunction(func(arr f.ArrayV, n int) f.ArrayV { return arr[:n] }),
+		"drop":        sliceFunction(func(arr f.ArrayV, n int) f.ArrayV { return arr[n:] }),
+		"prepend":     joinFunction(func(elems, arr f.ArrayV) f.ArrayV { return append(append(f.ArrayV{}, elems...), arr...) }),
+		"append":      joinFunction(func(elems, arr f.ArrayV) f.ArrayV { return append(append(f.ArrayV{}, arr...), elems...) }),
+		"distinct":    evalDistinct,
+		"union":       evalUnion,
+		"count":       evalCount,
+		"sum":         evalSum,
+		"mean":        evalMean,
+		"any":         quantifier(true),
+		"all":         quantifier(false),
+		"is_empty":    emptiness(true),
+		"is_nonempty": emptiness(false),
+
+		"is_number":  typePredicate(f.LongV(0), f.DoubleV(0)),
+		"is_double":  typePredicate(f.DoubleV(0)),
+		"is_integer": typePredicate(f.LongV(0)),
+		"is_boolean": typePredicate(f.BooleanV(false)),
+		"is_null":    typePredicate(f.NullV{}),
+		"is_string":  typePredicate(f.StringV("")),
+		"is_array":   typePredicate(f.ArrayV{}),
+		"is_object":  typePredicate(f.ObjectV{}),
+	}
+}
+
+// Control functions
+
+func evalAbort(c call) (f.Value, error) {
+	msg, err := c.str("abort")
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.errorf(f.ErrTransactionAborted, "%s", msg)
+}
+
+func evalDo(c call) (value f.Value, err error) {
+	exprs, positions, err := c.elements("do")
+
+	for i := 0; err == nil && i < len(exprs); i++ {
+		value, err = c.scope.eval(exprs[i], positions[i])
+	}
+
+	if err == nil && value == nil {
+		value = f.NullV{}
+	}
+
+	return
+}
+
+func evalIf(c call) (f.Value, error) {
+	cond, err := c.boolean("if")
+	if err != nil {
+		return nil, err
+	}
+
+	if cond {
+		return c.value("then")
+	}
+
+	return c.value("else")
+}
+
+func evalLet(c call) (f.Value, error) {
+	inner := &scope{vars: make(map[string]f.Value), parent: c.scope}
+	bindings := reflect.ValueOf(c.args["let"])
+	pos := at(c.pos, "let")
+
+	bind := func(binding reflect.Value, pos []string) error {
+		for _, key := range binding.MapKeys() {
+			value, err := inner.eval(binding.MapIndex(key).Interface(), at(pos, key.String()))
+			if err != nil {
+				return err
+			}
+
+			inner.vars[key.String()] = value
+		}
+
+		return nil
+	}
+
+	switch bindings.Kind() {
+	case reflect.Slice:
+		for i := 0; i < bindings.Len(); i++ {
+			binding := reflect.ValueOf(bindings.Index(i).Interface())
+
+			if binding.Kind() != reflect.Map {
+				return nil, c.errorf(f.ErrInvalidExpression, "Let bindings must be objects.")
+			}
+
+			if err := bind(binding, at(pos, strconv.Itoa(i))); err != nil {
+				return nil, err
+			}
+		}
+
+	case reflect.Map:
+		if err := bind(bindings, pos); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, c.errorf(f.ErrInvalidExpression, "Let bindings must be an object or an array of objects.")
+	}
+
+	return inner.eval(c.args["in"], at(c.pos, "in"))
+}
+
+func evalVar(c call) (f.Value, error) {
+	name, err := c.str("var")
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := c.scope.lookup(name)
+	if !ok {
+		return nil, c.errorf(f.ErrInvalidExpression, "Variable '%s' is not defined.", name)
+	}
+
+	return value, nil
+}
+
+func evalQuery(c call) (f.Value, error) {
+	fn, err := c.lambda("query")
+	if err != nil {
+		return nil, err
+	}
+
+	return fn, nil
+}
+
+// Math functions
+
+// Descriptions of the errors raised by integer operations.
+const (
+	divisionByZero  = "Illegal division by zero."
+	integerOverflow = "Integer overflow."
+)
+
+// arithmetic applies longOp to pairs of integers, or doubleOp as soon as a double is found. longOp
+// returns the description of the error raised when the operation fails, or an empty string.
+func arithmetic(longOp func(a, b int64) (int64, string), doubleOp func(a, b float64) float64) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		values, err := c.numbers(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		result := values[0]
+
+		for _, value := range values[1:] {
+			a, aIsLong := result.(f.LongV)
+			b, bIsLong := value.(f.LongV)
+
+			if aIsLong && bIsLong {
+				n, failure := longOp(int64(a), int64(b))
+				if failure != "" {
+					return nil, c.errorf(f.ErrInvalidArgument, "%s", failure)
+				}
+
+				result = f.LongV(n)
+			} else {
+				if (c.name == "divide" || c.name == "modulo") && toFloat(value) == 0 {
+					return nil, c.errorf(f.ErrInvalidArgument, divisionByZero)
+				}
+
+				result = f.DoubleV(doubleOp(toFloat(result), toFloat(value)))
+			}
+		}
+
+		return result, nil
+	}
+}
+
+func safeDivide(a, b int64, modulo bool) (int64, string) {
+	switch {
+	case b == 0:
+		return 0, divisionByZero
+	case modulo:
+		return a % b, ""
+	default:
+		return a / b, ""
+	}
+}
+
+func addLongs(a, b int64) (int64, string) {
+	n := a + b
+
+	if (a^n)&(b^n) < 0 {
+		return 0, integerOverflow
+	}
+
+	return n, ""
+}
+
+func subtractLongs(a, b int64) (int64, string) {
+	n := a - b
+
+	if (a^b)&(a^n) < 0 {
+		return 0, integerOverflow
+	}
+
+	return n, ""
+}
+
+func multiplyLongs(a, b int64) (int64, string) {
+	if a == 0 || b == 0 {
+		return 0, ""
+	}
+
+	n := a * b
+
+	if n/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, integerOverflow
+	}
+
+	return n, ""
+}
+
+func absLong(n int64) (int64, string) {
+	switch {
+	case n == math.MinInt64:
+		return 0, integerOverflow
+	case n < 0:
+		return -n, ""
+	default:
+		return n, ""
+	}
+}
+
+func maxInt(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func minInt(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func sign(n int64) int64 {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func signFloat(n float64) float64 {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func toFloat(value f.Value) float64 {
+	if n, ok := value.(f.LongV); ok {
+		return float64(n)
+	}
+
+	return float64(value.(f.DoubleV))
+}
+
+// unaryMath applies longOp to integers, or doubleOp to doubles and to integers if longOp is nil. See
+// arithmetic for the value returned by longOp.
+func unaryMath(longOp func(int64) (int64, string), doubleOp func(float64) float64) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		value, err := c.number(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if n, ok := value.(f.LongV); ok {
+			if longOp == nil {
+				return n, nil
+			}
+
+			result, failure := longOp(int64(n))
+			if failure != "" {
+				return nil, c.errorf(f.ErrInvalidArgument, "%s", failure)
+			}
+
+			return f.LongV(result), nil
+		}
+
+		return f.DoubleV(doubleOp(toFloat(value))), nil
+	}
+}
+
+func evalSqrt(c call) (f.Value, error) {
+	value, err := c.number("sqrt")
+	if err != nil {
+		return nil, err
+	}
+
+	return f.DoubleV(math.Sqrt(toFloat(value))), nil
+}
+
+// doubleMath applies op to a number, returning a double.
+func doubleMath(op func(float64) float64) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		value, err := c.number(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		return f.DoubleV(op(toFloat(value))), nil
+	}
+}
+
+func evalHypot(c call) (f.Value, error) {
+	a, err := c.number("hypot")
+	if err != nil {
+		return nil, err
+	}
+
+	b := a
+
+	if c.has("b") {
+		if b, err = c.number("b"); err != nil {
+			return nil, err
+		}
+	}
+
+	return f.DoubleV(math.Hypot(toFloat(a), toFloat(b))), nil
+}
+
+// precisionMath evaluates Round and Trunc, applying op to a number scaled to the given number of
+// decimal places, 2 by default. Integers are only changed by negative precisions.
+func precisionMath(op func(float64) float64) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		value, err := c.number(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		precision := int64(2)
+
+		if c.has("precision") {
+			if precision, err = c.integer("precision"); err != nil {
+				return nil, err
+			}
+		}
+
+		scale := math.Pow10(int(precision))
+
+		if n, ok := value.(f.LongV); ok {
+			if precision >= 0 {
+				return n, nil
+			}
+
+			return f.LongV(op(float64(n)*scale) / scale), nil
+		}
+
+		return f.DoubleV(op(toFloat(value)*scale) / scale), nil
+	}
+}
+
+func evalPow(c call) (f.Value, error) {
+	base, err := c.number("pow")
+	if err != nil {
+		return nil, err
+	}
+
+	exp := f.Value(f.LongV(2))
+
+	if c.has("exp") {
+		if exp, err = c.number("exp"); err != nil {
+			return nil, err
+		}
+	}
+
+	return f.DoubleV(math.Pow(toFloat(base), toFloat(exp))), nil
+}
+
+// Comparison and logical functions
+
+func evalEquals(c call) (f.Value, error) {
+	values, err := c.values("equals", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values[1:] {
+		if !reflect.DeepEqual(values[0], value) {
+			return f.BooleanV(false), nil
+		}
+	}
+
+	return f.BooleanV(true), nil
+}
+
+// compare orders numbers, strings, booleans, times and dates. It returns false if the values can't
+// be compared.
+func compare(a, b f.Value) (int, bool) {
+	if checkNumber(nil, a) == nil && checkNumber(nil, b) == nil {
+		x, xIsLong := a.(f.LongV)
+		y, yIsLong := b.(f.LongV)
+
+		switch {
+		case xIsLong && yIsLong && x == y:
+			return 0, true
+		case xIsLong && yIsLong && x < y:
+			return -1, true
+		case xIsLong && yIsLong:
+			return 1, true
+		case toFloat(a) < toFloat(b):
+			return -1, true
+		case toFloat(a) > toFloat(b):
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	switch x := a.(type) {
+	case f.StringV:
+		if y, ok := b.(f.StringV); ok {
+			return strings.Compare(string(x), string(y)), true
+		}
+	case f.BooleanV:
+		if y, ok := b.(f.BooleanV); ok {
+			return compareBools(bool(x), bool(y)), true
+		}
+	case f.TimeV:
+		if y, ok := b.(f.TimeV); ok {
+			return compareTimes(time.Time(x), time.Time(y)), true
+		}
+	case f.DateV:
+		if y, ok := b.(f.DateV); ok {
+			return compareTimes(time.Time(x), time.Time(y)), true
+		}
+	}
+
+	return 0, false
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func comparison(holds func(order int) bool) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		values, err := c.values(c.name, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		result := true
+
+		for i := 1; i < len(values); i++ {
+			order, ok := compare(values[i-1], values[i])
+			if !ok {
+				return nil, c.errorf(f.ErrInvalidArgument, "Cannot compare %s with %s.", typeName(values[i-1]), typeName(values[i]))
+			}
+
+			result = result && holds(order)
+		}
+
+		return f.BooleanV(result), nil
+	}
+}
+
+// logical evaluates And and Or, stopping at the first argument equal to stopAt.
+func logical(stopAt bool) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		exprs, positions, err := c.elements(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, expr := range exprs {
+			value, err := c.scope.eval(expr, positions[i])
+			if err != nil {
+				return nil, err
+			}
+
+			boolean, ok := value.(f.BooleanV)
+			if !ok {
+				return nil, typeError(positions[i], "Boolean", value)
+			}
+
+			if bool(boolean) == stopAt {
+				return boolean, nil
+			}
+		}
+
+		return f.BooleanV(!stopAt), nil
+	}
+}
+
+func evalNot(c call) (f.Value, error) {
+	boolean, err := c.boolean("not")
+	if err != nil {
+		return nil, err
+	}
+
+	return f.BooleanV(!boolean), nil
+}
+
+// String functions
+
+func stringValues(c call, key string) ([]string, error) {
+	values, err := c.values(key, func(pos []string, value f.Value) error {
+		if _, ok := value.(f.StringV); !ok {
+			return typeError(pos, "String", value)
+		}
+
+		return nil
+	})
+
+	strs := make([]string, len(values))
+
+	for i, value := range values {
+		strs[i] = string(value.(f.StringV))
+	}
+
+	return strs, err
+}
+
+func evalConcat(c call) (f.Value, error) {
+	strs, err := stringValues(c, "concat")
+	if err != nil {
+		return nil, err
+	}
+
+	var separator string
+
+	if c.has("separator") {
+		if separator, err = c.str("separator"); err != nil {
+			return nil, err
+		}
+	}
+
+	return f.StringV(strings.Join(strs, separator)), nil
+}
+
+var formatVerb = regexp.MustCompile(`%(?:(\d+)\$)?([-#+ 0]*)(\d+)?(?:\.(\d+))?([a-zA-Z@%])`)
+
+func evalFormat(c call) (f.Value, error) {
+	format, err := c.str("format")
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := c.values("values", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var formatErr error
+	next := 0
+
+	result := formatVerb.ReplaceAllStringFunc(format, func(verb string) string {
+		match := formatVerb.FindStringSubmatch(verb)
+		conversion := match[5]
+
+		switch conversion {
+		case "%":
+			return "%"
+		case "n":
+			return "\n"
+		}
+
+		index := next
+		next++
+
+		if match[1] != "" {
+			index, _ = strconv.Atoi(match[1])
+			index--
+		}
+
+		if index < 0 || index >= len(values) {
+			formatErr = c.errorf(f.ErrInvalidArgument, "Missing argument for format specifier '%s'.", verb)
+			return ""
+		}
+
+		value := values[index]
+		spec := "%" + match[2] + match[3]
+
+		if match[4] != "" {
+			spec += "." + match[4]
+		}
+
+		switch conversion {
+		case "s", "S":
+			str, err := toString(value)
+			if err != nil {
+				str = f.FQL(value)
+			}
+
+			if conversion == "S" {
+				str = strings.ToUpper(str)
+			}
+
+			return fmt.Sprintf(spec+"s", str)
+
+		case "@":
+			return fmt.Sprintf(spec+"s", f.FQL(value))
+
+		case "d", "x", "X", "o":
+			if n, ok := value.(f.LongV); ok {
+				return fmt.Sprintf(spec+conversion, int64(n))
+			}
+
+		case "f", "e", "E", "g", "G":
+			if checkNumber(nil, value) == nil {
+				return fmt.Sprintf(spec+conversion, toFloat(value))
+			}
+
+		case "b", "B":
+			if boolean, ok := value.(f.BooleanV); ok {
+				return fmt.Sprintf(spec+"t", bool(boolean))
+			}
+
+		default:
+			formatErr = c.errorf(f.ErrInvalidArgument, "Unsupported format specifier '%s'.", verb)
+			return ""
+		}
+
+		formatErr = c.errorf(f.ErrInvalidArgument, "Format specifier '%s' does not match %s.", verb, typeName(value))
+		return ""
+	})
+
+	if formatErr != nil {
+		return nil, formatErr
+	}
+
+	return f.StringV(result), nil
+}
+
+func evalSubString(c call) (f.Value, error) {
+	str, err := c.str("substring")
+	if err != nil {
+		return nil, err
+	}
+
+	var start int64
+
+	if c.has("start") {
+		if start, err = c.integer("start"); err != nil {
+			return nil, err
+		}
+	}
+
+	runes := []rune(str)
+	size := int64(len(runes))
+
+	if start < 0 {
+		start += size
+	}
+
+	start = clamp(start, 0, size)
+	end := size
+
+	if c.has("length") {
+		length, err := c.integer("length")
+		if err != nil {
+			return nil, err
+		}
+
+		end = clamp(start+length, start, size)
+	}
+
+	return f.StringV(runes[start:end]), nil
+}
+
+func clamp(n, min, max int64) int64 {
+	switch {
+	case n < min:
+		return min
+	case n > max:
+		return max
+	default:
+		return n
+	}
+}
+
+func evalReplaceStr(c call) (f.Value, error) {
+	str, err := c.str("replacestr")
+	if err != nil {
+		return nil, err
+	}
+
+	find, err := c.str("find")
+	if err != nil {
+		return nil, err
+	}
+
+	replace, err := c.str("replace")
+	if err != nil {
+		return nil, err
+	}
+
+	return f.StringV(strings.Replace(str, find, replace, -1)), nil
+}
+
+func evalReplaceStrRegex(c call) (f.Value, error) {
+	str, err := c.str("replacestrregex")
+	if err != nil {
+		return nil, err
+	}
+
+	pattern, err := c.str("pattern")
+	if err != nil {
+		return nil, err
+	}
+
+	replace, err := c.str("replace")
+	if err != nil {
+		return nil, err
+	}
+
+	var first bool
+
+	if c.has("first") {
+		if first, err = c.boolean("first"); err != nil {
+			return nil, err
+		}
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, Error{at(c.pos, "pattern"), f.ErrInvalidArgument, fmt.Sprintf("Invalid regular expression: %s.", err)}
+	}
+
+	if !first {
+		return f.StringV(re.ReplaceAllString(str, replace)), nil
+	}
+
+	if loc := re.FindStringSubmatchIndex(str); loc != nil {
+		replaced := re.ExpandString(nil, replace, str, loc)
+		return f.StringV(str[:loc[0]] + string(replaced) + str[loc[1]:]), nil
+	}
+
+	return f.StringV(str), nil
+}
+
+func evalFindStr(c call) (f.Value, error) {
+	str, err := c.str("findstr")
+	if err != nil {
+		return nil, err
+	}
+
+	find, err := c.str("find")
+	if err != nil {
+		return nil, err
+	}
+
+	var start int64
+
+	if c.has("start") {
+		if start, err = c.integer("start"); err != nil {
+			return nil, err
+		}
+	}
+
+	runes := []rune(str)
+	start = clamp(start, 0, int64(len(runes)))
+
+	index := strings.Index(string(runes[start:]), find)
+	if index < 0 {
+		return f.LongV(-1), nil
+	}
+
+	return f.LongV(start + int64(utf8.RuneCountInString(string(runes[start:])[:index]))), nil
+}
+
+func stringFunction(fn func(string) string) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		str, err := c.str(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		return f.StringV(fn(str)), nil
+	}
+}
+
+// titleCase upper cases the first letter of each word and lower cases the others, words being
+// separated by white space as in FaunaDB, so "hELLO o'NEIL" becomes "Hello O'neil".
+func titleCase(str string) string {
+	runes := []rune(str)
+	start := true
+
+	for i, r := range runes {
+		if start {
+			runes[i] = unicode.ToTitle(r)
+		} else {
+			runes[i] = unicode.ToLower(r)
+		}
+
+		start = unicode.IsSpace(r)
+	}
+
+	return string(runes)
+}
+
+func stringPredicate(fn func(str, search string) bool) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		str, err := c.str(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		search, err := c.str("search")
+		if err != nil {
+			return nil, err
+		}
+
+		return f.BooleanV(fn(str, search)), nil
+	}
+}
+
+func evalLength(c call) (f.Value, error) {
+	str, err := c.str("length")
+	if err != nil {
+		return nil, err
+	}
+
+	return f.LongV(utf8.RuneCountInString(str)), nil
+}
+
+func evalRepeat(c call) (f.Value, error) {
+	str, err := c.str("repeat")
+	if err != nil {
+		return nil, err
+	}
+
+	number := int64(2)
+
+	if c.has("number") {
+		if number, err = c.integer("number"); err != nil {
+			return nil, err
+		}
+	}
+
+	if number < 0 {
+		return nil, c.errorf(f.ErrInvalidArgument, "Number of repetitions must be positive.")
+	}
+
+	return f.StringV(strings.Repeat(str, int(number))), nil
+}
+
+func evalSpace(c call) (f.Value, error) {
+	number, err := c.integer("space")
+	if err != nil {
+		return nil, err
+	}
+
+	if number < 0 {
+		return nil, c.errorf(f.ErrInvalidArgument, "Number of spaces must be positive.")
+	}
+
+	return f.StringV(strings.Repeat(" ", int(number))), nil
+}
+
+func evalToString(c call) (f.Value, error) {
+	value, err := c.value("to_string")
+	if err != nil {
+		return nil, err
+	}
+
+	str, err := toString(value)
+	if err != nil {
+		return nil, c.errorf(f.ErrInvalidArgument, "Cannot cast %s to String.", typeName(value))
+	}
+
+	return f.StringV(str), nil
+}
+
+// toString converts scalar values to strings the way ToString does.
+func toString(value f.Value) (string, error) {
+	switch v := value.(type) {
+	case f.StringV:
+		return string(v), nil
+	case f.LongV:
+		return strconv.FormatInt(int64(v), 10), nil
+	case f.DoubleV:
+		return formatDouble(float64(v)), nil
+	case f.BooleanV:
+		return strconv.FormatBool(bool(v)), nil
+	case f.NullV:
+		return "null", nil
+	case f.TimeV:
+		return time.Time(v).UTC().Format("2006-01-02T15:04:05.999999999Z"), nil
+	case f.DateV:
+		return time.Time(v).Format("2006-01-02"), nil
+	}
+
+	return "", fmt.Errorf("can not convert %s to string", typeName(value))
+}
+
+// formatDouble formats doubles the way the JVM does, as in 1.0, 0.5 or 1.0E21.
+func formatDouble(n float64) string {
+	abs := math.Abs(n)
+
+	if abs == 0 || (abs >= 1e-3 && abs < 1e7) {
+		str := strconv.FormatFloat(n, 'f', -1, 64)
+
+		if !strings.Contains(str, ".") {
+			str += ".0"
+		}
+
+		return str
+	}
+
+	str := strconv.FormatFloat(n, 'E', -1, 64)
+	parts := strings.SplitN(str, "E", 2)
+
+	if !strings.Contains(parts[0], ".") {
+		parts[0] += ".0"
+	}
+
+	exp, _ := strconv.Atoi(parts[1])
+	return parts[0] + "E" + strconv.Itoa(exp)
+}
+
+func evalToNumber(c call) (f.Value, error) {
+	value, err := c.value("to_number")
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case f.LongV, f.DoubleV:
+		return v, nil
+
+	case f.StringV:
+		if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return f.LongV(n), nil
+		}
+
+		if n, err := strconv.ParseFloat(string(v), 64); err == nil {
+			return f.DoubleV(n), nil
+		}
+	}
+
+	return nil, c.errorf(f.ErrInvalidArgument, "Cannot cast %s to Number.", typeName(value))
+}
+
+// Time functions
+
+func evalTime(c call) (f.Value, error) {
+	str, err := c.str("time")
+	if err != nil {
+		return nil, err
+	}
+
+	if str == "now" {
+		return nil, UnsupportedError{Function: "time", Position: c.pos}
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, str)
+	if err != nil {
+		return nil, c.errorf(f.ErrInvalidArgument, "Cannot cast '%s' to Time.", str)
+	}
+
+	return f.TimeV(t.UTC()), nil
+}
+
+func evalDate(c call) (f.Value, error) {
+	str, err := c.str("date")
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := time.Parse("2006-01-02", str)
+	if err != nil {
+		return nil, c.errorf(f.ErrInvalidArgument, "Cannot cast '%s' to Date.", str)
+	}
+
+	return f.DateV(t), nil
+}
+
+// Number of units in a second, by unit accepted by Epoch.
+var epochUnits = map[string]int64{
+	f.TimeUnitSecond: 1, f.TimeUnitMillisecond: 1e3, f.TimeUnitMicrosecond: 1e6, f.TimeUnitNanosecond: 1e9,
+	"seconds": 1, "milliseconds": 1e3, "microseconds": 1e6, "nanoseconds": 1e9,
+}
+
+func evalEpoch(c call) (f.Value, error) {
+	number, err := c.integer("epoch")
+	if err != nil {
+		return nil, err
+	}
+
+	unit, err := c.str("unit")
+	if err != nil {
+		return nil, err
+	}
+
+	perSecond, ok := epochUnits[unit]
+	if !ok {
+		return nil, Error{at(c.pos, "unit"), f.ErrInvalidArgument, fmt.Sprintf("Invalid time unit '%s'.", unit)}
+	}
+
+	seconds, rest := number/perSecond, number%perSecond
+	return f.TimeV(time.Unix(seconds, rest*(1e9/perSecond)).UTC()), nil
+}
+
+// Object functions
+
+// path evaluates a path argument, either a single string or integer, or an array of them.
+func (c call) path(key string) (f.ArrayV, error) {
+	value, err := c.value(key)
+	if err != nil {
+		return nil, err
+	}
+
+	path, ok := value.(f.ArrayV)
+	if !ok {
+		path = f.ArrayV{value}
+	}
+
+	for _, segment := range path {
+		switch segment.(type) {
+		case f.StringV, f.LongV:
+		default:
+			return nil, typeError(at(c.pos, key), "String or Integer", segment)
+		}
+	}
+
+	return path, nil
+}
+
+func selectPath(path f.ArrayV, value f.Value) (f.Value, bool) {
+	for _, segment := range path {
+		var found bool
+
+		switch v := value.(type) {
+		case f.ObjectV:
+			if key, ok := segment.(f.StringV); ok {
+				value, found = v[string(key)]
+			}
+
+		case f.ArrayV:
+			if index, ok := segment.(f.LongV); ok && index >= 0 && int(index) < len(v) {
+				value, found = v[index], true
+			}
+
+		case f.RefV:
+			switch segment {
+			case f.StringV("id"):
+				value, found = f.StringV(v.ID), true
+			case f.StringV("collection"):
+				if v.Collection != nil {
+					value, found = *v.Collection, true
+				}
+			}
+		}
+
+		if !found {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+func evalSelect(c call) (f.Value, error) {
+	path, err := c.path("select")
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := c.value("from")
+	if err != nil {
+		return nil, err
+	}
+
+	if value, found := selectPath(path, from); found {
+		return value, nil
+	}
+
+	if c.has("default") {
+		return c.value("default")
+	}
+
+	segments := make([]string, len(path))
+
+	for i, segment := range path {
+		segments[i] = fmt.Sprint(segment)
+	}
+
+	return nil, c.errorf(f.ErrValueNotFound, "Value not found at path [%s].", strings.Join(segments, ","))
+}
+
+func evalContains(c call) (f.Value, error) {
+	path, err := c.path("contains")
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := c.value("in")
+	if err != nil {
+		return nil, err
+	}
+
+	_, found := selectPath(path, in)
+	return f.BooleanV(found), nil
+}
+
+func evalMerge(c call) (f.Value, error) {
+	merged, err := c.object("merge")
+	if err != nil {
+		return nil, err
+	}
+
+	with, err := c.value("with")
+	if err != nil {
+		return nil, err
+	}
+
+	others, ok := with.(f.ArrayV)
+	if !ok {
+		others = f.ArrayV{with}
+	}
+
+	var resolve *lambdaV
+
+	if c.has("lambda") {
+		fn, err := c.lambda("lambda")
+		if err != nil {
+			return nil, err
+		}
+
+		resolve = &fn
+	}
+
+	result := make(f.ObjectV, len(merged))
+
+	for key, value := range merged {
+		result[key] = value
+	}
+
+	for _, other := range others {
+		obj, ok := other.(f.ObjectV)
+		if !ok {
+			return nil, typeError(at(c.pos, "with"), "Object", other)
+		}
+
+		keys := make([]string, 0, len(obj))
+
+		for key := range obj {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value := obj[key]
+
+			if resolve != nil {
+				left, found := result[key]
+				if !found {
+					left = f.NullV{}
+				}
+
+				if value, err = resolve.apply(f.ArrayV{f.StringV(key), left, value}); err != nil {
+					return nil, err
+				}
+			}
+
+			if _, isNull := value.(f.NullV); isNull {
+				delete(result, key)
+			} else {
+				result[key] = value
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Collection functions
+
+func evalMap(c call) (f.Value, error) {
+	arr, rebuild, err := c.collection("collection")
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := c.lambda("map")
+	if err != nil {
+		return nil, err
+	}
+
+	mapped := make(f.ArrayV, len(arr))
+
+	for i, elem := range arr {
+		if mapped[i], err = fn.apply(elem); err != nil {
+			return nil, err
+		}
+	}
+
+	return rebuild(mapped), nil
+}
+
+func evalForeach(c call) (f.Value, error) {
+	value, err := c.value("collection")
+	if err != nil {
+		return nil, err
+	}
+
+	arr, _, err := collectionOf(at(c.pos, "collection"), value)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := c.lambda("foreach")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, elem := range arr {
+		if _, err := fn.apply(elem); err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+func evalFilter(c call) (f.Value, error) {
+	arr, rebuild, err := c.collection("collection")
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := c.lambda("filter")
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := f.ArrayV{}
+
+	for _, elem := range arr {
+		keep, err := fn.apply(elem)
+		if err != nil {
+			return nil, err
+		}
+
+		boolean, ok := keep.(f.BooleanV)
+		if !ok {
+			return nil, typeError(at(c.pos, "filter", "expr"), "Boolean", keep)
+		}
+
+		if boolean {
+			filtered = append(filtered, elem)
+		}
+	}
+
+	return rebuild(filtered), nil
+}
+
+func evalReduce(c call) (f.Value, error) {
+	fn, err := c.lambda("reduce")
+	if err != nil {
+		return nil, err
+	}
+
+	acc, err := c.value("initial")
+	if err != nil {
+		return nil, err
+	}
+
+	arr, _, err := c.collection("collection")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, elem := range arr {
+		if acc, err = fn.apply(f.ArrayV{acc, elem}); err != nil {
+			return nil, err
+		}
+	}
+
+	return acc, nil
+}
+
+func sliceFunction(slice func(arr f.ArrayV, n int) f.ArrayV) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		n, err := c.integer(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		arr, rebuild, err := c.collection("collection")
+		if err != nil {
+			return nil, err
+		}
+
+		return rebuild(slice(arr, int(clamp(n, 0, int64(len(arr)))))), nil
+	}
+}
+
+func joinFunction(join func(elems, arr f.ArrayV) f.ArrayV) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		elems, err := c.array(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		arr, rebuild, err := c.collection("collection")
+		if err != nil {
+			return nil, err
+		}
+
+		return rebuild(join(elems, arr)), nil
+	}
+}
+
+func evalDistinct(c call) (f.Value, error) {
+	arr, rebuild, err := c.collection("distinct")
+	if err != nil {
+		return nil, err
+	}
+
+	distinct := f.ArrayV{}
+
+	for _, elem := range arr {
+		seen := false
+
+		for _, other := range distinct {
+			if reflect.DeepEqual(elem, other) {
+				seen = true
+				break
+			}
+		}
+
+		if !seen {
+			distinct = append(distinct, elem)
+		}
+	}
+
+	return rebuild(distinct), nil
+}
+
+// evalUnion evaluates the union of arrays, holding each element as many times as the array holding it
+// the most times. The union of sets reads the database, so it can't be evaluated.
+func evalUnion(c call) (f.Value, error) {
+	values, err := c.values("union", func(pos []string, value f.Value) error {
+		switch value.(type) {
+		case f.ArrayV:
+			return nil
+		case f.SetRefV:
+			return UnsupportedError{Function: "union", Position: c.pos}
+		default:
+			return typeError(pos, "Array", value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	union := f.ArrayV{}
+
+	for _, value := range values {
+		seen := f.ArrayV{}
+
+		for _, elem := range value.(f.ArrayV) {
+			if occurrences(seen, elem) >= occurrences(union, elem) {
+				union = append(union, elem)
+			}
+
+			seen = append(seen, elem)
+		}
+	}
+
+	return union, nil
+}
+
+func occurrences(arr f.ArrayV, elem f.Value) (count int) {
+	for _, other := range arr {
+		if reflect.DeepEqual(elem, other) {
+			count++
+		}
+	}
+
+	return
+}
+
+func evalCount(c call) (f.Value, error) {
+	arr, err := c.array("count")
+	if err != nil {
+		return nil, err
+	}
+
+	return f.LongV(len(arr)), nil
+}
+
+func (c call) numbersOf(key string) (f.ArrayV, error) {
+	arr, err := c.array(key)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, elem := range arr {
+		if err := checkNumber(at(c.pos, key), elem); err != nil {
+			return nil, err
+		}
+	}
+
+	return arr, nil
+}
+
+func evalSum(c call) (f.Value, error) {
+	arr, err := c.numbersOf("sum")
+	if err != nil {
+		return nil, err
+	}
+
+	var sum f.Value = f.LongV(0)
+
+	for _, elem := range arr {
+		a, aIsLong := sum.(f.LongV)
+		b, bIsLong := elem.(f.LongV)
+
+		if aIsLong && bIsLong {
+			n, failure := addLongs(int64(a), int64(b))
+			if failure != "" {
+				return nil, c.errorf(f.ErrInvalidArgument, "%s", failure)
+			}
+
+			sum = f.LongV(n)
+		} else {
+			sum = f.DoubleV(toFloat(sum) + toFloat(elem))
+		}
+	}
+
+	return sum, nil
+}
+
+func evalMean(c call) (f.Value, error) {
+	arr, err := c.numbersOf("mean")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(arr) == 0 {
+		return nil, c.errorf(f.ErrInvalidArgument, "Non-empty array expected.")
+	}
+
+	var sum float64
+
+	for _, elem := range arr {
+		sum += toFloat(elem)
+	}
+
+	return f.DoubleV(sum / float64(len(arr))), nil
+}
+
+// quantifier evaluates Any and All, returning found as soon as an element equal to found is seen.
+func quantifier(found bool) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		arr, err := c.array(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		result := !found
+
+		for _, elem := range arr {
+			boolean, ok := elem.(f.BooleanV)
+			if !ok {
+				return nil, typeError(at(c.pos, c.name), "Boolean", elem)
+			}
+
+			if bool(boolean) == found {
+				result = found
+			}
+		}
+
+		return f.BooleanV(result), nil
+	}
+}
+
+func emptiness(empty bool) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		arr, _, err := c.collection(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		return f.BooleanV((len(arr) == 0) == empty), nil
+	}
+}
+
+func typePredicate(examples ...f.Value) func(c call) (f.Value, error) {
+	return func(c call) (f.Value, error) {
+		value, err := c.value(c.name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, example := range examples {
+			if reflect.TypeOf(value) == reflect.TypeOf(example) {
+				return f.BooleanV(true), nil
+			}
+		}
+
+		return f.BooleanV(false), nil
+	}
+}
@@ -58,6 +58,9 @@ func applyOptionals(options []OptionalParameter, fn unescapedObj) Expr {
 	for _, option := range options {
 		option(fn)
 	}
+	if trackingBuilderCalls() {
+		trackBuilderCall(fn)
+	}
 	return fn
 }
 
@@ -2,64 +2,101 @@ package faunadb
 
 import (
 	"fmt"
+	"io"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/fauna/faunadb-go/faunadb/recorder"
 )
 
+/*
+The integration suite runs against FaunaDB when FAUNA_ROOT_KEY is set, and replays the HTTP exchanges
+recorded in clientCassette otherwise. To record them again, run the suite with FAUNA_RECORD=1 and
+FAUNA_ROOT_KEY set to an admin key of the cluster. Random database and schema names are saved to the
+cassette, so recorded queries match when replayed.
+*/
 var (
 	faunaSecret   = os.Getenv("FAUNA_ROOT_KEY")
 	faunaEndpoint = os.Getenv("FAUNA_ENDPOINT")
+	faunaRecord   = os.Getenv("FAUNA_RECORD") != ""
 
 	allQueriesTimeout = os.Getenv("FAUNA_QUERY_TIMEOUT_MS")
 
+	clientCassette = filepath.Join("testdata", "client_test.json")
+
 	dbName string
 	dbRef  Expr
 
 	adminClient *FaunaClient
+	rec         *recorder.Recorder
 )
 
 func init() {
 	rand.Seed(time.Now().UTC().UnixNano()) // By default, the seed is always 1
 
-	if faunaSecret == "" {
-		panic("FAUNA_ROOT_KEY environment variable must be specified")
+	if faunaRecord && faunaSecret == "" {
+		panic("FAUNA_ROOT_KEY environment variable must be specified to record the integration suite")
 	}
 
 	if faunaEndpoint == "" {
 		faunaEndpoint = defaultEndpoint
 	}
-
-	dbName = RandomStartingWith("faunadb-go-test-")
-	dbRef = Database(dbName)
 }
 
+// RandomStartingWith returns a random name starting with the given parts. When recording or replaying
+// the integration suite, names are saved to the cassette or read from it.
 func RandomStartingWith(parts ...string) string {
-	return fmt.Sprintf("%s%v", strings.Join(parts, ""), rand.Uint32())
+	random := func() string { return fmt.Sprintf("%s%v", strings.Join(parts, ""), rand.Uint32()) }
+
+	if rec == nil {
+		return random()
+	}
+
+	name, err := rec.Value(random)
+	if err != nil {
+		panic(err)
+	}
+
+	return name
 }
 
 func SetupTestDB() (client *FaunaClient, err error) {
 	var key Value
 
-	adminClient = NewFaunaClient(
-		faunaSecret,
-		Endpoint(faunaEndpoint),
-	)
+	secret := faunaSecret
+	options := []ClientConfig{Endpoint(faunaEndpoint)}
+
+	if faunaRecord || faunaSecret == "" {
+		if rec, err = newTestRecorder(); err != nil {
+			return
+		}
+
+		if faunaSecret == "" {
+			secret = "secret" // Requests are replayed, the secret is not sent
+		}
+
+		options = append(options, HTTP(rec.Client()))
+	}
+
 	if allQueriesTimeout != "" {
 		if millis, err := strconv.ParseUint(allQueriesTimeout, 10, 64); err == nil {
-			adminClient = NewFaunaClient(
-				faunaSecret,
-				Endpoint(faunaEndpoint),
-				QueryTimeoutMS(millis),
-			)
+			options = append(options, QueryTimeoutMS(millis))
 		} else {
 			panic("FAUNA_QUERY_TIMEOUT_MS environment variable must be an integer.")
 		}
 	}
 
-	DeleteTestDB()
+	adminClient = NewFaunaClient(secret, options...)
+	dbName = RandomStartingWith("faunadb-go-test-")
+	dbRef = Database(dbName)
+
+	deleteTestDB()
 
 	if err = createTestDatabase(); err == nil {
 		if key, err = CreateKeyWithRole("server"); err == nil {
@@ -70,7 +107,33 @@ func SetupTestDB() (client *FaunaClient, err error) {
 	return
 }
 
+// newTestRecorder returns a recorder recording the integration suite with FAUNA_RECORD, and replaying
+// it otherwise.
+func newTestRecorder() (*recorder.Recorder, error) {
+	if faunaRecord {
+		return recorder.New(clientCassette, recorder.Record)
+	}
+
+	recorded, err := recorder.New(clientCassette, recorder.Replay)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("FAUNA_ROOT_KEY environment variable must be specified, no recorded integration suite at %s", clientCassette)
+	}
+
+	return recorded, err
+}
+
+// DeleteTestDB deletes the test database, and saves the recorded exchanges when recording.
 func DeleteTestDB() {
+	deleteTestDB()
+
+	if rec != nil {
+		if err := rec.Stop(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func deleteTestDB() {
 	_, _ = adminClient.Query(Delete(dbRef)) // Ignore error because db may not exist
 }
 
@@ -106,3 +169,15 @@ func GetSecret(key Value) (secret string) {
 func DbRef() *RefV {
 	return &RefV{dbName, NativeDatabases(), NativeDatabases(), nil}
 }
+
+func newTestServer(handler http.HandlerFunc) (*httptest.Server, *FaunaClient) {
+	server := httptest.NewServer(handler)
+	return server, NewFaunaClient("secret", Endpoint(server.URL))
+}
+
+func respondWith(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}
+}
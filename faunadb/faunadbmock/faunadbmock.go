@@ -0,0 +1,343 @@
+/*
+Package faunadbmock provides a mock implementation of faunadb.Querier, to unit test code issuing
+queries without a FaunaDB endpoint.
+
+Expectations are registered for the expressions the code under test is expected to issue, along
+with the value or error to respond with. An expression matches an expectation when it is
+structurally equal to the expected one, that is when both are encoded to the same query. For example:
+
+	mock := faunadbmock.New()
+	mock.ExpectQuery(f.Get(f.Ref(f.Collection("users"), "42"))).
+		ReturnJSON(`{"ref":{"@ref":{"id":"42","collection":{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}}}}}},"ts":1,"data":{"name":"Jane"}}`)
+	mock.ExpectQuery(f.Delete(f.Ref(f.Collection("users"), "42"))).
+		ReturnError(f.NotFound{})
+
+	service := UserService{db: mock}
+	// exercise the service...
+
+	mock.AssertExpectations(t)
+
+Queries matching no expectation fail with an UnexpectedQueryError. Batches of queries are matched
+as a single array of expressions, the query FaunaClient.BatchQuery issues, and respond with an array
+of values:
+
+	mock.ExpectQuery(f.Arr{f.Get(userRef), f.Get(postRef)}).
+		Return(f.ArrayV{user, post})
+*/
+package faunadbmock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+// TestingT is the subset of testing.T used by AssertExpectations.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// UnexpectedQueryError is returned for queries matching no expectation, or matching only
+// expectations already met as many times as expected.
+type UnexpectedQueryError struct {
+	Expr f.Expr
+}
+
+func (err UnexpectedQueryError) Error() string {
+	return fmt.Sprintf("faunadbmock: unexpected query %s", f.FQL(err.Expr))
+}
+
+// Expectation is a query expected by a Mock, along with its response.
+type Expectation struct {
+	expr    f.Expr
+	match   func(expr f.Expr) bool
+	value   f.Value
+	headers map[string][]string
+	err     error
+	times   int
+	calls   int
+}
+
+// Return sets the value returned for the expected query. It defaults to null.
+func (exp *Expectation) Return(value f.Value) *Expectation {
+	exp.value = value
+	return exp
+}
+
+// ReturnJSON sets the value returned for the expected query from its JSON representation, as
+// returned by FaunaDB in the resource field of responses. It panics if the JSON is not valid.
+func (exp *Expectation) ReturnJSON(raw string) *Expectation {
+	var value f.Value
+
+	if err := f.UnmarshalJSON([]byte(raw), &value); err != nil {
+		panic(fmt.Sprintf("faunadbmock: invalid JSON value %s: %s", raw, err))
+	}
+
+	return exp.Return(value)
+}
+
+// ReturnError sets the error returned for the expected query.
+func (exp *Expectation) ReturnError(err error) *Expectation {
+	exp.err = err
+	return exp
+}
+
+// ReturnHeaders sets the headers returned by QueryResult and BatchQueryResult for the expected
+// query.
+func (exp *Expectation) ReturnHeaders(headers map[string][]string) *Expectation {
+	exp.headers = headers
+	return exp
+}
+
+// Times sets how many times the query is expected. Expectations are met once by default, and a
+// negative number of times expects the query any number of times, including none.
+func (exp *Expectation) Times(n int) *Expectation {
+	exp.times = n
+	return exp
+}
+
+func (exp *Expectation) String() string {
+	if exp.match != nil {
+		return "query matching a function"
+	}
+
+	return f.FQL(exp.expr)
+}
+
+func (exp *Expectation) exhausted() bool { return exp.times >= 0 && exp.calls >= exp.times }
+
+func (exp *Expectation) met() bool { return exp.times < 0 || exp.calls >= exp.times }
+
+// Mock is a faunadb.Querier responding to queries with the responses of its expectations. Queries
+// are matched with expectations in the order they were registered, skipping expectations already
+// met as many times as expected. It's safe for concurrent use.
+type Mock struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []f.Expr
+	queries      []f.Expr
+}
+
+var _ f.Querier = (*Mock)(nil)
+
+// New creates a Mock with no expectations.
+func New() *Mock { return &Mock{} }
+
+// ExpectQuery registers an expectation for queries structurally equal to the given expression.
+func (mock *Mock) ExpectQuery(expr f.Expr) *Expectation {
+	return mock.expect(&Expectation{expr: expr, times: 1})
+}
+
+// ExpectQueryFunc registers an expectation for queries the given function returns true for. The
+// function is called without holding the mock's lock, so it can use the mock.
+func (mock *Mock) ExpectQueryFunc(match func(expr f.Expr) bool) *Expectation {
+	return mock.expect(&Expectation{match: match, times: 1})
+}
+
+func (mock *Mock) expect(exp *Expectation) *Expectation {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	mock.expectations = append(mock.expectations, exp)
+	return exp
+}
+
+// Queries returns the expressions queried so far, in order.
+func (mock *Mock) Queries() []f.Expr {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	return append([]f.Expr(nil), mock.queries...)
+}
+
+// ExpectationsWereMet returns an error describing the expected queries that were not issued, and
+// the unexpected ones that were.
+func (mock *Mock) ExpectationsWereMet() error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	var problems []string
+
+	for _, exp := range mock.expectations {
+		if !exp.met() {
+			problems = append(problems, fmt.Sprintf("expected %s %d time(s), got %d", exp, exp.times, exp.calls))
+		}
+	}
+
+	for _, expr := range mock.unexpected {
+		problems = append(problems, fmt.Sprintf("unexpected query %s", f.FQL(expr)))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("faunadbmock: %s", strings.Join(problems, "; "))
+}
+
+// AssertExpectations reports an error to t if the expectations were not met.
+func (mock *Mock) AssertExpectations(t TestingT) {
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("%s", err)
+	}
+}
+
+// Query responds with the response of the first expectation matching the expression.
+func (mock *Mock) Query(expr f.Expr, configs ...f.QueryConfig) (f.Value, error) {
+	value, _, err := mock.QueryResultWithConfig(expr, configs...)
+	return value, err
+}
+
+// QueryResult is like Query, also returning the headers of the matching expectation.
+func (mock *Mock) QueryResult(expr f.Expr) (f.Value, map[string][]string, error) {
+	return mock.QueryResultWithConfig(expr)
+}
+
+// QueryResultWithConfig is like QueryResult. Query configurations are ignored.
+func (mock *Mock) QueryResultWithConfig(expr f.Expr, configs ...f.QueryConfig) (f.Value, map[string][]string, error) {
+	mock.mu.Lock()
+	mock.queries = append(mock.queries, expr)
+	mock.mu.Unlock()
+
+	exp := mock.claim(expr)
+
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if exp == nil {
+		mock.unexpected = append(mock.unexpected, expr)
+		return nil, nil, UnexpectedQueryError{expr}
+	}
+
+	if exp.err != nil {
+		return nil, exp.headers, exp.err
+	}
+
+	if exp.value == nil {
+		return f.NullV{}, exp.headers, nil
+	}
+
+	return exp.value, exp.headers, nil
+}
+
+// BatchQuery responds with the values of the expectation matching the array of expressions, as
+// FaunaClient.BatchQuery issues them in a single query.
+func (mock *Mock) BatchQuery(exprs []f.Expr) ([]f.Value, error) {
+	return mock.BatchQueryWithConfig(exprs)
+}
+
+// BatchQueryWithConfig is like BatchQuery. Query configurations are ignored.
+func (mock *Mock) BatchQueryWithConfig(exprs []f.Expr, configs ...f.QueryConfig) ([]f.Value, error) {
+	values, _, err := mock.BatchQueryResultWithConfig(exprs, configs...)
+	return values, err
+}
+
+// BatchQueryResult is like BatchQuery, also returning the headers of the matching expectation.
+func (mock *Mock) BatchQueryResult(exprs []f.Expr) ([]f.Value, map[string][]string, error) {
+	return mock.BatchQueryResultWithConfig(exprs)
+}
+
+// BatchQueryResultWithConfig is like BatchQueryResult. Query configurations are ignored.
+func (mock *Mock) BatchQueryResultWithConfig(exprs []f.Expr, configs ...f.QueryConfig) ([]f.Value, map[string][]string, error) {
+	batch := make(f.Arr, len(exprs))
+
+	for i, expr := range exprs {
+		batch[i] = expr
+	}
+
+	value, headers, err := mock.QueryResultWithConfig(batch, configs...)
+	if err != nil {
+		return nil, headers, err
+	}
+
+	var values []f.Value
+
+	if err := value.Get(&values); err != nil {
+		return nil, headers, err
+	}
+
+	return values, headers, nil
+}
+
+// QueryAt responds to the expression wrapped in At, as FaunaClient.QueryAt issues it.
+func (mock *Mock) QueryAt(ts int64, expr f.Expr, configs ...f.QueryConfig) (f.Value, error) {
+	return mock.Query(f.At(ts, expr), configs...)
+}
+
+// History returns an iterator over the events of a document, issuing the same queries as
+// FaunaClient.History.
+func (mock *Mock) History(ref interface{}, from, to int64, configs ...f.QueryConfig) *f.EventIterator {
+	return f.HistoryOf(mock, ref, from, to, configs...)
+}
+
+// SetEvents returns an iterator over the events of a set, issuing the same queries as
+// FaunaClient.SetEvents.
+func (mock *Mock) SetEvents(set interface{}, options ...f.OptionalParameter) *f.EventIterator {
+	return f.SetEventsOf(mock, set, options...)
+}
+
+// claim returns the first expectation matching the expression, counting the call, or nil if none
+// matches. Expectations are matched without holding the lock, and matched again if another query
+// met the matching expectation in the meantime.
+func (mock *Mock) claim(expr f.Expr) *Expectation {
+	for {
+		exp := mock.find(expr, mock.pending())
+		if exp == nil {
+			return nil
+		}
+
+		mock.mu.Lock()
+		claimed := !exp.exhausted()
+
+		if claimed {
+			exp.calls++
+		}
+
+		mock.mu.Unlock()
+
+		if claimed {
+			return exp
+		}
+	}
+}
+
+// pending returns the expectations not yet met as many times as expected.
+func (mock *Mock) pending() (pending []*Expectation) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	for _, exp := range mock.expectations {
+		if !exp.exhausted() {
+			pending = append(pending, exp)
+		}
+	}
+
+	return
+}
+
+func (mock *Mock) find(expr f.Expr, expectations []*Expectation) *Expectation {
+	encoded, err := json.Marshal(expr)
+	if err != nil {
+		return nil
+	}
+
+	for _, exp := range expectations {
+		if exp.match != nil {
+			if exp.match(expr) {
+				return exp
+			}
+
+			continue
+		}
+
+		if expected, err := json.Marshal(exp.expr); err == nil && bytes.Equal(expected, encoded) {
+			return exp
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package faunadbmock
+
+import (
+	"testing"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, format)
+}
+
+func TestQueryMatchesStructurally(t *testing.T) {
+	mock := New()
+	mock.ExpectQuery(f.Add(1, 2)).Return(f.LongV(3))
+	mock.ExpectQuery(f.Obj{"name": "Jane", "age": 42}).ReturnJSON(`{"ok":true}`)
+
+	value, err := mock.Query(f.Obj{"age": 42, "name": "Jane"})
+	require.NoError(t, err)
+	require.Equal(t, f.ObjectV{"ok": f.BooleanV(true)}, value)
+
+	value, err = mock.Query(f.Add(f.Arr{1, 2}))
+	require.NoError(t, err)
+	require.Equal(t, f.LongV(3), value)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryReturnsErrors(t *testing.T) {
+	mock := New()
+	mock.ExpectQuery(f.Get(f.Ref(f.Collection("users"), "42"))).ReturnError(f.NotFound{})
+
+	_, err := mock.Query(f.Get(f.Ref(f.Collection("users"), "42")))
+	require.Equal(t, f.NotFound{}, err)
+
+	_, err = mock.Query(f.Get(f.Ref(f.Collection("users"), "42")))
+	require.IsType(t, UnexpectedQueryError{}, err)
+	require.EqualError(t, err, `faunadbmock: unexpected query Get(Ref(Collection("users"), "42"))`)
+
+	rec := &recordingT{}
+	mock.AssertExpectations(rec)
+	require.Len(t, rec.errors, 1)
+}
+
+func TestTimes(t *testing.T) {
+	mock := New()
+	mock.ExpectQuery(f.Now()).Times(2)
+	mock.ExpectQueryFunc(func(expr f.Expr) bool { return true }).Times(-1).Return(f.StringV("any"))
+
+	value, err := mock.Query(f.Now())
+	require.NoError(t, err)
+	require.Equal(t, f.NullV{}, value)
+
+	require.EqualError(t, mock.ExpectationsWereMet(), "faunadbmock: expected Now() 2 time(s), got 1")
+
+	value, err = mock.Query(f.Now())
+	require.NoError(t, err)
+	require.Equal(t, f.NullV{}, value)
+
+	value, err = mock.Query(f.Now())
+	require.NoError(t, err)
+	require.Equal(t, f.StringV("any"), value)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, mock.Queries(), 3)
+}
+
+func TestBatchQuery(t *testing.T) {
+	mock := New()
+	mock.ExpectQuery(f.Arr{f.Add(1, 2), f.Add(3, 4)}).Return(f.ArrayV{f.LongV(3), f.LongV(7)}).ReturnHeaders(map[string][]string{"X-Txn-Time": {"1"}})
+	mock.ExpectQuery(f.At(10, f.Now())).Return(f.StringV("then"))
+
+	var querier f.Querier = mock
+
+	values, headers, err := querier.BatchQueryResult([]f.Expr{f.Add(1, 2), f.Add(3, 4)})
+	require.NoError(t, err)
+	require.Equal(t, []f.Value{f.LongV(3), f.LongV(7)}, values)
+	require.Equal(t, []string{"1"}, headers["X-Txn-Time"])
+
+	value, err := querier.QueryAt(10, f.Now())
+	require.NoError(t, err)
+	require.Equal(t, f.StringV("then"), value)
+
+	_, err = querier.BatchQuery([]f.Expr{f.Now()})
+	require.IsType(t, UnexpectedQueryError{}, err)
+}
+
+func TestQueryFuncCanUseMock(t *testing.T) {
+	mock := New()
+	mock.ExpectQueryFunc(func(expr f.Expr) bool { return len(mock.Queries()) == 1 }).Return(f.StringV("first"))
+
+	value, err := mock.Query(f.Now())
+	require.NoError(t, err)
+	require.Equal(t, f.StringV("first"), value)
+
+	_, err = mock.Query(f.Now())
+	require.IsType(t, UnexpectedQueryError{}, err)
+}
+
+func TestHistory(t *testing.T) {
+	ref := f.Ref(f.Collection("users"), "42")
+
+	mock := New()
+	mock.ExpectQueryFunc(func(expr f.Expr) bool { return true }).ReturnJSON(
+		`{"data":[{"ts":10,"action":"create","document":{"@ref":{"id":"42","collection":{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}}}}}},"data":{"name":"Jane"}}]}`)
+
+	events := mock.History(ref, 0, 0)
+	require.True(t, events.Next())
+	require.Equal(t, int64(10), events.Event().TS)
+	require.False(t, events.Next())
+	require.NoError(t, events.Err())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, mock.Queries(), 1)
+}
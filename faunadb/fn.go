@@ -0,0 +1,107 @@
+package faunadb
+
+import (
+	"strconv"
+	"sync"
+)
+
+// FnParamPrefix starts the names of the parameters of the lambdas created by Fn and its variants.
+// Variable names starting with it are reserved.
+const FnParamPrefix = "__fn"
+
+/*
+Fn creates a Lambda from a Go function. The function is called once, with a Var referencing the
+lambda's parameter, to build the lambda's body. Parameter names are generated from the nesting depth
+of lambdas, so building the same lambda twice encodes the same query, and nested lambdas can freely
+reference the parameters of enclosing ones. Generated names start with FnParamPrefix. For example:
+
+	Map(Paginate(Documents(Collection("users"))), Fn(func(ref Expr) Expr {
+		return Get(ref)
+	}))
+*/
+func Fn(body func(x Expr) Expr) Expr {
+	lambda := newFnLambda(1)
+	return lambda.build(lambda.params[0], func() Expr { return body(lambda.vars[0]) })
+}
+
+/*
+Fn2 creates a Lambda taking two parameters. The lambda destructures an array of two elements, such as
+the values of an index entry, into its parameters. For example, given an index with the ts and ref of
+documents as values:
+
+	Map(Paginate(Match(Index("users_by_ts"))), Fn2(func(ts, ref Expr) Expr {
+		return Obj{"ts": ts, "user": Get(ref)}
+	}))
+
+See Fn.
+*/
+func Fn2(body func(x, y Expr) Expr) Expr {
+	lambda := newFnLambda(2)
+	return lambda.build(lambda.params, func() Expr { return body(lambda.vars[0], lambda.vars[1]) })
+}
+
+// Fn3 creates a Lambda taking three parameters, destructuring an array of three elements. See Fn2.
+func Fn3(body func(x, y, z Expr) Expr) Expr {
+	lambda := newFnLambda(3)
+	return lambda.build(lambda.params, func() Expr { return body(lambda.vars[0], lambda.vars[1], lambda.vars[2]) })
+}
+
+// FnN creates a Lambda taking n parameters, destructuring an array of n elements. The body is called
+// with a Var for each parameter, in order. See Fn2.
+func FnN(n int, body func(args ...Expr) Expr) Expr {
+	lambda := newFnLambda(n)
+	return lambda.build(lambda.params, func() Expr { return body(lambda.vars...) })
+}
+
+// fnDepths counts the lambdas being built by Fn at each nesting depth. A lambda takes the lowest depth
+// that no lambda being built has, so its parameters, named after that depth, never shadow the
+// parameters of the lambdas enclosing it. Building a lambda from a single goroutine always gives it
+// the same depth, its nesting in the lambdas created by Fn.
+var fnDepths struct {
+	sync.Mutex
+	active []int
+}
+
+// fnLambda holds the parameters of a lambda being built by Fn.
+type fnLambda struct {
+	depth  int
+	params Arr
+	vars   []Expr
+}
+
+func newFnLambda(n int) *fnLambda {
+	fnDepths.Lock()
+	depth := 0
+
+	for depth < len(fnDepths.active) && fnDepths.active[depth] > 0 {
+		depth++
+	}
+
+	if depth == len(fnDepths.active) {
+		fnDepths.active = append(fnDepths.active, 0)
+	}
+
+	fnDepths.active[depth]++
+	fnDepths.Unlock()
+
+	lambda := &fnLambda{depth: depth, params: make(Arr, n), vars: make([]Expr, n)}
+
+	for i := range lambda.params {
+		name := FnParamPrefix + strconv.Itoa(depth) + "_" + strconv.Itoa(i)
+		lambda.params[i] = name
+		lambda.vars[i] = Var(name)
+	}
+
+	return lambda
+}
+
+// build creates the lambda with the body returned by the Go function, and releases its depth.
+func (lambda *fnLambda) build(params interface{}, body func() Expr) Expr {
+	defer func() {
+		fnDepths.Lock()
+		fnDepths.active[lambda.depth]--
+		fnDepths.Unlock()
+	}()
+
+	return Lambda(params, body())
+}
@@ -0,0 +1,96 @@
+package faunadb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func lambdaJSON(t *testing.T, expr Expr) (lambda map[string]interface{}) {
+	bytes, err := json.Marshal(expr)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(bytes, &lambda))
+	return
+}
+
+func TestFn(t *testing.T) {
+	lambda := lambdaJSON(t, Fn(func(ref Expr) Expr { return Get(ref) }))
+	param := lambda["lambda"].(string)
+
+	require.Equal(t, map[string]interface{}{"get": map[string]interface{}{"var": param}}, lambda["expr"])
+}
+
+func TestFn2Destructures(t *testing.T) {
+	lambda := lambdaJSON(t, Fn2(func(ts, ref Expr) Expr { return Arr{ref, ts} }))
+	params := lambda["lambda"].([]interface{})
+
+	require.Len(t, params, 2)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"var": params[1]},
+		map[string]interface{}{"var": params[0]},
+	}, lambda["expr"])
+}
+
+func TestFnParamsAreUnique(t *testing.T) {
+	var inner Expr
+
+	outer := lambdaJSON(t, Fn3(func(x, y, z Expr) Expr {
+		inner = Fn(func(x Expr) Expr { return x })
+		return inner
+	}))
+
+	outerParams := outer["lambda"].([]interface{})
+	innerParam := outer["expr"].(map[string]interface{})["lambda"]
+
+	require.Len(t, outerParams, 3)
+	require.NotContains(t, outerParams, innerParam)
+	require.NotEqual(t, outerParams[0], outerParams[1])
+	require.NotEqual(t, outerParams[1], outerParams[2])
+}
+
+func TestFnN(t *testing.T) {
+	lambda := lambdaJSON(t, FnN(4, func(args ...Expr) Expr {
+		require.Len(t, args, 4)
+		return Add(args[0], args[3])
+	}))
+	params := lambda["lambda"].([]interface{})
+
+	require.Len(t, params, 4)
+	require.Equal(t, map[string]interface{}{"add": []interface{}{
+		map[string]interface{}{"var": params[0]},
+		map[string]interface{}{"var": params[3]},
+	}}, lambda["expr"])
+}
+
+func TestFnParamsAreNamedAfterNesting(t *testing.T) {
+	lambda := lambdaJSON(t, Fn(func(x Expr) Expr {
+		return Arr{Fn(func(y Expr) Expr { return y }), Fn(func(z Expr) Expr { return x })}
+	}))
+
+	require.Equal(t, "__fn0_0", lambda["lambda"])
+	require.Equal(t, "__fn1_0", lambda["expr"].([]interface{})[0].(map[string]interface{})["lambda"])
+	require.Equal(t, "__fn1_0", lambda["expr"].([]interface{})[1].(map[string]interface{})["lambda"])
+}
+
+func TestFnIsDeterministic(t *testing.T) {
+	build := func() Expr {
+		return Map(Arr{1, 2}, Fn(func(x Expr) Expr {
+			return Map(Arr{3}, Fn2(func(y, z Expr) Expr { return Add(x, y, z) }))
+		}))
+	}
+
+	first, err := json.Marshal(build())
+	require.NoError(t, err)
+
+	second, err := json.Marshal(build())
+	require.NoError(t, err)
+
+	require.Equal(t, string(first), string(second))
+	require.Equal(t,
+		`{"collection":[1,2],"map":{"expr":{"collection":[3],"map":{"expr":{"add":[{"var":"__fn0_0"},{"var":"__fn1_0"},{"var":"__fn1_1"}]},`+
+			`"lambda":["__fn1_0","__fn1_1"]}},"lambda":"__fn0_0"}}`,
+		string(first))
+	require.Equal(t, `Map([1, 2], Lambda("__fn0_0", Map([3], Lambda(["__fn1_0", "__fn1_1"], Add(Var("__fn0_0"), Var("__fn1_0"), Var("__fn1_1"))))))`,
+		FQL(build()))
+}
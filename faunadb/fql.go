@@ -0,0 +1,531 @@
+package faunadb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query language functions, mapped to their name followed by their positional parameters. Optional
+// parameters are rendered positionally if listed in fqlOptionalParams, or as a trailing object.
+var fqlFunctions = map[string][]string{
+	"@ref":              {"Ref", "@ref"},
+	"abort":             {"Abort", "abort"},
+	"abs":               {"Abs", "abs"},
+	"acos":              {"Acos", "acos"},
+	"add":               {"Add", "add"},
+	"all":               {"All", "all"},
+	"and":               {"And", "and"},
+	"any":               {"Any", "any"},
+	"append":            {"Append", "append", "collection"},
+	"asin":              {"Asin", "asin"},
+	"at":                {"At", "at", "expr"},
+	"atan":              {"Atan", "atan"},
+	"bitand":            {"BitAnd", "bitand"},
+	"bitnot":            {"BitNot", "bitnot"},
+	"bitor":             {"BitOr", "bitor"},
+	"bitxor":            {"BitXor", "bitxor"},
+	"call":              {"Call", "call", "arguments"},
+	"casefold":          {"Casefold", "casefold"},
+	"ceil":              {"Ceil", "ceil"},
+	"class":             {"Class", "class", "scope"},
+	"classes":           {"Classes", "classes"},
+	"collection":        {"Collection", "collection", "scope"},
+	"collections":       {"Collections", "collections"},
+	"concat":            {"Concat", "concat"},
+	"contains":          {"Contains", "contains", "in"},
+	"containsstr":       {"ContainsStr", "containsstr", "search"},
+	"containsstrregex":  {"ContainsStrRegex", "containsstrregex", "pattern"},
+	"cos":               {"Cos", "cos"},
+	"cosh":              {"Cosh", "cosh"},
+	"count":             {"Count", "count"},
+	"create":            {"Create", "create", "params"},
+	"create_class":      {"CreateClass", "create_class"},
+	"create_collection": {"CreateCollection", "create_collection"},
+	"create_database":   {"CreateDatabase", "create_database"},
+	"create_function":   {"CreateFunction", "create_function"},
+	"create_index":      {"CreateIndex", "create_index"},
+	"create_key":        {"CreateKey", "create_key"},
+	"create_role":       {"CreateRole", "create_role"},
+	"credentials":       {"Credentials", "credentials"},
+	"database":          {"Database", "database", "scope"},
+	"databases":         {"Databases", "databases"},
+	"date":              {"Date", "date"},
+	"day_of_month":      {"DayOfMonth", "day_of_month"},
+	"day_of_week":       {"DayOfWeek", "day_of_week"},
+	"day_of_year":       {"DayOfYear", "day_of_year"},
+	"degrees":           {"Degrees", "degrees"},
+	"delete":            {"Delete", "delete"},
+	"difference":        {"Difference", "difference"},
+	"distinct":          {"Distinct", "distinct"},
+	"divide":            {"Divide", "divide"},
+	"do":                {"Do", "do"},
+	"documents":         {"Documents", "documents"},
+	"drop":              {"Drop", "drop", "collection"},
+	"endswith":          {"EndsWith", "endswith", "search"},
+	"epoch":             {"Epoch", "epoch", "unit"},
+	"equals":            {"Equals", "equals"},
+	"events":            {"Events", "events"},
+	"exists":            {"Exists", "exists"},
+	"exp":               {"Exp", "exp"},
+	"filter":            {"Filter", "collection", "filter"},
+	"findstr":           {"FindStr", "findstr", "find"},
+	"findstrregex":      {"FindStrRegex", "findstrregex", "pattern"},
+	"floor":             {"Floor", "floor"},
+	"foreach":           {"Foreach", "collection", "foreach"},
+	"format":            {"Format", "format", "values"},
+	"function":          {"Function", "function", "scope"},
+	"functions":         {"Functions", "functions"},
+	"get":               {"Get", "get"},
+	"gt":                {"GT", "gt"},
+	"gte":               {"GTE", "gte"},
+	"has_identity":      {"HasIdentity", "has_identity"},
+	"hour":              {"Hour", "hour"},
+	"hypot":             {"Hypot", "hypot", "b"},
+	"identify":          {"Identify", "identify", "password"},
+	"identity":          {"Identity", "identity"},
+	"if":                {"If", "if", "then", "else"},
+	"index":             {"Index", "index", "scope"},
+	"indexes":           {"Indexes", "indexes"},
+	"insert":            {"Insert", "insert", "ts", "action", "params"},
+	"intersection":      {"Intersection", "intersection"},
+	"is_array":          {"IsArray", "is_array"},
+	"is_boolean":        {"IsBoolean", "is_boolean"},
+	"is_bytes":          {"IsBytes", "is_bytes"},
+	"is_collection":     {"IsCollection", "is_collection"},
+	"is_credentials":    {"IsCredentials", "is_credentials"},
+	"is_database":       {"IsDatabase", "is_database"},
+	"is_date":           {"IsDate", "is_date"},
+	"is_doc":            {"IsDoc", "is_doc"},
+	"is_double":         {"IsDouble", "is_double"},
+	"is_empty":          {"IsEmpty", "is_empty"},
+	"is_function":       {"IsFunction", "is_function"},
+	"is_index":          {"IsIndex", "is_index"},
+	"is_integer":        {"IsInteger", "is_integer"},
+	"is_key":            {"IsKey", "is_key"},
+	"is_lambda":         {"IsLambda", "is_lambda"},
+	"is_nonempty":       {"IsNonEmpty", "is_nonempty"},
+	"is_null":           {"IsNull", "is_null"},
+	"is_number":         {"IsNumber", "is_number"},
+	"is_object":         {"IsObject", "is_object"},
+	"is_ref":            {"IsRef", "is_ref"},
+	"is_role":           {"IsRole", "is_role"},
+	"is_set":            {"IsSet", "is_set"},
+	"is_string":         {"IsString", "is_string"},
+	"is_timestamp":      {"IsTimestamp", "is_timestamp"},
+	"is_token":          {"IsToken", "is_token"},
+	"join":              {"Join", "join", "with"},
+	"key_from_secret":   {"KeyFromSecret", "key_from_secret"},
+	"keys":              {"Keys", "keys"},
+	"lambda":            {"Lambda", "lambda", "expr"},
+	"length":            {"Length", "length"},
+	"let":               {"Let", "let", "in"},
+	"ln":                {"Ln", "ln"},
+	"log":               {"Log", "log"},
+	"login":             {"Login", "login", "params"},
+	"logout":            {"Logout", "logout"},
+	"lowercase":         {"LowerCase", "lowercase"},
+	"lt":                {"LT", "lt"},
+	"lte":               {"LTE", "lte"},
+	"ltrim":             {"LTrim", "ltrim"},
+	"map":               {"Map", "collection", "map"},
+	"match":             {"Match", "match", "terms"},
+	"max":               {"Max", "max"},
+	"mean":              {"Mean", "mean"},
+	"merge":             {"Merge", "merge", "with"},
+	"min":               {"Min", "min"},
+	"minute":            {"Minute", "minute"},
+	"modulo":            {"Modulo", "modulo"},
+	"month":             {"Month", "month"},
+	"move_database":     {"MoveDatabase", "move_database", "to"},
+	"multiply":          {"Multiply", "multiply"},
+	"new_id":            {"NewId", "new_id"},
+	"not":               {"Not", "not"},
+	"now":               {"Now", "now"},
+	"or":                {"Or", "or"},
+	"paginate":          {"Paginate", "paginate"},
+	"pow":               {"Pow", "pow", "exp"},
+	"prepend":           {"Prepend", "prepend", "collection"},
+	"query":             {"Query", "query"},
+	"radians":           {"Radians", "radians"},
+	"range":             {"Range", "range", "from", "to"},
+	"reduce":            {"Reduce", "reduce", "initial", "collection"},
+	"ref":               {"Ref", "ref", "id"},
+	"regexescape":       {"RegexEscape", "regexescape"},
+	"remove":            {"Remove", "remove", "ts", "action"},
+	"repeat":            {"Repeat", "repeat", "number"},
+	"replace":           {"Replace", "replace", "params"},
+	"replacestr":        {"ReplaceStr", "replacestr", "find", "replace"},
+	"replacestrregex":   {"ReplaceStrRegex", "replacestrregex", "pattern", "replace"},
+	"role":              {"Role", "role", "scope"},
+	"roles":             {"Roles", "roles"},
+	"round":             {"Round", "round"},
+	"rtrim":             {"RTrim", "rtrim"},
+	"second":            {"Second", "second"},
+	"select":            {"Select", "select", "from"},
+	"select_all":        {"SelectAll", "select_all", "from"},
+	"sign":              {"Sign", "sign"},
+	"sin":               {"Sin", "sin"},
+	"singleton":         {"Singleton", "singleton"},
+	"sinh":              {"Sinh", "sinh"},
+	"space":             {"Space", "space"},
+	"sqrt":              {"Sqrt", "sqrt"},
+	"startswith":        {"StartsWith", "startswith", "search"},
+	"substring":         {"SubString", "substring", "start"},
+	"subtract":          {"Subtract", "subtract"},
+	"sum":               {"Sum", "sum"},
+	"take":              {"Take", "take", "collection"},
+	"tan":               {"Tan", "tan"},
+	"tanh":              {"Tanh", "tanh"},
+	"time":              {"Time", "time"},
+	"time_add":          {"TimeAdd", "time_add", "offset", "unit"},
+	"time_diff":         {"TimeDiff", "time_diff", "other", "unit"},
+	"time_subtract":     {"TimeSubtract", "time_subtract", "offset", "unit"},
+	"titlecase":         {"TitleCase", "titlecase"},
+	"to_date":           {"ToDate", "to_date"},
+	"to_micros":         {"ToMicros", "to_micros"},
+	"to_millis":         {"ToMillis", "to_millis"},
+	"to_number":         {"ToNumber", "to_number"},
+	"to_seconds":        {"ToSeconds", "to_seconds"},
+	"to_string":         {"ToString", "to_string"},
+	"to_time":           {"ToTime", "to_time"},
+	"tokens":            {"Tokens", "tokens"},
+	"trim":              {"Trim", "trim"},
+	"trunc":             {"Trunc", "trunc"},
+	"union":             {"Union", "union"},
+	"update":            {"Update", "update", "params"},
+	"uppercase":         {"UpperCase", "uppercase"},
+	"var":               {"Var", "var"},
+	"year":              {"Year", "year"},
+}
+
+// Functions whose positional parameters are omitted when null.
+var fqlOptionalArgs = map[string]bool{
+	"classes": true, "collections": true, "credentials": true, "databases": true, "functions": true,
+	"has_identity": true, "identity": true, "indexes": true, "keys": true, "new_id": true, "now": true,
+	"roles": true, "tokens": true,
+}
+
+// Functions returning refs to schema documents, by the id of the native collection they belong to.
+var fqlSchemaFunctions = map[string]string{
+	"classes": "Class", "collections": "Collection", "databases": "Database", "functions": "Function",
+	"indexes": "Index", "roles": "Role",
+}
+
+var fqlIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+/*
+FQL returns the representation of an expression in the FaunaDB query language, as used by the FaunaDB
+shell and documentation. For example:
+
+	FQL(Get(Ref(Collection("users"), "42"))) // Get(Ref(Collection("users"), "42"))
+
+Positional parameters are rendered in the order they are documented. Optional parameters are rendered
+positionally for the functions accepting them that way, as in Get(ref, ts), and as a trailing object
+otherwise, as in Paginate(set, { size: 10 }). Values are rendered with their query
+language constructors.
+*/
+func FQL(expr Expr) string {
+	var buffer bytes.Buffer
+	writeFQL(&buffer, expr)
+	return buffer.String()
+}
+
+func writeFQL(buffer *bytes.Buffer, expr Expr) {
+	switch e := normalizeExpr(expr).(type) {
+	case nil, NullV:
+		buffer.WriteString("null")
+
+	case unescapedArr:
+		buffer.WriteByte('[')
+
+		for i, elem := range e {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+
+			writeFQL(buffer, elem)
+		}
+
+		buffer.WriteByte(']')
+
+	case unescapedObj:
+		if isObjectLiteral(e) {
+			if fields, ok := e["object"].(unescapedObj); ok {
+				writeFQLObject(buffer, fields)
+				return
+			}
+		}
+
+		if call, ok := asCall(e); ok {
+			writeFQLCall(buffer, call)
+		} else {
+			writeFQLObject(buffer, e)
+		}
+
+	case ArrayV:
+		arr := make(unescapedArr, len(e))
+
+		for i, elem := range e {
+			arr[i] = elem
+		}
+
+		writeFQL(buffer, arr)
+
+	case ObjectV:
+		obj := make(unescapedObj, len(e))
+
+		for key, elem := range e {
+			obj[key] = elem
+		}
+
+		writeFQLObject(buffer, obj)
+
+	case StringV:
+		buffer.WriteString(strconv.Quote(string(e)))
+
+	case LongV:
+		buffer.WriteString(strconv.FormatInt(int64(e), 10))
+
+	case DoubleV:
+		str := strconv.FormatFloat(float64(e), 'g', -1, 64)
+
+		if !strings.ContainsAny(str, ".eEIN") {
+			str += ".0"
+		}
+
+		buffer.WriteString(str)
+
+	case BooleanV:
+		buffer.WriteString(strconv.FormatBool(bool(e)))
+
+	case RefV:
+		writeFQLRef(buffer, e)
+
+	case TimeV:
+		fmt.Fprintf(buffer, "Time(%q)", time.Time(e).UTC().Format("2006-01-02T15:04:05.999999999Z"))
+
+	case DateV:
+		fmt.Fprintf(buffer, "Date(%q)", time.Time(e).Format("2006-01-02"))
+
+	case BytesV:
+		fmt.Fprintf(buffer, "Bytes(%q)", base64.StdEncoding.EncodeToString(e))
+
+	case SetRefV:
+		set := make(unescapedObj, len(e.Parameters))
+
+		for key, param := range e.Parameters {
+			set[key] = param
+		}
+
+		writeFQL(buffer, set)
+
+	case QueryV:
+		buffer.WriteString("Query(")
+
+		if lambda, err := exprFromJSON(e.lambda); err == nil {
+			writeFQL(buffer, lambda)
+		} else {
+			buffer.Write(e.lambda)
+		}
+
+		buffer.WriteByte(')')
+
+	case invalidExpr:
+		fmt.Fprintf(buffer, "<invalid expression: %s>", e.err)
+
+	default:
+		fmt.Fprintf(buffer, "%v", e)
+	}
+}
+
+func writeFQLCall(buffer *bytes.Buffer, call exprCall) {
+	spec, ok := fqlFunctions[call.name]
+	if !ok {
+		spec = []string{fqlFunctionName(call.name), call.name}
+	}
+
+	var args unescapedArr
+	used := make(map[string]bool, len(call.args))
+
+	for _, param := range spec[1:] {
+		if arg, ok := call.args[param]; ok {
+			args = append(args, arg)
+			used[param] = true
+		}
+	}
+
+	if len(args) == len(spec)-1 {
+		for _, param := range fqlOptionalParams[call.name] {
+			arg, ok := call.args[param]
+			if !ok {
+				break
+			}
+
+			args = append(args, arg)
+			used[param] = true
+		}
+	}
+
+	if fqlOptionalArgs[call.name] {
+		for len(args) > 0 {
+			if _, isNull := args[len(args)-1].(NullV); !isNull {
+				break
+			}
+
+			args = args[:len(args)-1]
+		}
+	}
+
+	if variadic, ok := fqlVariadicFunctions[call.name]; ok && spec[len(spec)-1] == variadic && len(args) == len(spec)-1 {
+		if rest, isArr := normalizeExpr(args[len(args)-1]).(unescapedArr); isArr && (len(rest) > 1 || call.name == "do") {
+			args = append(args[:len(args)-1], rest...)
+		}
+	}
+
+	options := unescapedObj{}
+
+	for key, arg := range call.args {
+		if !used[key] {
+			options[key] = arg
+		}
+	}
+
+	buffer.WriteString(spec[0])
+	buffer.WriteByte('(')
+
+	for i, arg := range args {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+
+		if call.name == "let" && i == 0 {
+			writeFQLBindings(buffer, arg)
+		} else {
+			writeFQL(buffer, arg)
+		}
+	}
+
+	if len(options) > 0 {
+		if len(args) > 0 {
+			buffer.WriteString(", ")
+		}
+
+		writeFQLObject(buffer, options)
+	}
+
+	buffer.WriteByte(')')
+}
+
+// writeFQLBindings writes the bindings of a Let call, which are objects rather than function calls.
+func writeFQLBindings(buffer *bytes.Buffer, bindings Expr) {
+	switch b := bindings.(type) {
+	case unescapedObj:
+		writeFQLObject(buffer, b)
+
+	case unescapedArr:
+		buffer.WriteByte('[')
+
+		for i, binding := range b {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+
+			writeFQLBindings(buffer, binding)
+		}
+
+		buffer.WriteByte(']')
+
+	default:
+		writeFQL(buffer, bindings)
+	}
+}
+
+func writeFQLObject(buffer *bytes.Buffer, fields unescapedObj) {
+	if len(fields) == 0 {
+		buffer.WriteString("{}")
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+
+	for key := range fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	buffer.WriteString("{ ")
+
+	for i, key := range keys {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+
+		if fqlIdentifier.MatchString(key) {
+			buffer.WriteString(key)
+		} else {
+			buffer.WriteString(strconv.Quote(key))
+		}
+
+		buffer.WriteString(": ")
+		writeFQL(buffer, fields[key])
+	}
+
+	buffer.WriteString(" }")
+}
+
+func writeFQLRef(buffer *bytes.Buffer, ref RefV) {
+	collection := ref.Collection
+	if collection == nil {
+		collection = ref.Class
+	}
+
+	switch {
+	case collection == nil:
+		if spec, ok := fqlFunctions[ref.ID]; ok && fqlOptionalArgs[ref.ID] {
+			buffer.WriteString(spec[0])
+			buffer.WriteByte('(')
+
+			if ref.Database != nil {
+				writeFQLRef(buffer, *ref.Database)
+			}
+
+			buffer.WriteByte(')')
+		} else {
+			fmt.Fprintf(buffer, "Ref(%q)", ref.ID)
+		}
+
+	case collection.Collection == nil && collection.Class == nil && fqlSchemaFunctions[collection.ID] != "":
+		fmt.Fprintf(buffer, "%s(%q", fqlSchemaFunctions[collection.ID], ref.ID)
+
+		if ref.Database != nil {
+			buffer.WriteString(", ")
+			writeFQLRef(buffer, *ref.Database)
+		}
+
+		buffer.WriteByte(')')
+
+	default:
+		buffer.WriteString("Ref(")
+		writeFQLRef(buffer, *collection)
+		fmt.Fprintf(buffer, ", %q)", ref.ID)
+	}
+}
+
+// fqlFunctionName returns the query language name of a function missing from fqlFunctions.
+func fqlFunctionName(name string) string {
+	words := strings.Split(name, "_")
+
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+
+	return strings.Join(words, "")
+}
@@ -0,0 +1,49 @@
+package faunadb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFQL(t *testing.T) {
+	users := RefV{"users", NativeCollections(), nil, nil}
+
+	tests := []struct {
+		expr Expr
+		fql  string
+	}{
+		{Get(Ref(Collection("users"), "42")), `Get(Ref(Collection("users"), "42"))`},
+		{Map(Arr{1, 2.5}, Lambda("x", Var("x"))), `Map([1, 2.5], Lambda("x", Var("x")))`},
+		{If(true, "yes", nil), `If(true, "yes", null)`},
+		{Reduce(Lambda(Arr{"acc", "x"}, Var("acc")), 0, Arr{}), `Reduce(Lambda(["acc", "x"], Var("acc")), 0, [])`},
+		{Paginate(Match(Index("by_name")), Size(10)), `Paginate(Match(Index("by_name")), { size: 10 })`},
+		{Create(Collection("users"), Obj{"data": Obj{"first-name": "Bob"}}), `Create(Collection("users"), { data: { "first-name": "Bob" } })`},
+		{Let().Bind("x", 1).Bind("y", Var("x")).In(Var("y")), `Let([{ x: 1 }, { y: Var("x") }], Var("y"))`},
+		{Collections(), `Collections()`},
+		{ScopedCollections(Database("db")), `Collections(Database("db"))`},
+		{Now(), `Now()`},
+		{RefV{"42", &users, nil, nil}, `Ref(Collection("users"), "42")`},
+		{users, `Collection("users")`},
+		{RefV{"users", NativeCollections(), nil, &RefV{"db", NativeDatabases(), nil, nil}}, `Collection("users", Database("db"))`},
+		{*NativeIndexes(), `Indexes()`},
+		{ObjectV{"n": DoubleV(1)}, `{ n: 1.0 }`},
+		{TimeV(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)), `Time("2019-01-01T00:00:00Z")`},
+		{TimeV(time.Date(2019, 1, 1, 2, 30, 0, 0, time.FixedZone("", 2*60*60))), `Time("2019-01-01T00:30:00Z")`},
+		{DateV(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)), `Date("2019-01-01")`},
+		{BytesV{1, 2}, `Bytes("AQI=")`},
+		{SetRefV{map[string]Value{"match": RefV{"by_name", NativeIndexes(), nil, nil}, "terms": StringV("Bob")}}, `Match(Index("by_name"), "Bob")`},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.fql, FQL(test.expr))
+	}
+}
+
+func TestFQLOfQueryValue(t *testing.T) {
+	var value Value
+
+	require.NoError(t, UnmarshalJSON([]byte(`{"@query":{"lambda":"x","expr":{"get":{"var":"x"}}}}`), &value))
+	require.Equal(t, `Query(Lambda("x", Get(Var("x"))))`, FQL(value))
+}
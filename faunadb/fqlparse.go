@@ -0,0 +1,514 @@
+package faunadb
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Functions taking a variable number of arguments, mapped to the parameter receiving them.
+var fqlVariadicFunctions = map[string]string{
+	"add": "add", "and": "and", "bitand": "bitand", "bitor": "bitor", "bitxor": "bitxor", "call": "arguments",
+	"difference": "difference", "divide": "divide", "do": "do", "equals": "equals", "format": "values",
+	"gt": "gt", "gte": "gte", "intersection": "intersection", "lt": "lt", "lte": "lte", "max": "max",
+	"min": "min", "modulo": "modulo", "multiply": "multiply", "or": "or", "subtract": "subtract",
+	"union": "union",
+}
+
+// Optional parameters that can also be given as extra positional arguments.
+var fqlOptionalParams = map[string][]string{
+	"casefold": {"normalizer"}, "concat": {"separator"}, "exists": {"ts"}, "findstr": {"start"},
+	"findstrregex": {"start"}, "get": {"ts"}, "merge": {"lambda"}, "replacestrregex": {"first"},
+	"round": {"precision"}, "select": {"default"}, "substring": {"length"}, "trunc": {"precision"},
+}
+
+// Functions whose last positional parameter is omitted when not given, mapped to the parameter.
+var fqlOmittableParams = map[string]string{
+	"class": "scope", "collection": "scope", "database": "scope", "function": "scope", "index": "scope",
+	"match": "terms", "role": "scope",
+}
+
+// Query language function names mapped to their function.
+var fqlCallNames = func() map[string]string {
+	names := make(map[string]string, len(fqlFunctions))
+
+	for fn, spec := range fqlFunctions {
+		if fn != "@ref" {
+			names[spec[0]] = fn
+		}
+	}
+
+	return names
+}()
+
+// FQLSyntaxError is returned by ParseFQL for invalid input.
+type FQLSyntaxError struct {
+	Line       int
+	Column     int
+	Message    string
+	Incomplete bool // True if the input ended before the expression was complete
+}
+
+func (err FQLSyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", err.Line, err.Column, err.Message)
+}
+
+/*
+ParseFQL parses an expression written in the FaunaDB query language, as rendered by FQL. For example:
+
+	expr, err := ParseFQL(`Map(Paginate(Documents(Collection("users"))), Lambda("ref", Get(Var("ref"))))`)
+
+Functions taking a variable number of arguments, such as Add or Do, accept them either as separate
+arguments or as a single array. Optional parameters are given as a trailing object, as in
+Paginate(set, { size: 10 }), or positionally for the functions documented to accept them, as in
+Get(ref, ts) or Select(path, from, default). Line comments starting with // are ignored.
+
+Errors are of type FQLSyntaxError. Its Incomplete field tells if more input could make the
+expression valid, which is useful to read multiline input.
+*/
+func ParseFQL(src string) (Expr, error) {
+	p := &fqlParser{src: src}
+	p.next()
+
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != fqlEOF {
+		return nil, p.errorf("unexpected %s after expression", p.tok)
+	}
+
+	return expr, nil
+}
+
+type fqlTokenKind int
+
+const (
+	fqlEOF fqlTokenKind = iota
+	fqlIdent
+	fqlString
+	fqlNumber
+	fqlPunct
+	fqlInvalid
+)
+
+type fqlToken struct {
+	kind fqlTokenKind
+	text string
+	pos  int
+}
+
+func (tok fqlToken) String() string {
+	switch tok.kind {
+	case fqlEOF:
+		return "end of input"
+	case fqlString:
+		return "string " + tok.text
+	default:
+		return strconv.Quote(tok.text)
+	}
+}
+
+type fqlParser struct {
+	src string
+	pos int
+	tok fqlToken
+}
+
+func (p *fqlParser) errorAt(pos int, incomplete bool, format string, args ...interface{}) error {
+	line := 1 + strings.Count(p.src[:pos], "\n")
+	column := 1 + utf8.RuneCountInString(p.src[strings.LastIndex(p.src[:pos], "\n")+1:pos])
+	return FQLSyntaxError{line, column, fmt.Sprintf(format, args...), incomplete}
+}
+
+func (p *fqlParser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.tok.pos, p.tok.kind == fqlEOF, format, args...)
+}
+
+func (p *fqlParser) next() {
+	p.skipSpaceAndComments()
+	start := p.pos
+
+	if p.pos >= len(p.src) {
+		p.tok = fqlToken{fqlEOF, "", start}
+		return
+	}
+
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+
+	switch {
+	case r == '"' || r == '\'':
+		p.pos++
+
+		for p.pos < len(p.src) && p.src[p.pos] != byte(r) {
+			if p.src[p.pos] == '\\' {
+				p.pos++
+			}
+
+			p.pos++
+		}
+
+		if p.pos >= len(p.src) {
+			p.pos = len(p.src)
+			p.tok = fqlToken{fqlInvalid, p.src[start:], start}
+			return
+		}
+
+		p.pos++
+		p.tok = fqlToken{fqlString, p.src[start:p.pos], start}
+
+	case unicode.IsDigit(r) || ((r == '-' || r == '.') && p.pos+1 < len(p.src) && unicode.IsDigit(rune(p.src[p.pos+1]))):
+		p.pos++
+
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			if (p.src[p.pos] == '+' || p.src[p.pos] == '-') && !strings.ContainsAny(p.src[p.pos-1:p.pos], "eE") {
+				break
+			}
+
+			p.pos++
+		}
+
+		p.tok = fqlToken{fqlNumber, p.src[start:p.pos], start}
+
+	case unicode.IsLetter(r) || r == '_' || r == '$':
+		for p.pos < len(p.src) {
+			r, size = utf8.DecodeRuneInString(p.src[p.pos:])
+
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '$' {
+				break
+			}
+
+			p.pos += size
+		}
+
+		p.tok = fqlToken{fqlIdent, p.src[start:p.pos], start}
+
+	case strings.ContainsRune("()[]{},:", r):
+		p.pos += size
+		p.tok = fqlToken{fqlPunct, string(r), start}
+
+	default:
+		p.pos += size
+		p.tok = fqlToken{fqlInvalid, string(r), start}
+	}
+}
+
+func (p *fqlParser) skipSpaceAndComments() {
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+
+		switch {
+		case unicode.IsSpace(r):
+			p.pos += size
+
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			if end := strings.IndexByte(p.src[p.pos:], '\n'); end >= 0 {
+				p.pos += end
+			} else {
+				p.pos = len(p.src)
+			}
+
+		default:
+			return
+		}
+	}
+}
+
+func (p *fqlParser) expect(punct string) error {
+	if p.tok.kind != fqlPunct || p.tok.text != punct {
+		return p.errorf("expected %q, found %s", punct, p.tok)
+	}
+
+	p.next()
+	return nil
+}
+
+func (p *fqlParser) isPunct(punct string) bool {
+	return p.tok.kind == fqlPunct && p.tok.text == punct
+}
+
+func (p *fqlParser) parseExpr() (Expr, error) {
+	tok := p.tok
+
+	switch tok.kind {
+	case fqlString:
+		p.next()
+		return p.parseString(tok)
+
+	case fqlNumber:
+		p.next()
+
+		if num, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return LongV(num), nil
+		}
+
+		if num, err := strconv.ParseFloat(tok.text, 64); err == nil {
+			return DoubleV(num), nil
+		}
+
+		return nil, p.errorAt(tok.pos, false, "invalid number %s", tok.text)
+
+	case fqlIdent:
+		p.next()
+
+		switch tok.text {
+		case "true", "false":
+			return BooleanV(tok.text == "true"), nil
+		case "null":
+			return NullV{}, nil
+		}
+
+		if !p.isPunct("(") {
+			return nil, p.errorAt(tok.pos, false, "unexpected identifier %s", tok.text)
+		}
+
+		args, err := p.parseList("(", ")")
+		if err != nil {
+			return nil, err
+		}
+
+		return p.buildCall(tok, args)
+
+	case fqlPunct:
+		switch tok.text {
+		case "[":
+			elems, err := p.parseList("[", "]")
+			return unescapedArr(elems), err
+		case "{":
+			fields, err := p.parseObject()
+			return unescapedObj{"object": fields}, err
+		}
+
+	case fqlInvalid:
+		if strings.HasPrefix(tok.text, `"`) || strings.HasPrefix(tok.text, "'") {
+			return nil, p.errorAt(tok.pos, true, "unterminated string")
+		}
+	}
+
+	return nil, p.errorf("unexpected %s", tok)
+}
+
+func (p *fqlParser) parseString(tok fqlToken) (Expr, error) {
+	text := tok.text
+
+	if strings.HasPrefix(text, "'") {
+		inner := strings.Replace(text[1:len(text)-1], `\'`, `'`, -1)
+		text = `"` + strings.Replace(inner, `"`, `\"`, -1) + `"`
+	}
+
+	str, err := strconv.Unquote(text)
+	if err != nil {
+		return nil, p.errorAt(tok.pos, false, "invalid string %s", tok.text)
+	}
+
+	return StringV(str), nil
+}
+
+func (p *fqlParser) parseList(open, close string) (elems []Expr, err error) {
+	if err = p.expect(open); err != nil {
+		return
+	}
+
+	for !p.isPunct(close) {
+		var elem Expr
+
+		if elem, err = p.parseExpr(); err != nil {
+			return
+		}
+
+		elems = append(elems, elem)
+
+		if !p.isPunct(close) {
+			if err = p.expect(","); err != nil {
+				return
+			}
+		}
+	}
+
+	p.next()
+	return
+}
+
+func (p *fqlParser) parseObject() (fields unescapedObj, err error) {
+	fields = unescapedObj{}
+
+	if err = p.expect("{"); err != nil {
+		return
+	}
+
+	for !p.isPunct("}") {
+		var key string
+
+		switch p.tok.kind {
+		case fqlIdent:
+			key = p.tok.text
+		case fqlString:
+			var str Expr
+
+			if str, err = p.parseString(p.tok); err != nil {
+				return
+			}
+
+			key = string(str.(StringV))
+		default:
+			err = p.errorf("expected object key, found %s", p.tok)
+			return
+		}
+
+		p.next()
+
+		if err = p.expect(":"); err != nil {
+			return
+		}
+
+		if fields[key], err = p.parseExpr(); err != nil {
+			return
+		}
+
+		if !p.isPunct("}") {
+			if err = p.expect(","); err != nil {
+				return
+			}
+		}
+	}
+
+	p.next()
+	return
+}
+
+func (p *fqlParser) buildCall(tok fqlToken, args []Expr) (Expr, error) {
+	switch tok.text {
+	case "Ref":
+		switch len(args) {
+		case 1:
+			return unescapedObj{"@ref": args[0]}, nil
+		case 2:
+			return unescapedObj{"ref": args[0], "id": args[1]}, nil
+		}
+
+	case "Let":
+		if len(args) == 2 {
+			return unescapedObj{"let": letBindingsOf(args[0]), "in": args[1]}, nil
+		}
+
+	case "Bytes":
+		if str, ok := argOf(args).(StringV); ok {
+			if bytes, err := base64.StdEncoding.DecodeString(string(str)); err == nil {
+				return BytesV(bytes), nil
+			}
+		}
+
+		return nil, p.errorAt(tok.pos, false, "Bytes expects a base64 encoded string")
+
+	default:
+		fn, ok := fqlCallNames[tok.text]
+		if !ok {
+			return nil, p.errorAt(tok.pos, false, "unknown function %s", tok.text)
+		}
+
+		call, err := fqlCall(fn, args)
+		if err != nil {
+			return nil, p.errorAt(tok.pos, false, "%s %s", tok.text, err)
+		}
+
+		return call, nil
+	}
+
+	return nil, p.errorAt(tok.pos, false, "%s expects 2 arguments", tok.text)
+}
+
+func argOf(args []Expr) Expr {
+	if len(args) == 1 {
+		return args[0]
+	}
+
+	return nil
+}
+
+// letBindingsOf converts object literals given to Let into bindings.
+func letBindingsOf(expr Expr) Expr {
+	switch e := expr.(type) {
+	case unescapedObj:
+		if isObjectLiteral(e) {
+			return e["object"]
+		}
+
+	case unescapedArr:
+		bindings := make(unescapedArr, len(e))
+
+		for i, binding := range e {
+			bindings[i] = letBindingsOf(binding)
+		}
+
+		return bindings
+	}
+
+	return expr
+}
+
+func fqlCall(fn string, args []Expr) (unescapedObj, error) {
+	params := fqlFunctions[fn][1:]
+	call := unescapedObj{}
+
+	if variadic, ok := fqlVariadicFunctions[fn]; ok && params[len(params)-1] == variadic {
+		fixed := len(params) - 1
+
+		if len(args) < fixed {
+			return nil, fmt.Errorf("expects at least %d arguments", fixed)
+		}
+
+		for i := 0; i < fixed; i++ {
+			call[params[i]] = args[i]
+		}
+
+		if rest := args[fixed:]; len(rest) == 1 && fn != "do" {
+			call[variadic] = rest[0]
+		} else {
+			call[variadic] = unescapedArr(rest)
+		}
+
+		return call, nil
+	}
+
+	for i, param := range params {
+		switch {
+		case i < len(args):
+			call[param] = args[i]
+		case fqlOptionalArgs[fn]:
+			call[param] = NullV{}
+		case fqlOmittableParams[fn] == param:
+		default:
+			return nil, fmt.Errorf("expects %d arguments", len(params))
+		}
+	}
+
+	if len(args) <= len(params) {
+		return call, nil
+	}
+
+	extra := args[len(params):]
+
+	for i, arg := range extra {
+		optional := fqlOptionalParams[fn]
+
+		if i < len(optional) {
+			call[optional[i]] = arg
+			continue
+		}
+
+		options, isObj := arg.(unescapedObj)
+		if !isObj || !isObjectLiteral(options) || i != len(extra)-1 {
+			return nil, fmt.Errorf("expects %d arguments", len(params))
+		}
+
+		for key, option := range options["object"].(unescapedObj) {
+			call[key] = option
+		}
+	}
+
+	return call, nil
+}
@@ -0,0 +1,98 @@
+package faunadb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func requireSameJSON(t *testing.T, expected, actual Expr) {
+	expectedJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+
+	actualJSON, err := json.Marshal(actual)
+	require.NoError(t, err)
+
+	require.JSONEq(t, string(expectedJSON), string(actualJSON))
+}
+
+func TestParseFQLRoundTrip(t *testing.T) {
+	exprs := []Expr{
+		Get(Ref(Collection("users"), "42")),
+		Get(Ref("collections/users/42"), TS(10)),
+		Map(Paginate(Documents(Collection("users")), Size(10), After(Arr{"a"})), Lambda("ref", Get(Var("ref")))),
+		If(true, "yes", nil),
+		Let().Bind("x", 1).Bind("y", Var("x")).In(Var("y")),
+		Create(Collection("users"), Obj{"data": Obj{"first-name": "Bob", "age": 42, "score": 1.5}}),
+		Collections(),
+		ScopedCollections(Database("db")),
+		Now(),
+		Do(Add(1, 2), Abort("no")),
+		Do(Var("x")),
+		Call(Function("f"), 1, 2),
+		Format("%s %s", "a", "b"),
+		Select(Arr{"data", 0}, Var("doc"), Default(nil)),
+		Concat(Arr{"a", "b"}, Separator(", ")),
+		Reduce(Lambda(Arr{"acc", "x"}, Add(Var("acc"), Var("x"))), 0, Arr{-1, 2}),
+		TimeAdd(Time("2019-01-01T00:00:00Z"), 1, TimeUnitDay),
+		BytesV{1, 2, 3},
+		Equals("a\n\"b\"", "c"),
+	}
+
+	for _, expr := range exprs {
+		parsed, err := ParseFQL(FQL(expr))
+		require.NoError(t, err, FQL(expr))
+		requireSameJSON(t, expr, parsed)
+	}
+}
+
+func TestParseFQLArguments(t *testing.T) {
+	tests := []struct {
+		fql  string
+		expr Expr
+	}{
+		{`Add(1, 2, 3)`, Add(1, 2, 3)},
+		{`Add([1, 2, 3])`, Add(1, 2, 3)},
+		{`Equals(1)`, Equals(1)},
+		{`Get(Var("ref"), 10)`, Get(Var("ref"), TS(10))},
+		{`Select(["a"], {}, "default")`, Select(Arr{"a"}, Obj{}, Default("default"))},
+		{`Paginate(Match(Index("all")), { size: 2, "before": null })`, Paginate(Match(Index("all")), Size(2), Before(nil))},
+		{`Let({ x: 1 }, Var("x"))`, fn2("let", unescapedObj{"x": LongV(1)}, "in", Var("x"))},
+		{"Add(\n  1, // one\n  2\n)", Add(1, 2)},
+		{`Concat(['it\'s', "a"])`, Concat(Arr{"it's", "a"})},
+		{`ToString(-1.5e3)`, ToString(-1500.0)},
+		{`Call(Function("f"))`, fn2("call", Function("f"), "arguments", unescapedArr{})},
+	}
+
+	for _, test := range tests {
+		parsed, err := ParseFQL(test.fql)
+		require.NoError(t, err, test.fql)
+		requireSameJSON(t, test.expr, parsed)
+	}
+}
+
+func TestParseFQLErrors(t *testing.T) {
+	tests := []struct {
+		fql        string
+		message    string
+		incomplete bool
+	}{
+		{`Get(Ref(Collection("users"), "42")`, `line 1, column 35: expected ",", found end of input`, true},
+		{"Map(\n  [1, 2],", `line 2, column 10: unexpected end of input`, true},
+		{`Concat("abc`, `line 1, column 8: unterminated string`, true},
+		{`Foo(1)`, `line 1, column 1: unknown function Foo`, false},
+		{`Get(1, 2, 3)`, `line 1, column 1: Get expects 1 arguments`, false},
+		{`If(true, 1)`, `line 1, column 1: If expects 3 arguments`, false},
+		{`Var("x") Var("y")`, `line 1, column 10: unexpected "Var" after expression`, false},
+		{`users`, `line 1, column 1: unexpected identifier users`, false},
+		{`{ 1: 2 }`, `line 1, column 3: expected object key, found "1"`, false},
+		{`Bytes(1)`, `line 1, column 1: Bytes expects a base64 encoded string`, false},
+	}
+
+	for _, test := range tests {
+		_, err := ParseFQL(test.fql)
+		require.EqualError(t, err, test.message, test.fql)
+		require.Equal(t, test.incomplete, err.(FQLSyntaxError).Incomplete, test.fql)
+	}
+}
@@ -0,0 +1,145 @@
+package faunadb
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendDefaultHeaders(t *testing.T) {
+	headers := captureHeaders(t,
+		[]ClientConfig{DefaultHeaders(map[string]string{
+			"X-Tenant":              "acme",
+			"X-FaunaDB-API-Version": "1.0",
+			"Authorization":         "Bearer nope",
+		})},
+	)
+
+	require.Equal(t, "acme", headers.Get("X-Tenant"))
+	require.Equal(t, apiVersion, headers.Get("X-FaunaDB-API-Version"))
+	require.Equal(t, basicAuth("secret"), headers.Get("Authorization"))
+}
+
+func TestQueryHeadersOverrideDefaultHeaders(t *testing.T) {
+	headers := captureHeaders(t,
+		[]ClientConfig{DefaultHeaders(map[string]string{"X-Tenant": "acme"}), QueryTimeoutMS(100)},
+		Header("X-Tenant", "other"),
+		TimeoutMS(200),
+	)
+
+	require.Equal(t, []string{"other"}, headers["X-Tenant"])
+	require.Equal(t, []string{"200"}, headers["X-Query-Timeout"])
+}
+
+func TestDefaultHeadersCanNotDuplicateLastSeenTxn(t *testing.T) {
+	var headers http.Header
+
+	server, _ := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		w.Header().Set(headerTxnTime, "42")
+		respondWith(200, `{"resource": 42}`)(w, r)
+	})
+	defer server.Close()
+
+	client := NewFaunaClient("secret", Endpoint(server.URL), DefaultHeaders(map[string]string{"X-Last-Seen-Txn": "1"}))
+
+	_, err := client.Query(NewId())
+	require.NoError(t, err)
+	require.Empty(t, headers["X-Last-Seen-Txn"])
+
+	_, err = client.Query(NewId())
+	require.NoError(t, err)
+	require.Equal(t, []string{"42"}, headers["X-Last-Seen-Txn"])
+}
+
+func TestQueryHeadersCanNotOverrideDriverHeaders(t *testing.T) {
+	headers := captureHeaders(t, nil,
+		Header("authorization", "Bearer nope"),
+		Header("X-FaunaDB-API-Version", "1.0"),
+		Header("Content-Type", "text/plain"),
+		Header("X-Last-Seen-Txn", "1"),
+	)
+
+	require.Equal(t, []string{basicAuth("secret")}, headers["Authorization"])
+	require.Equal(t, []string{apiVersion}, headers["X-Faunadb-Api-Version"])
+	require.Equal(t, []string{"application/json; charset=utf-8"}, headers["Content-Type"])
+	require.Empty(t, headers["X-Last-Seen-Txn"])
+}
+
+func TestSendQueryTagsAndTraceParent(t *testing.T) {
+	traceParent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+	headers := captureHeaders(t, nil,
+		Tags(map[string]string{"service": "billing", "endpoint": "invoices"}),
+		Tags(map[string]string{"team": "core"}),
+		TraceParent(traceParent),
+	)
+
+	require.Equal(t, "endpoint=invoices,service=billing,team=core", headers.Get(headerQueryTags))
+	require.Equal(t, traceParent, headers.Get("traceparent"))
+}
+
+func TestInvalidQueryTagsAreRejected(t *testing.T) {
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("query with invalid tags was sent")
+	})
+	defer server.Close()
+
+	_, err := client.Query(NewId(), Tags(map[string]string{"service": "a,b=c"}))
+	require.EqualError(t, err, `faunadb: invalid query tag "service"="a,b=c": keys and values must be non-empty and only contain letters, digits and underscores`)
+
+	_, err = client.Query(NewId(), Tags(map[string]string{"": "billing"}))
+	require.IsType(t, InvalidTagError{}, err)
+
+	_, err = client.Query(NewId(), Tags(map[string]string{"service": strings.Repeat("a", 81)}))
+	require.EqualError(t, err, `faunadb: invalid query tag "service"="`+strings.Repeat("a", 81)+`": values can be at most 80 characters long`)
+}
+
+func TestObserverSeesQueryTags(t *testing.T) {
+	server, client := newTestServer(respondWith(200, `{"resource": 42}`))
+	defer server.Close()
+
+	var tags map[string]string
+
+	observed := client.NewWithObserver(func(result *QueryResult) {
+		tags = result.Tags
+	})
+
+	_, err := observed.Query(NewId(), Tags(map[string]string{"service": "billing"}))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"service": "billing"}, tags)
+}
+
+func TestQueryResultAcceptsQueryConfigs(t *testing.T) {
+	var tags string
+
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		tags = r.Header.Get(headerQueryTags)
+		w.Header().Set("X-Read-Ops", "1")
+		respondWith(200, `{"resource": [1, 2]}`)(w, r)
+	})
+	defer server.Close()
+
+	values, headers, err := client.BatchQueryResultWithConfig([]Expr{NewId(), NewId()}, Tags(map[string]string{"batch": "yes"}))
+	require.NoError(t, err)
+	require.Equal(t, []Value{LongV(1), LongV(2)}, values)
+	require.Equal(t, []string{"1"}, headers["X-Read-Ops"])
+	require.Equal(t, "batch=yes", tags)
+}
+
+func captureHeaders(t *testing.T, clientConfigs []ClientConfig, queryConfigs ...QueryConfig) (headers http.Header) {
+	server, _ := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		respondWith(200, `{"resource": 42}`)(w, r)
+	})
+	defer server.Close()
+
+	client := NewFaunaClient("secret", append(clientConfigs, Endpoint(server.URL))...)
+
+	_, err := client.Query(NewId(), queryConfigs...)
+	require.NoError(t, err)
+
+	return
+}
@@ -0,0 +1,133 @@
+package faunadb
+
+// Event is an event of a document's history or of a set's history. Document events have a create,
+// update or delete action, and their data is the document's data after the event. Set events have an
+// add or remove action, and their data is the values of the index entry, if any.
+type Event struct {
+	TS       int64  `fauna:"ts"`       // Time of the event, in microseconds since the epoch
+	Action   string `fauna:"action"`   // One of ActionCreate, ActionUpdate, ActionDelete, ActionAdd or ActionRemove
+	Document RefV   `fauna:"document"` // Document the event applies to
+	Data     Value  `fauna:"data"`     // Data of the event, nil or NullV if the event has none
+}
+
+/*
+EventIterator iterates over the events of a document or a set, fetching pages of events as needed.
+Use it as follows:
+
+	events := client.History(ref, 0, 0)
+
+	for events.Next() {
+		event := events.Event()
+		...
+	}
+
+	if err := events.Err(); err != nil {
+		...
+	}
+*/
+type EventIterator struct {
+	pages pageIterator
+	to    int64
+	event Event
+	err   error
+}
+
+/*
+History returns an iterator over the events of a document, oldest first, from time from to time to
+inclusive. Times are in microseconds since the epoch, as in the ts field of documents. Use 0 as from to
+start at the beginning of the history, and 0 as to for no upper bound. For example:
+
+	events := client.History(Ref(Collection("users"), "42"), 0, 0)
+
+	for events.Next() {
+		fmt.Println(events.Event().Action, events.Event().Data)
+	}
+*/
+func (client *FaunaClient) History(ref interface{}, from, to int64, configs ...QueryConfig) *EventIterator {
+	return HistoryOf(client, ref, from, to, configs...)
+}
+
+// HistoryOf is like FaunaClient.History, issuing its queries with the given querier.
+func HistoryOf(querier Querier, ref interface{}, from, to int64, configs ...QueryConfig) *EventIterator {
+	it := newEventIterator(querier, ref, nil, configs)
+	it.to = to
+
+	if from > 0 {
+		it.pages.after = LongV(from)
+	}
+
+	return it
+}
+
+/*
+SetEvents returns an iterator over the events of a set, such as the documents added to and removed
+from an index match. The options are given to Paginate: use Size to set the number of events fetched
+per query, After or Before to start from a given cursor, and TS to read the events as of a snapshot
+time. For example:
+
+	events := client.SetEvents(MatchTerm(Index("users_by_email"), "jane@example.com"), Size(100))
+*/
+func (client *FaunaClient) SetEvents(set interface{}, options ...OptionalParameter) *EventIterator {
+	return SetEventsOf(client, set, options...)
+}
+
+// SetEventsOf is like FaunaClient.SetEvents, issuing its queries with the given querier.
+func SetEventsOf(querier Querier, set interface{}, options ...OptionalParameter) *EventIterator {
+	return newEventIterator(querier, set, options, nil)
+}
+
+func newEventIterator(querier Querier, set interface{}, options []OptionalParameter, configs []QueryConfig) *EventIterator {
+	query := func(options ...OptionalParameter) Expr { return Paginate(Events(set), options...) }
+	return &EventIterator{pages: pageIterator{querier: querier, query: query, options: options, configs: configs}}
+}
+
+// Next advances the iterator to the next event. It returns false when there are no more events or
+// when a query fails, in which case Err returns the error.
+func (it *EventIterator) Next() bool {
+	elem, ok := it.pages.next()
+	if !ok || it.err != nil {
+		return false
+	}
+
+	it.event = Event{}
+
+	if it.err = elem.Get(&it.event); it.err != nil {
+		return false
+	}
+
+	if it.to > 0 && it.event.TS > it.to {
+		it.pages.stop()
+		return false
+	}
+
+	return true
+}
+
+// Event returns the current event.
+func (it *EventIterator) Event() Event { return it.event }
+
+// Err returns the error that stopped the iteration, if any.
+func (it *EventIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+
+	return it.pages.err
+}
+
+// QueryAt evaluates the expression as of the given snapshot time, in microseconds since the epoch.
+// All reads in the expression observe the database as it was at that time. See At.
+func (client *FaunaClient) QueryAt(ts int64, expr Expr, configs ...QueryConfig) (Value, error) {
+	return client.Query(At(ts, expr), configs...)
+}
+
+/*
+Restore replaces the data of a document with the data it had at the given time, in microseconds since
+the epoch. The restore is recorded as a new update event, so the document's history is preserved.
+For example:
+
+	client.Query(Restore(Ref(Collection("users"), "42"), event.TS))
+*/
+func Restore(ref, ts interface{}) Expr {
+	return Replace(ref, Obj{"data": Select("data", Get(ref, TS(ts)))})
+}
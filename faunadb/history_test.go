@@ -0,0 +1,125 @@
+package faunadb
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const historyRef = `{"@ref":{"id":"42","collection":{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}}}}}}`
+
+func respondWithPages(queries *[]string, pages ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*queries = append(*queries, string(body))
+
+		page := pages[0]
+		pages = pages[1:]
+
+		_, _ = io.WriteString(w, `{"resource":`+page+`}`)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	var queries []string
+
+	server, client := newTestServer(respondWithPages(&queries,
+		`{"data":[{"ts":10,"action":"create","document":`+historyRef+`,"data":{"name":"Jane"}}],"after":[{"ts":20,"action":"update","document":`+historyRef+`}]}`,
+		`{"data":[{"ts":20,"action":"update","document":`+historyRef+`,"data":{"name":"Jo"}},{"ts":30,"action":"delete","document":`+historyRef+`,"data":null}]}`,
+	))
+	defer server.Close()
+
+	events := client.History(RefCollection(Collection("users"), "42"), 5, 0)
+	var actions []string
+
+	for events.Next() {
+		event := events.Event()
+		actions = append(actions, event.Action)
+		require.Equal(t, "42", event.Document.ID)
+
+		if event.TS == 20 {
+			require.Equal(t, ObjectV{"name": StringV("Jo")}, event.Data)
+		}
+	}
+
+	require.NoError(t, events.Err())
+	require.Equal(t, []string{ActionCreate, ActionUpdate, ActionDelete}, actions)
+	require.False(t, events.Next())
+
+	require.Len(t, queries, 2)
+	require.Contains(t, queries[0], `"after":5`)
+	require.Contains(t, queries[0], `"paginate":{"events":{"id":"42"`)
+	require.Contains(t, queries[1], `"after":[{"object":{"action":"update"`)
+}
+
+func TestHistoryStopsAtUpperBound(t *testing.T) {
+	var queries []string
+
+	server, client := newTestServer(respondWithPages(&queries,
+		`{"data":[{"ts":10,"action":"create","document":`+historyRef+`},{"ts":20,"action":"update","document":`+historyRef+`}],"after":[{"ts":30}]}`,
+	))
+	defer server.Close()
+
+	events := client.History(RefCollection(Collection("users"), "42"), 0, 15)
+
+	require.True(t, events.Next())
+	require.Equal(t, int64(10), events.Event().TS)
+	require.False(t, events.Next())
+	require.NoError(t, events.Err())
+	require.Len(t, queries, 1)
+	require.NotContains(t, queries[0], `"after"`)
+}
+
+func TestSetEvents(t *testing.T) {
+	var queries []string
+
+	server, client := newTestServer(respondWithPages(&queries,
+		`{"data":[{"ts":10,"action":"add","document":`+historyRef+`,"data":["jane@example.com"]}]}`,
+	))
+	defer server.Close()
+
+	events := client.SetEvents(MatchTerm(Index("users_by_email"), "jane@example.com"), Size(10), TS(100))
+
+	require.True(t, events.Next())
+	require.Equal(t, Event{
+		TS:       10,
+		Action:   ActionAdd,
+		Document: RefV{"42", &RefV{"users", NativeCollections(), NativeCollections(), nil}, &RefV{"users", NativeCollections(), NativeCollections(), nil}, nil},
+		Data:     ArrayV{StringV("jane@example.com")},
+	}, events.Event())
+	require.False(t, events.Next())
+	require.NoError(t, events.Err())
+	require.Contains(t, queries[0], `"size":10,"ts":100`)
+}
+
+func TestHistoryError(t *testing.T) {
+	server, client := newTestServer(respondWith(404, `{"errors":[{"code":"instance not found","description":"Not found."}]}`))
+	defer server.Close()
+
+	events := client.History(RefCollection(Collection("users"), "42"), 0, 0)
+
+	require.False(t, events.Next())
+	require.True(t, IsNotFound(events.Err()))
+}
+
+func TestQueryAt(t *testing.T) {
+	var queries []string
+
+	server, client := newTestServer(respondWithPages(&queries, `null`))
+	defer server.Close()
+
+	_, err := client.QueryAt(100, Get(RefCollection(Collection("users"), "42")))
+	require.NoError(t, err)
+	require.Equal(t, `{"at":100,"expr":{"get":{"id":"42","ref":{"collection":"users"}}}}`, queries[0])
+}
+
+func TestSerializeRestore(t *testing.T) {
+	assertJSON(t,
+		Restore(RefCollection(Collection("users"), "42"), 100),
+		`{"params":{"object":{"data":{"from":{"get":{"id":"42","ref":{"collection":"users"}},"ts":100},"select":"data"}}},`+
+			`"replace":{"id":"42","ref":{"collection":"users"}}}`,
+	)
+}
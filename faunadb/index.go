@@ -0,0 +1,234 @@
+package faunadb
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/*
+IndexDef describes an index: the fields of the indexed documents used as terms, and the fields
+returned as values. It creates the index, builds Match and Range calls shaped after its terms and
+values, and decodes value tuples into structs by position. For example:
+
+	usersByEmail := IndexDef{
+		Name:   "users_by_email",
+		Source: Collection("users"),
+		Terms:  []IndexField{FieldPath("data", "email")},
+		Values: []IndexField{FieldPath("data", "name"), FieldPath("ref")},
+	}
+
+	_, err := client.Query(usersByEmail.Create())
+
+	value, err := client.Query(Paginate(usersByEmail.Match("jane@example.com")))
+
+	var users []struct {
+		Name string
+		Ref  RefV
+	}
+
+	err = usersByEmail.Decode(value, &users)
+*/
+type IndexDef struct {
+	Name     string
+	Source   interface{}     // Source collection, such as Collection("users")
+	Bindings map[string]Expr // Computed fields usable by terms and values, as lambdas wrapped with Query
+	Terms    []IndexField
+	Values   []IndexField
+	Unique   bool
+}
+
+// IndexField is an index term or value, either a path into the indexed documents or a binding.
+type IndexField struct {
+	Field   []string
+	Binding string
+	Reverse bool
+}
+
+// FieldPath returns an index field for the given path into the indexed documents.
+func FieldPath(path ...string) IndexField { return IndexField{Field: path} }
+
+// BindingField returns an index field for the given binding of the index's source.
+func BindingField(binding string) IndexField { return IndexField{Binding: binding} }
+
+// Params returns the field as given to CreateIndex in the terms or values of an index.
+func (field IndexField) Params() Obj {
+	param := Obj{}
+
+	if field.Binding != "" {
+		param["binding"] = field.Binding
+	} else {
+		path := Arr{}
+
+		for _, segment := range field.Field {
+			path = append(path, segment)
+		}
+
+		param["field"] = path
+	}
+
+	if field.Reverse {
+		param["reverse"] = true
+	}
+
+	return param
+}
+
+// Reversed returns a copy of the field sorted in reverse order.
+func (field IndexField) Reversed() IndexField {
+	field.Reverse = true
+	return field
+}
+
+// Ref returns a ref to the index.
+func (index IndexDef) Ref() Expr { return Index(index.Name) }
+
+// CreateParams returns the parameters given to CreateIndex to create the index.
+func (index IndexDef) CreateParams() Obj {
+	source := index.Source
+
+	if len(index.Bindings) > 0 {
+		fields := Obj{}
+
+		for name, binding := range index.Bindings {
+			fields[name] = binding
+		}
+
+		source = Obj{"collection": source, "fields": fields}
+	}
+
+	params := Obj{"name": index.Name, "source": source, "unique": index.Unique}
+
+	if len(index.Terms) > 0 {
+		params["terms"] = indexFieldsParams(index.Terms)
+	}
+
+	if len(index.Values) > 0 {
+		params["values"] = indexFieldsParams(index.Values)
+	}
+
+	return params
+}
+
+// Create returns an expression creating the index.
+func (index IndexDef) Create() Expr { return CreateIndex(index.CreateParams()) }
+
+// Match returns the set of index entries matching the given terms, one for each of the index's
+// terms. A single term is given as is and multiple terms as an array, as expected by FaunaDB. It
+// panics if the number of terms doesn't match the index.
+func (index IndexDef) Match(terms ...interface{}) Expr {
+	switch {
+	case len(terms) != len(index.Terms):
+		panic(fmt.Sprintf("faunadb: index %s has %d terms, %d given", index.Name, len(index.Terms), len(terms)))
+	case len(terms) == 0:
+		return Match(index.Ref())
+	case len(terms) == 1:
+		return MatchTerm(index.Ref(), terms[0])
+	default:
+		return MatchTerm(index.Ref(), Arr(terms))
+	}
+}
+
+// Range filters a set of the index's entries, such as the result of Match, to the entries whose
+// values are between from and to, inclusive. Bounds are prefixes of the index's values, where an
+// empty bound leaves that side of the range unbounded. For example, Range(set, Arr{10}, Arr{})
+// returns the entries whose first value is greater than or equal to 10.
+func (index IndexDef) Range(set interface{}, from, to Arr) Expr {
+	return Range(set, index.rangeBound(from), index.rangeBound(to))
+}
+
+func (index IndexDef) rangeBound(bound Arr) interface{} {
+	if len(index.Values) == 1 && len(bound) == 1 {
+		return bound[0]
+	}
+
+	if bound == nil {
+		return Arr{}
+	}
+
+	return bound
+}
+
+/*
+Decode decodes the values of index entries into dest, assigning the values of each entry to the
+exported fields of a struct by position, in the order the fields are declared. Fields tagged with
+fauna:"-" are skipped. Dest must be a pointer to a struct, to decode a single entry, or a pointer to
+a slice of structs, to decode an array of entries or a page of them as returned by Paginate.
+*/
+func (index IndexDef) Decode(value Value, dest interface{}) error {
+	target := reflect.ValueOf(dest)
+
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("faunadb: decoding index %s values requires a non-nil pointer, got %T", index.Name, dest)
+	}
+
+	target = target.Elem()
+
+	if target.Kind() == reflect.Struct {
+		return index.decodeEntry(value, target)
+	}
+
+	if target.Kind() != reflect.Slice || target.Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("faunadb: can not decode index %s values into %T", index.Name, dest)
+	}
+
+	if obj, ok := value.(ObjectV); ok {
+		value = obj["data"]
+	}
+
+	entries, ok := value.(ArrayV)
+
+	if !ok {
+		return fmt.Errorf("faunadb: index %s entries are a %T, not an array or a page", index.Name, value)
+	}
+
+	slice := reflect.MakeSlice(target.Type(), len(entries), len(entries))
+
+	for i, entry := range entries {
+		if err := index.decodeEntry(entry, slice.Index(i)); err != nil {
+			return DecodeError{path: pathFromIndexes(i), err: err}
+		}
+	}
+
+	target.Set(slice)
+	return nil
+}
+
+func (index IndexDef) decodeEntry(entry Value, target reflect.Value) error {
+	tuple, ok := entry.(ArrayV)
+
+	if len(index.Values) <= 1 {
+		tuple = ArrayV{entry}
+	} else if !ok {
+		return fmt.Errorf("faunadb: index %s entry is a %T, not an array of values", index.Name, entry)
+	}
+
+	var fields []reflect.Value
+
+	for i := 0; i < target.NumField(); i++ {
+		if target.Field(i).CanSet() && fieldName(target.Type().Field(i)) != "-" {
+			fields = append(fields, target.Field(i))
+		}
+	}
+
+	if len(fields) != len(tuple) {
+		return fmt.Errorf("faunadb: index %s entry has %d values, %s has %d fields", index.Name, len(tuple), target.Type(), len(fields))
+	}
+
+	for i, field := range fields {
+		if err := tuple[i].Get(field.Addr().Interface()); err != nil {
+			return DecodeError{path: pathFromIndexes(i), err: err}
+		}
+	}
+
+	return nil
+}
+
+func indexFieldsParams(fields []IndexField) Arr {
+	params := Arr{}
+
+	for _, field := range fields {
+		params = append(params, field.Params())
+	}
+
+	return params
+}
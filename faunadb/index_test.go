@@ -0,0 +1,105 @@
+package faunadb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var usersByAge = IndexDef{
+	Name:   "users_by_age",
+	Source: Collection("users"),
+	Terms:  []IndexField{FieldPath("data", "country")},
+	Values: []IndexField{FieldPath("data", "age").Reversed(), FieldPath("ref")},
+}
+
+type userByAge struct {
+	Age    int
+	Ref    RefV
+	Ignore string `fauna:"-"`
+	hidden string
+}
+
+func TestSerializeIndexDefCreate(t *testing.T) {
+	assertJSON(t, usersByAge.Create(),
+		`{"create_index":{"object":{"name":"users_by_age","source":{"collection":"users"},`+
+			`"terms":[{"object":{"field":["data","country"]}}],`+
+			`"unique":false,"values":[{"object":{"field":["data","age"],"reverse":true}},{"object":{"field":["ref"]}}]}}}`,
+	)
+
+	index := IndexDef{
+		Name:     "users_by_initial",
+		Source:   Collection("users"),
+		Bindings: map[string]Expr{"initial": Query(Lambda("doc", LowerCase(Select(Arr{"data", "name"}, Var("doc")))))},
+		Terms:    []IndexField{BindingField("initial")},
+		Unique:   true,
+	}
+
+	assertJSON(t, index.Create(),
+		`{"create_index":{"object":{"name":"users_by_initial","source":{"object":{"collection":{"collection":"users"},`+
+			`"fields":{"object":{"initial":{"query":{"expr":{"lowercase":{"from":{"var":"doc"},"select":["data","name"]}},"lambda":"doc"}}}}}},`+
+			`"terms":[{"object":{"binding":"initial"}}],"unique":true}}}`,
+	)
+}
+
+func TestSerializeIndexDefMatch(t *testing.T) {
+	assertJSON(t, usersByAge.Match("BR"), `{"match":{"index":"users_by_age"},"terms":"BR"}`)
+	require.PanicsWithValue(t, "faunadb: index users_by_age has 1 terms, 0 given", func() { usersByAge.Match() })
+
+	index := IndexDef{Name: "all_users"}
+	assertJSON(t, index.Match(), `{"match":{"index":"all_users"}}`)
+
+	index = IndexDef{Name: "users_by_name", Terms: []IndexField{FieldPath("data", "first"), FieldPath("data", "last")}}
+	assertJSON(t, index.Match("Jane", "Doe"), `{"match":{"index":"users_by_name"},"terms":["Jane","Doe"]}`)
+}
+
+func TestSerializeIndexDefRange(t *testing.T) {
+	assertJSON(t, usersByAge.Range(usersByAge.Match("BR"), Arr{30}, nil),
+		`{"from":[30],"range":{"match":{"index":"users_by_age"},"terms":"BR"},"to":[]}`)
+
+	index := IndexDef{Name: "users_by_age", Values: []IndexField{FieldPath("data", "age")}}
+	assertJSON(t, index.Range(Match(index.Ref()), Arr{18}, Arr{65}),
+		`{"from":18,"range":{"match":{"index":"users_by_age"}},"to":65}`)
+}
+
+func TestDecodeIndexValues(t *testing.T) {
+	page := decodedValue(t, `{"data":[[42,`+userRef+`],[24,`+userRef+`]],"after":[24,`+userRef+`]}`)
+
+	var users []userByAge
+	require.NoError(t, usersByAge.Decode(page, &users))
+	require.Len(t, users, 2)
+	require.Equal(t, 42, users[0].Age)
+	require.Equal(t, 24, users[1].Age)
+	require.Equal(t, "42", users[1].Ref.ID)
+
+	var user userByAge
+	require.NoError(t, usersByAge.Decode(decodedValue(t, `[42,`+userRef+`]`), &user))
+	require.Equal(t, 42, user.Age)
+
+	var ages []struct{ Age int }
+	index := IndexDef{Name: "ages", Values: []IndexField{FieldPath("data", "age")}}
+	require.NoError(t, index.Decode(decodedValue(t, `[42,24]`), &ages))
+	require.Equal(t, []struct{ Age int }{{42}, {24}}, ages)
+
+	var tags []struct{ Tags []string }
+	index = IndexDef{Name: "tags", Values: []IndexField{FieldPath("data", "tags")}}
+	require.NoError(t, index.Decode(decodedValue(t, `[["a","b"],["c"]]`), &tags))
+	require.Equal(t, []struct{ Tags []string }{{[]string{"a", "b"}}, {[]string{"c"}}}, tags)
+}
+
+func TestDecodeIndexValuesErrors(t *testing.T) {
+	var users []userByAge
+
+	err := usersByAge.Decode(decodedValue(t, `{"data":[[42,`+userRef+`],["old",`+userRef+`]]}`), &users)
+	require.EqualError(t, err, `Error while decoding fauna value at: 1 / 0. Can not assign value of type "faunadb.StringV" to a value of type "int"`)
+
+	err = usersByAge.Decode(decodedValue(t, `[[42]]`), &users)
+	require.EqualError(t, err, `Error while decoding fauna value at: 0. faunadb: index users_by_age entry has 1 values, faunadb.userByAge has 2 fields`)
+
+	err = usersByAge.Decode(decodedValue(t, `[42]`), &users)
+	require.EqualError(t, err, `Error while decoding fauna value at: 0. faunadb: index users_by_age entry is a faunadb.LongV, not an array of values`)
+
+	require.EqualError(t, usersByAge.Decode(decodedValue(t, `[]`), users), `faunadb: decoding index users_by_age values requires a non-nil pointer, got []faunadb.userByAge`)
+	require.EqualError(t, usersByAge.Decode(decodedValue(t, `[]`), new(int)), `faunadb: can not decode index users_by_age values into *int`)
+	require.EqualError(t, usersByAge.Decode(decodedValue(t, `42`), &users), `faunadb: index users_by_age entries are a faunadb.LongV, not an array or a page`)
+}
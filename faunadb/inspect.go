@@ -0,0 +1,118 @@
+package faunadb
+
+import "github.com/fauna/faunadb-go/faunadb/internal/funcs"
+
+// Functions that may write to the database when evaluated.
+var writeFunctions = map[string]bool{
+	"call": true, "create": true, "create_class": true, "create_collection": true, "create_database": true,
+	"create_function": true, "create_index": true, "create_key": true, "create_role": true, "delete": true,
+	"insert": true, "login": true, "logout": true, "move_database": true, "remove": true, "replace": true,
+	"update": true,
+}
+
+// exprCall describes a function call found in an expression tree.
+type exprCall struct {
+	name string
+	args unescapedObj
+}
+
+func normalizeExpr(expr Expr) Expr {
+	switch expr.(type) {
+	case Obj, Arr:
+		return wrap(expr)
+	default:
+		return expr
+	}
+}
+
+func isObjectLiteral(obj unescapedObj) bool {
+	_, ok := obj["object"]
+	return ok && len(obj) == 1
+}
+
+func asCall(expr Expr) (call exprCall, ok bool) {
+	obj, isObj := normalizeExpr(expr).(unescapedObj)
+	if !isObj || len(obj) == 0 || isObjectLiteral(obj) {
+		return
+	}
+
+	keys := make([]string, 0, len(obj))
+
+	for key := range obj {
+		keys = append(keys, key)
+	}
+
+	if name := funcs.Name(keys); name != "" {
+		return exprCall{name, obj}, true
+	}
+
+	return
+}
+
+// subExprs returns the expressions nested directly inside of the given expression.
+func subExprs(expr Expr) (exprs []Expr) {
+	switch e := normalizeExpr(expr).(type) {
+	case unescapedArr:
+		exprs = append(exprs, e...)
+
+	case unescapedObj:
+		if isObjectLiteral(e) {
+			return subExprs(e["object"])
+		}
+
+		call, isCall := asCall(e)
+
+		for key, arg := range e {
+			if isCall && call.name == "let" && key == "let" {
+				exprs = append(exprs, letBindings(arg)...)
+			} else {
+				exprs = append(exprs, arg)
+			}
+		}
+	}
+
+	return
+}
+
+func letBindings(bindings Expr) (exprs []Expr) {
+	switch b := bindings.(type) {
+	case unescapedArr:
+		for _, binding := range b {
+			exprs = append(exprs, letBindings(binding)...)
+		}
+
+	case unescapedObj:
+		for _, value := range b {
+			exprs = append(exprs, value)
+		}
+	}
+
+	return
+}
+
+// walkCalls visits every function call in the expression tree. Nested expressions of a call are
+// only visited if visit returns true.
+func walkCalls(expr Expr, visit func(exprCall) bool) {
+	if call, ok := asCall(expr); ok && !visit(call) {
+		return
+	}
+
+	for _, sub := range subExprs(expr) {
+		walkCalls(sub, visit)
+	}
+}
+
+// isReadOnly reports whether the expression can be evaluated without writing to the database.
+func isReadOnly(expr Expr) bool {
+	readOnly := true
+
+	walkCalls(expr, func(call exprCall) bool {
+		if writeFunctions[call.name] {
+			readOnly = false
+		}
+
+		return readOnly
+	})
+
+	return readOnly
+}
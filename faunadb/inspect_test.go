@@ -0,0 +1,56 @@
+package faunadb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallNames(t *testing.T) {
+	tests := []struct {
+		expr Expr
+		name string
+	}{
+		{Get(Ref("collections/spells/42"), TS(1)), "get"},
+		{Map(Arr{1, 2}, Lambda("x", Var("x"))), "map"},
+		{Lambda("x", Var("x")), "lambda"},
+		{Collection("spells"), "collection"},
+		{ScopedCollection("spells", Database("db")), "collection"},
+		{Replace(Ref("collections/spells/42"), Obj{}), "replace"},
+		{ReplaceStr("abc", "b", "c"), "replacestr"},
+		{Pow(2, 3), "pow"},
+		{Exp(2), "exp"},
+		{Paginate(Match(Index("idx")), EventsOpt(true)), "paginate"},
+		{Merge(Obj{}, Obj{}, ConflictResolver(Lambda("x", Var("x")))), "merge"},
+	}
+
+	for _, test := range tests {
+		call, ok := asCall(test.expr)
+		require.True(t, ok)
+		require.Equal(t, test.name, call.name)
+	}
+}
+
+func TestLiteralsAreNotCalls(t *testing.T) {
+	for _, expr := range []Expr{Obj{"get": 1}, Arr{1}, StringV("get"), ObjectV{"get": LongV(1)}} {
+		_, ok := asCall(expr)
+		require.False(t, ok)
+	}
+}
+
+func TestIsReadOnly(t *testing.T) {
+	ref := Ref("collections/spells/42")
+
+	require.True(t, isReadOnly(Get(ref)))
+	require.True(t, isReadOnly(Obj{"create": Get(ref)}))
+	require.True(t, isReadOnly(Let().Bind("update", Get(ref)).In(Var("update"))))
+	require.True(t, isReadOnly(ReplaceStr("abc", "b", "c")))
+	require.True(t, isReadOnly(Paginate(MatchTerm(Index("spells_by_element"), "fire"))))
+
+	require.False(t, isReadOnly(Create(Collection("spells"), Obj{})))
+	require.False(t, isReadOnly(Replace(ref, Obj{})))
+	require.False(t, isReadOnly(Arr{Get(ref), Delete(ref)}))
+	require.False(t, isReadOnly(Obj{"data": Update(ref, Obj{})}))
+	require.False(t, isReadOnly(Let().Bind("x", Get(ref)).In(Delete(Var("x")))))
+	require.False(t, isReadOnly(Map(Arr{1}, Lambda("x", Call(Function("fn"), Var("x"))))))
+}
@@ -0,0 +1,40 @@
+// Package funcs describes how query language function calls are encoded, for the packages reading
+// expressions back.
+package funcs
+
+// Parameters are the names used as parameters of query language functions. A function call is encoded
+// as an object containing the function name along with its parameters, so these names are used to tell
+// them apart.
+var Parameters = map[string]bool{
+	"action": true, "after": true, "arguments": true, "b": true, "before": true, "collection": true,
+	"default": true, "else": true, "events": true, "exp": true, "expr": true, "find": true, "first": true,
+	"from": true, "id": true, "in": true, "initial": true, "lambda": true, "length": true, "normalizer": true,
+	"number": true, "offset": true, "other": true, "params": true, "password": true, "pattern": true,
+	"precision": true, "replace": true, "scope": true, "search": true, "separator": true, "size": true,
+	"sources": true, "start": true, "terms": true, "then": true, "to": true, "ts": true, "unit": true,
+	"values": true, "with": true,
+}
+
+// ParameterFunctions are the parameter names that are also names of query language functions.
+var ParameterFunctions = map[string]bool{
+	"collection": true, "events": true, "exp": true, "lambda": true, "length": true, "replace": true,
+}
+
+// Name returns the name of the function called by an object with the given keys, or an empty string
+// if the keys are only parameters. A key that is not a parameter is the function name, otherwise a
+// parameter that is also a function name is, as in {"length": "str"}.
+func Name(keys []string) string {
+	var fallback string
+
+	for _, key := range keys {
+		if !Parameters[key] {
+			return key
+		}
+
+		if ParameterFunctions[key] {
+			fallback = key
+		}
+	}
+
+	return fallback
+}
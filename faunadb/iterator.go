@@ -0,0 +1,58 @@
+package faunadb
+
+// pageIterator fetches the pages of a paginated query as its elements are consumed.
+type pageIterator struct {
+	querier Querier
+	query   func(options ...OptionalParameter) Expr
+	options []OptionalParameter
+	configs []QueryConfig
+	after   Value
+	page    []Value
+	done    bool
+	err     error
+}
+
+// next returns the next element of the pages, fetching a new page if needed. It returns false when
+// there are no more elements or when a query fails.
+func (it *pageIterator) next() (Value, bool) {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			return nil, false
+		}
+
+		it.fetch()
+	}
+
+	elem := it.page[0]
+	it.page = it.page[1:]
+
+	return elem, true
+}
+
+// stop ends the iteration without fetching further pages.
+func (it *pageIterator) stop() {
+	it.done = true
+	it.page = nil
+}
+
+func (it *pageIterator) fetch() {
+	options := it.options
+
+	if it.after != nil {
+		options = append(options[:len(options):len(options)], After(it.after))
+	}
+
+	res, err := it.querier.Query(it.query(options...), it.configs...)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	if it.err = res.At(ObjKey("data")).Get(&it.page); it.err != nil {
+		return
+	}
+
+	if it.after, err = res.At(ObjKey("after")).GetValue(); err != nil {
+		it.done = true
+	}
+}
@@ -23,6 +23,21 @@ func UnmarshalJSON(buffer []byte, outValue *Value) error {
 	return nil
 }
 
+// Unmarshal json string into a FaunaDB expression. JSON objects are decoded as function calls, following
+// FaunaDB's wire format, where object literals are escaped with the "object" key. For example:
+//
+//	var expr Expr
+//	err := UnmarshalJSONExpr([]byte(`{"get":{"collection":"users"}}`), &expr) // Get(Collection("users"))
+func UnmarshalJSONExpr(buffer []byte, outExpr *Expr) error {
+	expr, err := exprFromJSON(buffer)
+	if err != nil {
+		return err
+	}
+
+	*outExpr = expr
+	return nil
+}
+
 // Marshal a FaunaDB value into a json string.
 func MarshalJSON(value Value) ([]byte, error) {
 	return json.Marshal(unwrap(value))
@@ -347,3 +362,77 @@ func (p *jsonParser) hasMore() bool {
 
 	return true
 }
+
+// exprFromJSON decodes the wire representation of an expression, such as the lambda of a QueryV.
+func exprFromJSON(raw []byte) (Expr, error) {
+	var decoded interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	return exprFromDecodedJSON(decoded)
+}
+
+func exprFromDecodedJSON(decoded interface{}) (Expr, error) {
+	switch v := decoded.(type) {
+	case map[string]interface{}:
+		for key := range v {
+			if len(v) == 1 && strings.HasPrefix(key, "@") {
+				raw, err := json.Marshal(v)
+				if err != nil {
+					return nil, err
+				}
+
+				return parseJSON(bytes.NewReader(raw))
+			}
+		}
+
+		obj := make(unescapedObj, len(v))
+
+		for key, elem := range v {
+			expr, err := exprFromDecodedJSON(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			obj[key] = expr
+		}
+
+		return obj, nil
+
+	case []interface{}:
+		arr := make(unescapedArr, len(v))
+
+		for i, elem := range v {
+			expr, err := exprFromDecodedJSON(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			arr[i] = expr
+		}
+
+		return arr, nil
+
+	case json.Number:
+		if num, err := v.Int64(); err == nil {
+			return LongV(num), nil
+		}
+
+		num, err := v.Float64()
+		return DoubleV(num), err
+
+	case string:
+		return StringV(v), nil
+
+	case bool:
+		return BooleanV(v), nil
+
+	default:
+		return NullV{}, nil
+	}
+}
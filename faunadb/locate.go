@@ -0,0 +1,240 @@
+package faunadb
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrorLocation describes the subexpression of a query pointed to by the position of a query error.
+type ErrorLocation struct {
+	Error QueryError // The located query error
+	Expr  Expr       // The failing subexpression
+	FQL   string     // The failing subexpression in the query language syntax
+	File  string     // Go source file of the builder call of the failing subexpression, if tracked
+	Line  int        // Line of the builder call in File, if tracked
+}
+
+func (location ErrorLocation) String() string {
+	str := fmt.Sprintf("[%s] %s", strings.Join(location.Error.Position, "/"), location.FQL)
+
+	if location.File != "" {
+		str = fmt.Sprintf("%s (%s:%d)", str, location.File, location.Line)
+	}
+
+	return str
+}
+
+/*
+Locate resolves a query error position against the expression submitted to the server and returns
+the failing subexpression. It returns false if the position doesn't exist in the expression.
+
+If builder calls are tracked, see TrackBuilderCalls, the returned location also contains the Go source
+file and line of the innermost query function call enclosing the failing subexpression.
+*/
+func Locate(expr Expr, position []string) (location ErrorLocation, ok bool) {
+	current := normalizeExpr(expr)
+	call, tracked := trackedBuilderCall(current)
+
+	for _, key := range position {
+		if current, ok = locateStep(current, key); !ok {
+			return
+		}
+
+		if innerCall, innerTracked := trackedBuilderCall(current); innerTracked {
+			call, tracked = innerCall, true
+		}
+	}
+
+	location = ErrorLocation{Expr: current, FQL: FQL(current)}
+
+	if tracked {
+		location.File, location.Line = call.file, call.line
+	}
+
+	return location, true
+}
+
+func locateStep(expr Expr, key string) (Expr, bool) {
+	switch e := expr.(type) {
+	case unescapedObj:
+		if sub, ok := e[key]; ok {
+			return normalizeExpr(sub), true
+		}
+
+		if isObjectLiteral(e) {
+			return locateStep(e["object"], key)
+		}
+
+	case ObjectV:
+		if sub, ok := e[key]; ok {
+			return sub, true
+		}
+
+	case unescapedArr:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(e) {
+			return normalizeExpr(e[i]), true
+		}
+
+	case ArrayV:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(e) {
+			return e[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// LocateErrors resolves the positions of all query errors found in err against the expression that
+// caused them. Errors without position in the expression are omitted. The causes of errors raised by
+// user defined functions are not located, since their positions are relative to the function's body.
+func LocateErrors(err error, expr Expr) (locations []ErrorLocation) {
+	faunaErr, ok := asFaunaError(err)
+	if !ok {
+		return
+	}
+
+	for _, queryError := range faunaErr.Errors() {
+		if location, ok := Locate(expr, queryError.Position); ok {
+			location.Error = queryError
+			locations = append(locations, location)
+		}
+	}
+
+	return
+}
+
+// AnnotatedError is returned by queries of clients using the AnnotateErrors middleware. It wraps the
+// original error along with the location of its query errors in the submitted expression.
+type AnnotatedError struct {
+	Err       error
+	Locations []ErrorLocation
+}
+
+func (err AnnotatedError) Error() string {
+	locations := make([]string, len(err.Locations))
+
+	for i, location := range err.Locations {
+		locations[i] = location.String()
+	}
+
+	return fmt.Sprintf("%s Locations: %s", err.Err, strings.Join(locations, ", "))
+}
+
+// Unwrap returns the original error.
+func (err AnnotatedError) Unwrap() error { return err.Err }
+
+/*
+AnnotateErrors returns a middleware that wraps query errors into an AnnotatedError containing the
+location of each query error in the submitted expression. For example:
+
+	client.Use(f.AnnotateErrors())
+
+	_, err := client.Query(f.Map(refs, f.Lambda("ref", f.Get(f.Var("ref")))))
+	// Response error 404. Errors: [map/expr/get](instance not found): Document not found.
+	// Locations: [map/expr/get] Var("ref")
+
+The original error is still available through Unwrap and the helper functions of this package,
+such as IsNotFound.
+*/
+func AnnotateErrors() Middleware {
+	return func(next QueryFunc) QueryFunc {
+		return func(expr Expr, configs ...QueryConfig) (Value, error) {
+			value, err := next(expr, configs...)
+
+			if locations := LocateErrors(err, expr); len(locations) > 0 {
+				err = AnnotatedError{err, locations}
+			}
+
+			return value, err
+		}
+	}
+}
+
+type builderCall struct {
+	expr unescapedObj // Kept to prevent the expression's memory from being reused while tracked
+	file string
+	line int
+}
+
+var builderCalls struct {
+	sync.Mutex
+	enabled int32
+	calls   map[uintptr]builderCall
+}
+
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+/*
+TrackBuilderCalls enables or disables the recording of the Go source file and line of query function
+calls, such as Get or Map, which are then reported by Locate. This is a debugging aid: while enabled,
+every expression built is retained in memory and building expressions is considerably slower.
+Disabling the tracking releases all recorded expressions.
+*/
+func TrackBuilderCalls(enabled bool) {
+	builderCalls.Lock()
+	defer builderCalls.Unlock()
+
+	if enabled {
+		atomic.StoreInt32(&builderCalls.enabled, 1)
+		builderCalls.calls = make(map[uintptr]builderCall)
+	} else {
+		atomic.StoreInt32(&builderCalls.enabled, 0)
+		builderCalls.calls = nil
+	}
+}
+
+// trackingBuilderCalls reports whether builder calls are tracked. It is small enough to be inlined.
+func trackingBuilderCalls() bool { return atomic.LoadInt32(&builderCalls.enabled) != 0 }
+
+// trackBuilderCall records the caller of a query function. Callers check that the tracking is enabled
+// first, keeping the cost of building expressions unchanged while it is disabled.
+func trackBuilderCall(fn unescapedObj) {
+	if file, line, ok := builderCaller(); ok {
+		builderCalls.Lock()
+		defer builderCalls.Unlock()
+
+		if builderCalls.calls != nil {
+			builderCalls.calls[reflect.ValueOf(fn).Pointer()] = builderCall{fn, file, line}
+		}
+	}
+}
+
+func trackedBuilderCall(expr Expr) (call builderCall, ok bool) {
+	fn, isObj := expr.(unescapedObj)
+	if !isObj || !trackingBuilderCalls() {
+		return
+	}
+
+	builderCalls.Lock()
+	defer builderCalls.Unlock()
+
+	call, ok = builderCalls.calls[reflect.ValueOf(fn).Pointer()]
+	return
+}
+
+// builderCaller returns the first caller outside of this package's source files.
+func builderCaller() (file string, line int, ok bool) {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+
+	for {
+		frame, more := frames.Next()
+
+		if frame.File != "" && filepath.Dir(frame.File) != packageDir || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File, frame.Line, true
+		}
+
+		if !more {
+			return
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package faunadb
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocateErrorPosition(t *testing.T) {
+	expr := Map(Arr{1, 2}, Lambda("x", Get(Var("x"))))
+
+	location, ok := Locate(expr, []string{"map", "expr", "get"})
+	require.True(t, ok)
+	require.Equal(t, Var("x"), location.Expr)
+	require.Equal(t, `Var("x")`, location.FQL)
+	require.Empty(t, location.File)
+
+	location, ok = Locate(expr, []string{"collection", "1"})
+	require.True(t, ok)
+	require.Equal(t, `2`, location.FQL)
+
+	location, ok = Locate(expr, nil)
+	require.True(t, ok)
+	require.Equal(t, `Map([1, 2], Lambda("x", Get(Var("x"))))`, location.FQL)
+
+	_, ok = Locate(expr, []string{"map", "missing"})
+	require.False(t, ok)
+}
+
+func TestLocateInsideObjectLiterals(t *testing.T) {
+	expr := Create(Collection("users"), Obj{"data": Obj{"name": Concat(Arr{"a", 1})}})
+
+	location, ok := Locate(expr, []string{"params", "object", "data", "object", "name", "concat"})
+	require.True(t, ok)
+	require.Equal(t, `["a", 1]`, location.FQL)
+
+	location, ok = Locate(expr, []string{"params", "data", "name"})
+	require.True(t, ok)
+	require.Equal(t, `Concat(["a", 1])`, location.FQL)
+}
+
+func TestLocateTrackedBuilderCalls(t *testing.T) {
+	TrackBuilderCalls(true)
+	defer TrackBuilderCalls(false)
+
+	_, file, line, _ := runtime.Caller(0)
+	expr := Map(Arr{1, 2}, Lambda("x", Add(Var("x"), "one")))
+
+	location, ok := Locate(expr, []string{"map", "expr", "add", "1"})
+	require.True(t, ok)
+	require.Equal(t, `"one"`, location.FQL)
+	require.Equal(t, file, location.File)
+	require.Equal(t, line+1, location.Line)
+}
+
+func TestUntrackedBuilderCalls(t *testing.T) {
+	TrackBuilderCalls(true)
+	TrackBuilderCalls(false)
+
+	location, ok := Locate(Get(Var("x")), []string{"get"})
+	require.True(t, ok)
+	require.Empty(t, location.File)
+}
+
+func TestAnnotateErrors(t *testing.T) {
+	server, client := newTestServer(respondWith(404,
+		`{"errors":[{"position":["map","expr","get"],"code":"instance not found","description":"Document not found."}]}`,
+	))
+	defer server.Close()
+
+	client.Use(AnnotateErrors())
+
+	_, err := client.Query(Map(Arr{1}, Lambda("ref", Get(Var("ref")))))
+	require.Error(t, err)
+	require.True(t, IsNotFound(err))
+
+	annotated, ok := err.(AnnotatedError)
+	require.True(t, ok)
+	require.Len(t, annotated.Locations, 1)
+	require.Equal(t, `Var("ref")`, annotated.Locations[0].FQL)
+	require.Equal(t, ErrInstanceNotFound.Error(), annotated.Locations[0].Error.Code)
+	require.Equal(t,
+		`Response error 404. Errors: [map/expr/get](instance not found): Document not found. Locations: [map/expr/get] Var("ref")`,
+		err.Error(),
+	)
+}
+
+func TestAnnotateErrorsIgnoresSuccessfulQueries(t *testing.T) {
+	server, client := newTestServer(respondWith(200, `{"resource":1}`))
+	defer server.Close()
+
+	client.Use(AnnotateErrors())
+
+	value, err := client.Query(Add(1, 0))
+	require.NoError(t, err)
+	require.Equal(t, LongV(1), value)
+}
@@ -0,0 +1,45 @@
+package faunadb
+
+// QueryFunc is the signature of a function that evaluates an expression and returns its result.
+// FaunaClient's Query method satisfies this signature.
+type QueryFunc func(expr Expr, configs ...QueryConfig) (Value, error)
+
+/*
+Middleware wraps a QueryFunc with additional behavior. A middleware can inspect or rewrite the
+expression and its query configurations before calling next, and inspect or replace the resulting
+value and error after it returns. A middleware may also return without calling next at all.
+
+For example, a middleware that logs the duration of every query:
+
+	client.Use(func(next f.QueryFunc) f.QueryFunc {
+		return func(expr f.Expr, configs ...f.QueryConfig) (f.Value, error) {
+			start := time.Now()
+			value, err := next(expr, configs...)
+			log.Printf("query took %s", time.Since(start))
+			return value, err
+		}
+	})
+*/
+type Middleware func(next QueryFunc) QueryFunc
+
+/*
+Use registers middlewares to be applied to every query issued by this client. Middlewares are applied
+in the order they are registered: the first middleware is the outermost one, and the last middleware
+is the closest to the network call.
+
+Clients created with NewSessionClient or NewWithObserver inherit the middlewares registered at the
+time of their creation. Use must not be called concurrently with queries issued by the same client.
+*/
+func (client *FaunaClient) Use(middlewares ...Middleware) {
+	client.middlewares = append(client.middlewares, middlewares...)
+}
+
+func (client *FaunaClient) queryChain() QueryFunc {
+	query := QueryFunc(client.query)
+
+	for i := len(client.middlewares) - 1; i >= 0; i-- {
+		query = client.middlewares[i](query)
+	}
+
+	return query
+}
@@ -0,0 +1,103 @@
+package faunadb
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewaresAreAppliedInRegistrationOrder(t *testing.T) {
+	var calls []string
+
+	record := func(name string) Middleware {
+		return func(next QueryFunc) QueryFunc {
+			return func(expr Expr, configs ...QueryConfig) (Value, error) {
+				calls = append(calls, name)
+				return next(expr, configs...)
+			}
+		}
+	}
+
+	client := NewFaunaClient("secret")
+	client.Use(record("first"), record("second"))
+	client.Use(record("third"), shortCircuit(LongV(42), nil))
+
+	value, err := client.Query(NewId())
+	require.NoError(t, err)
+	require.Equal(t, LongV(42), value)
+	require.Equal(t, []string{"first", "second", "third"}, calls)
+}
+
+func TestMiddlewareCanRewriteExpression(t *testing.T) {
+	var body []byte
+
+	server, client := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		respondWith(200, `{"resource": "rewritten"}`)(w, r)
+	})
+	defer server.Close()
+
+	client.Use(func(next QueryFunc) QueryFunc {
+		return func(expr Expr, configs ...QueryConfig) (Value, error) {
+			return next(Do(expr, "rewritten"), configs...)
+		}
+	})
+
+	value, err := client.Query(NewId())
+	require.NoError(t, err)
+	require.Equal(t, StringV("rewritten"), value)
+	require.JSONEq(t, `{"do":[{"new_id":null},"rewritten"]}`, string(body))
+}
+
+func TestMiddlewareSeesQueryErrors(t *testing.T) {
+	server, client := newTestServer(respondWith(404, `{"errors": []}`))
+	defer server.Close()
+
+	var seen error
+
+	client.Use(func(next QueryFunc) QueryFunc {
+		return func(expr Expr, configs ...QueryConfig) (Value, error) {
+			value, err := next(expr, configs...)
+			seen = err
+			return value, err
+		}
+	})
+
+	_, err := client.Query(Get(Ref("collections/spells/42")))
+	require.Error(t, err)
+	require.IsType(t, NotFound{}, seen)
+}
+
+func TestMiddlewaresApplyToBatchQuery(t *testing.T) {
+	client := NewFaunaClient("secret")
+	client.Use(shortCircuit(ArrayV{LongV(1), LongV(2)}, nil))
+
+	values, err := client.BatchQuery([]Expr{NewId(), NewId()})
+	require.NoError(t, err)
+	require.Equal(t, []Value{LongV(1), LongV(2)}, values)
+}
+
+func TestSessionClientInheritsMiddlewares(t *testing.T) {
+	parent := NewFaunaClient("secret")
+	parent.Use(shortCircuit(StringV("parent"), nil))
+
+	session := parent.NewSessionClient("other-secret")
+	session.Use(shortCircuit(StringV("session"), nil))
+	parent.Use(shortCircuit(StringV("unreachable"), nil))
+
+	value, err := session.Query(NewId())
+	require.NoError(t, err)
+	require.Equal(t, StringV("parent"), value)
+	require.Len(t, session.middlewares, 2)
+	require.Len(t, parent.middlewares, 2)
+}
+
+func shortCircuit(value Value, err error) Middleware {
+	return func(next QueryFunc) QueryFunc {
+		return func(expr Expr, configs ...QueryConfig) (Value, error) {
+			return value, err
+		}
+	}
+}
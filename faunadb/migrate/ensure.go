@@ -0,0 +1,92 @@
+package migrate
+
+import f "github.com/fauna/faunadb-go/faunadb"
+
+// Kind is the kind of a schema document.
+type Kind string
+
+// Kinds of schema documents supported by Ensure and Drop.
+const (
+	Collection Kind = "collection"
+	Index      Kind = "index"
+	Role       Kind = "role"
+	Function   Kind = "function"
+	Database   Kind = "database"
+)
+
+// Ref returns the expression referencing the schema document of this kind with the given name.
+func (kind Kind) Ref(name string) f.Expr {
+	switch kind {
+	case Collection:
+		return f.Collection(name)
+	case Index:
+		return f.Index(name)
+	case Role:
+		return f.Role(name)
+	case Function:
+		return f.Function(name)
+	case Database:
+		return f.Database(name)
+	default:
+		return f.Abort("unknown schema document kind: " + string(kind))
+	}
+}
+
+func (kind Kind) valid() bool {
+	switch kind {
+	case Collection, Index, Role, Function, Database:
+		return true
+	default:
+		return false
+	}
+}
+
+func (kind Kind) create(params interface{}) f.Expr {
+	switch kind {
+	case Collection:
+		return f.CreateCollection(params)
+	case Index:
+		return f.CreateIndex(params)
+	case Role:
+		return f.CreateRole(params)
+	case Function:
+		return f.CreateFunction(params)
+	case Database:
+		return f.CreateDatabase(params)
+	default:
+		return f.Abort("unknown schema document kind: " + string(kind))
+	}
+}
+
+/*
+Ensure returns an idempotent expression that creates the schema document of the given kind and name
+with the given parameters, or updates it if it already exists. The name is added to the parameters,
+which can be nil. For example:
+
+	migrate.Ensure(migrate.Index, "users_by_email", f.Obj{
+		"source": f.Collection("users"),
+		"terms":  f.Arr{f.Obj{"field": f.Arr{"data", "email"}}},
+		"unique": true,
+	})
+
+Since migration steps can be retried after a failure, using Ensure rather than the Create functions
+makes migrations safe to run again. Note that FaunaDB rejects updates changing the source, terms or
+values of an existing index.
+*/
+func Ensure(kind Kind, name string, params interface{}) f.Expr {
+	var withName interface{} = f.Obj{"name": name}
+
+	if params != nil {
+		withName = f.Merge(params, withName)
+	}
+
+	ref := kind.Ref(name)
+	return f.If(f.Exists(ref), f.Update(ref, withName), kind.create(withName))
+}
+
+// Drop returns an idempotent expression that deletes the schema document of the given kind and name,
+// if it exists.
+func Drop(kind Kind, name string) f.Expr {
+	ref := kind.Ref(name)
+	return f.If(f.Exists(ref), f.Delete(ref), nil)
+}
@@ -0,0 +1,162 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.json$`)
+
+type migrationJSON struct {
+	Up   []json.RawMessage  `json:"up"`
+	Down *[]json.RawMessage `json:"down"`
+}
+
+type directiveJSON struct {
+	Ensure Kind            `json:"ensure"`
+	Drop   Kind            `json:"drop"`
+	Name   string          `json:"name"`
+	Params json.RawMessage `json:"params"`
+}
+
+/*
+LoadDir loads the migrations defined by the JSON files of a directory. Files are named after the
+migration's version and name, as in "0001_create_users.json", and contain its up and down steps:
+
+	{
+		"up": [
+			{"ensure": "collection", "name": "users", "params": {"history_days": 30}},
+			{"create": {"collection": "users"}, "params": {"object": {"data": {"object": {"name": "admin"}}}}}
+		],
+		"down": [
+			{"drop": "collection", "name": "users"}
+		]
+	}
+
+Steps are either expressions in FaunaDB's JSON wire format, or directives calling Ensure or Drop for a
+schema document. The parameters of an "ensure" directive are a FaunaDB value rather than an expression,
+so objects don't need to be escaped. Omitting "down" makes the migration irreversible.
+*/
+func LoadDir(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+
+	for _, file := range files {
+		match := migrationFile.FindStringSubmatch(file.Name())
+		if file.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		path := filepath.Join(dir, file.Name())
+
+		migration, err := loadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: %s: %s", path, err)
+		}
+
+		migration.Version = version
+		migration.Name = match[2]
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func loadFile(path string) (migration Migration, err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var decoded migrationJSON
+
+	if err = json.Unmarshal(content, &decoded); err != nil {
+		return
+	}
+
+	if migration.Up, err = parseSteps(decoded.Up); err != nil {
+		return
+	}
+
+	if decoded.Down != nil {
+		if migration.Down, err = parseSteps(*decoded.Down); err != nil {
+			return
+		}
+
+		if migration.Down == nil {
+			migration.Down = []f.Expr{}
+		}
+	}
+
+	return
+}
+
+func parseSteps(steps []json.RawMessage) (exprs []f.Expr, err error) {
+	for _, step := range steps {
+		var expr f.Expr
+
+		if expr, err = parseStep(step); err != nil {
+			return
+		}
+
+		exprs = append(exprs, expr)
+	}
+
+	return
+}
+
+func parseStep(step json.RawMessage) (expr f.Expr, err error) {
+	var directive directiveJSON
+
+	if json.Unmarshal(step, &directive) == nil && (directive.Ensure != "" || directive.Drop != "") {
+		return parseDirective(directive)
+	}
+
+	err = f.UnmarshalJSONExpr(step, &expr)
+	return
+}
+
+func parseDirective(directive directiveJSON) (f.Expr, error) {
+	if directive.Name == "" {
+		return nil, fmt.Errorf("missing name in directive")
+	}
+
+	if directive.Drop != "" {
+		if !directive.Drop.valid() {
+			return nil, fmt.Errorf("unknown schema document kind %q", directive.Drop)
+		}
+
+		return Drop(directive.Drop, directive.Name), nil
+	}
+
+	if !directive.Ensure.valid() {
+		return nil, fmt.Errorf("unknown schema document kind %q", directive.Ensure)
+	}
+
+	var params f.Value
+
+	if len(directive.Params) > 0 {
+		if err := f.UnmarshalJSON(directive.Params, &params); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, isNull := params.(f.NullV); params == nil || isNull {
+		return Ensure(directive.Ensure, directive.Name, nil), nil
+	}
+
+	return Ensure(directive.Ensure, directive.Name, params), nil
+}
@@ -0,0 +1,81 @@
+package migrate
+
+import (
+	"fmt"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+const lockedCode = "migration_locked"
+
+// LockedError is returned by Migrate when another runner holds the lock.
+type LockedError struct {
+	Owner string // Owner of the lock, empty if it was taken concurrently
+}
+
+func (err LockedError) Error() string {
+	if err.Owner == "" {
+		return "migrate: locked by a concurrent runner"
+	}
+
+	return fmt.Sprintf("migrate: locked by %s", err.Owner)
+}
+
+func (m *Migrator) lockCollection() string { return m.collection + "_lock" }
+
+func (m *Migrator) lockRef() f.Expr { return f.Ref(f.Collection(m.lockCollection()), "1") }
+
+// lock acquires the lock, or refreshes it if it's already held by this runner. Expired locks are
+// taken over.
+func (m *Migrator) lock() error {
+	ref := m.lockRef()
+	owner := f.Select("owner", f.Var("lock"))
+
+	data := f.Obj{"data": f.Obj{
+		"owner":   m.owner,
+		"expires": f.TimeAdd(f.Now(), int64(m.lockTTL.Seconds()), f.TimeUnitSecond),
+	}}
+
+	_, err := m.client.Query(
+		f.If(f.Exists(ref),
+			f.Let().Bind("lock", f.Select("data", f.Get(ref))).In(
+				f.If(f.Or(f.Equals(owner, m.owner), f.LT(f.Select("expires", f.Var("lock")), f.Now())),
+					f.Replace(ref, data),
+					f.AbortWith(f.Obj{"code": lockedCode, "owner": owner}),
+				),
+			),
+			f.Create(ref, data),
+		),
+	)
+
+	if payload, ok := f.AbortPayload(err); ok {
+		var locked struct {
+			Code  string `fauna:"code"`
+			Owner string `fauna:"owner"`
+		}
+
+		if payload.Get(&locked) == nil && locked.Code == lockedCode {
+			return LockedError{locked.Owner}
+		}
+	}
+
+	if f.IsContention(err) {
+		return LockedError{}
+	}
+
+	return err
+}
+
+// unlock releases the lock if it's held by this runner.
+func (m *Migrator) unlock() error {
+	ref := m.lockRef()
+
+	_, err := m.client.Query(
+		f.If(f.Exists(ref),
+			f.If(f.Equals(f.Select(f.Arr{"data", "owner"}, f.Get(ref)), m.owner), f.Delete(ref), nil),
+			nil,
+		),
+	)
+
+	return err
+}
@@ -0,0 +1,400 @@
+/*
+Package migrate implements versioned schema migrations for FaunaDB.
+
+A migration is made of up steps, evaluated in order when applying it, and down steps, evaluated in
+order when reverting it. Applied migrations are recorded in a bookkeeping collection, so running the
+migrations again only applies the ones that are missing. For example:
+
+	migrations := []migrate.Migration{
+		{
+			Version: 1,
+			Name:    "create_users",
+			Up:      []f.Expr{migrate.Ensure(migrate.Collection, "users", nil)},
+			Down:    []f.Expr{migrate.Drop(migrate.Collection, "users")},
+		},
+		{
+			Version: 2,
+			Name:    "index_users_by_email",
+			Up: []f.Expr{migrate.Ensure(migrate.Index, "users_by_email", f.Obj{
+				"source": f.Collection("users"),
+				"terms":  f.Arr{f.Obj{"field": f.Arr{"data", "email"}}},
+			})},
+			Down: []f.Expr{migrate.Drop(migrate.Index, "users_by_email")},
+		},
+	}
+
+	migrator, err := migrate.New(client, migrations)
+	if err != nil {
+		panic(err)
+	}
+
+	plan, err := migrator.Migrate(migrate.Latest)
+
+Each step is evaluated in its own query, since FaunaDB doesn't allow using a schema document in the
+same transaction that creates it. A migration is recorded along with its last step: if a step fails,
+the migration is not recorded and all of its steps are evaluated again on the next run. Steps should
+therefore be idempotent, which is what Ensure and Drop provide for schema documents.
+
+Concurrent runners are serialized with a lock document: Migrate returns a LockedError while another
+runner holds the lock.
+*/
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+// Latest is the target version that applies all migrations.
+const Latest int64 = math.MaxInt64
+
+const (
+	defaultCollection = "migrations"
+	defaultLockTTL    = 5 * time.Minute
+	pageSize          = 100
+)
+
+var (
+	dataField  = f.ObjKey("data")
+	afterField = f.ObjKey("after")
+)
+
+// Migration is a versioned set of changes to a database. Down is nil for irreversible migrations; use
+// an empty slice for migrations that can be reverted without evaluating any step.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []f.Expr
+	Down    []f.Expr
+}
+
+// Record describes an applied migration, as stored in the bookkeeping collection.
+type Record struct {
+	Version   int64     `fauna:"version"`
+	Name      string    `fauna:"name"`
+	AppliedAt time.Time `fauna:"applied_at"`
+}
+
+// Direction tells whether a migration is being applied or reverted.
+type Direction string
+
+// Migration directions.
+const (
+	Up   Direction = "up"
+	Down Direction = "down"
+)
+
+// Step is a migration to apply or revert.
+type Step struct {
+	Migration Migration
+	Direction Direction
+}
+
+// Exprs returns the expressions evaluated by the step.
+func (step Step) Exprs() []f.Expr {
+	if step.Direction == Down {
+		return step.Migration.Down
+	}
+
+	return step.Migration.Up
+}
+
+// Plan is the ordered list of steps needed to reach a target version.
+type Plan []Step
+
+// String describes the plan along with the expressions of each step in the query language syntax.
+func (plan Plan) String() string {
+	var buffer bytes.Buffer
+
+	for _, step := range plan {
+		fmt.Fprintf(&buffer, "%s %d %s\n", step.Direction, step.Migration.Version, step.Migration.Name)
+
+		for _, expr := range step.Exprs() {
+			fmt.Fprintf(&buffer, "\t%s\n", f.FQL(expr))
+		}
+	}
+
+	return buffer.String()
+}
+
+// StepError is returned when evaluating one of the expressions of a step fails.
+type StepError struct {
+	Step  Step
+	Index int // Index of the failing expression in the step's expressions
+	Err   error
+}
+
+func (err StepError) Error() string {
+	return fmt.Sprintf("migrate: %s %d %s, step %d: %s",
+		err.Step.Direction, err.Step.Migration.Version, err.Step.Migration.Name, err.Index, err.Err)
+}
+
+// Unwrap returns the error returned by FaunaDB.
+func (err StepError) Unwrap() error { return err.Err }
+
+// Option configures a Migrator.
+type Option func(*Migrator)
+
+// BookkeepingCollection sets the name of the collection recording applied migrations. The lock
+// document is stored in a collection with the same name suffixed by "_lock". Defaults to "migrations".
+func BookkeepingCollection(name string) Option {
+	return func(m *Migrator) { m.collection = name }
+}
+
+// LockTTL sets how long the lock is held without activity before other runners can take it over.
+// The lock is refreshed before every migration. Defaults to 5 minutes.
+func LockTTL(ttl time.Duration) Option {
+	return func(m *Migrator) { m.lockTTL = ttl }
+}
+
+// Owner sets the name identifying this runner in the lock document. Defaults to the host name
+// followed by the process id.
+func Owner(owner string) Option {
+	return func(m *Migrator) { m.owner = owner }
+}
+
+// Migrator applies and reverts migrations.
+type Migrator struct {
+	client     f.Querier
+	migrations map[int64]Migration
+	collection string
+	lockTTL    time.Duration
+	owner      string
+}
+
+// New creates a Migrator for the given migrations. Versions must be positive and unique.
+func New(client f.Querier, migrations []Migration, options ...Option) (*Migrator, error) {
+	host, _ := os.Hostname()
+
+	m := &Migrator{
+		client:     client,
+		migrations: make(map[int64]Migration, len(migrations)),
+		collection: defaultCollection,
+		lockTTL:    defaultLockTTL,
+		owner:      fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+
+	for _, option := range options {
+		option(m)
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("migrate: invalid version %d for migration %s", migration.Version, migration.Name)
+		}
+
+		if _, duplicated := m.migrations[migration.Version]; duplicated {
+			return nil, fmt.Errorf("migrate: duplicated version %d", migration.Version)
+		}
+
+		m.migrations[migration.Version] = migration
+	}
+
+	return m, nil
+}
+
+func (m *Migrator) recordRef(version int64) f.Expr {
+	return f.Ref(f.Collection(m.collection), strconv.FormatInt(version, 10))
+}
+
+// Applied returns the applied migrations, ordered by version. It returns no migrations if the
+// bookkeeping collection doesn't exist yet.
+func (m *Migrator) Applied() (records []Record, err error) {
+	collection := f.Collection(m.collection)
+	options := []f.OptionalParameter{f.Size(pageSize)}
+
+	for {
+		var res f.Value
+
+		res, err = m.client.Query(f.If(f.Exists(collection),
+			f.Map(
+				f.Paginate(f.Documents(collection), options...),
+				f.Lambda("ref", f.Select("data", f.Get(f.Var("ref")))),
+			),
+			f.Obj{"data": f.Arr{}},
+		))
+		if err != nil {
+			return
+		}
+
+		var page []Record
+
+		if err = res.At(dataField).Get(&page); err != nil {
+			return
+		}
+
+		records = append(records, page...)
+
+		after, afterErr := res.At(afterField).GetValue()
+		if afterErr != nil {
+			break
+		}
+
+		options = []f.OptionalParameter{f.Size(pageSize), f.After(after)}
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Version < records[j].Version })
+	return
+}
+
+// Pending returns the migrations not applied yet, ordered by version.
+func (m *Migrator) Pending() ([]Migration, error) {
+	plan, err := m.Plan(Latest)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Migration, 0, len(plan))
+
+	for _, step := range plan {
+		if step.Direction == Up {
+			pending = append(pending, step.Migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Plan returns the steps Migrate would run to reach the target version, without running them. Use
+// Latest as the target to apply all migrations, or 0 to revert all of them.
+func (m *Migrator) Plan(target int64) (Plan, error) {
+	applied, err := m.Applied()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.plan(applied, target)
+}
+
+// plan reverts the applied migrations above the target, newest first, then applies the missing
+// migrations up to the target, oldest first.
+func (m *Migrator) plan(applied []Record, target int64) (plan Plan, err error) {
+	isApplied := make(map[int64]bool, len(applied))
+
+	for i := len(applied) - 1; i >= 0; i-- {
+		record := applied[i]
+		isApplied[record.Version] = true
+
+		if record.Version <= target {
+			continue
+		}
+
+		migration, known := m.migrations[record.Version]
+		if !known {
+			return nil, fmt.Errorf("migrate: applied migration %d %s is unknown", record.Version, record.Name)
+		}
+
+		if migration.Down == nil {
+			return nil, fmt.Errorf("migrate: migration %d %s is irreversible", migration.Version, migration.Name)
+		}
+
+		plan = append(plan, Step{migration, Down})
+	}
+
+	versions := make([]int64, 0, len(m.migrations))
+
+	for version := range m.migrations {
+		if version <= target && !isApplied[version] {
+			versions = append(versions, version)
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	for _, version := range versions {
+		plan = append(plan, Step{m.migrations[version], Up})
+	}
+
+	return
+}
+
+/*
+Migrate applies or reverts migrations until the target version is reached, and returns the steps
+that were run. Use Latest as the target to apply all migrations, or 0 to revert all of them.
+
+The bookkeeping collections are created if needed, and the lock is held for the whole run. If a
+step fails, Migrate stops and returns the steps completed so far along with a StepError.
+*/
+func (m *Migrator) Migrate(target int64) (done Plan, err error) {
+	if err = m.init(); err != nil {
+		return
+	}
+
+	if err = m.lock(); err != nil {
+		return
+	}
+
+	defer func() {
+		if unlockErr := m.unlock(); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	plan, err := m.Plan(target)
+	if err != nil {
+		return
+	}
+
+	for _, step := range plan {
+		if err = m.lock(); err != nil {
+			return
+		}
+
+		if err = m.run(step); err != nil {
+			return
+		}
+
+		done = append(done, step)
+	}
+
+	return
+}
+
+func (m *Migrator) init() error {
+	ensure := func(name string) f.Expr {
+		return f.If(f.Exists(f.Collection(name)), nil, f.CreateCollection(f.Obj{"name": name}))
+	}
+
+	_, err := m.client.Query(f.Do(ensure(m.collection), ensure(m.lockCollection())))
+	return err
+}
+
+func (m *Migrator) run(step Step) error {
+	var record f.Expr
+
+	if step.Direction == Up {
+		record = f.Create(m.recordRef(step.Migration.Version), f.Obj{"data": f.Obj{
+			"version":    step.Migration.Version,
+			"name":       step.Migration.Name,
+			"applied_at": f.Now(),
+		}})
+	} else {
+		record = f.Delete(m.recordRef(step.Migration.Version))
+	}
+
+	exprs := step.Exprs()
+
+	if len(exprs) == 0 {
+		_, err := m.client.Query(record)
+		return err
+	}
+
+	for i, expr := range exprs {
+		if i == len(exprs)-1 {
+			expr = f.Do(expr, record)
+		}
+
+		if _, err := m.client.Query(expr); err != nil {
+			return StepError{step, i, err}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,254 @@
+package migrate
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/stretchr/testify/require"
+)
+
+func newMigrator(t *testing.T, client *f.FaunaClient, migrations ...Migration) *Migrator {
+	migrator, err := New(client, migrations, Owner("test"))
+	require.NoError(t, err)
+	return migrator
+}
+
+func testMigrations() []Migration {
+	return []Migration{
+		{Version: 1, Name: "one", Up: []f.Expr{Ensure(Collection, "one", nil)}, Down: []f.Expr{Drop(Collection, "one")}},
+		{Version: 2, Name: "two", Up: []f.Expr{Ensure(Collection, "two", nil)}, Down: []f.Expr{}},
+		{Version: 3, Name: "three", Up: []f.Expr{Ensure(Collection, "three", nil)}, Down: []f.Expr{Drop(Collection, "three")}},
+		{Version: 4, Name: "four", Up: []f.Expr{Ensure(Collection, "four", nil)}},
+	}
+}
+
+func planOf(plan Plan) (steps []string) {
+	for _, step := range plan {
+		steps = append(steps, string(step.Direction)+" "+step.Migration.Name)
+	}
+
+	return
+}
+
+func TestPlan(t *testing.T) {
+	migrator := newMigrator(t, nil, testMigrations()...)
+	applied := []Record{{Version: 1, Name: "one"}, {Version: 3, Name: "three"}}
+
+	plan, err := migrator.plan(applied, Latest)
+	require.NoError(t, err)
+	require.Equal(t, []string{"up two", "up four"}, planOf(plan))
+
+	plan, err = migrator.plan(applied, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"down three", "up two"}, planOf(plan))
+
+	plan, err = migrator.plan(applied, 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"down three", "down one"}, planOf(plan))
+
+	plan, err = migrator.plan(applied, 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{"up two"}, planOf(plan))
+}
+
+func TestPlanFailures(t *testing.T) {
+	migrator := newMigrator(t, nil, testMigrations()...)
+
+	_, err := migrator.plan([]Record{{Version: 4, Name: "four"}}, 3)
+	require.EqualError(t, err, "migrate: migration 4 four is irreversible")
+
+	_, err = migrator.plan([]Record{{Version: 5, Name: "five"}}, 3)
+	require.EqualError(t, err, "migrate: applied migration 5 five is unknown")
+}
+
+func TestNewValidatesVersions(t *testing.T) {
+	_, err := New(nil, []Migration{{Version: 0, Name: "zero"}})
+	require.EqualError(t, err, "migrate: invalid version 0 for migration zero")
+
+	_, err = New(nil, []Migration{{Version: 1, Name: "a"}, {Version: 1, Name: "b"}})
+	require.EqualError(t, err, "migrate: duplicated version 1")
+}
+
+func TestPlanString(t *testing.T) {
+	plan := Plan{{testMigrations()[0], Up}, {testMigrations()[1], Down}}
+
+	require.Equal(t,
+		"up 1 one\n"+
+			"\tIf(Exists(Collection(\"one\")), Update(Collection(\"one\"), { name: \"one\" }), CreateCollection({ name: \"one\" }))\n"+
+			"down 2 two\n",
+		plan.String(),
+	)
+}
+
+func assertJSON(t *testing.T, expr f.Expr, expected string) {
+	bytes, err := json.Marshal(expr)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(bytes))
+}
+
+func TestEnsure(t *testing.T) {
+	assertJSON(t,
+		Ensure(Index, "by_name", f.Obj{"source": f.Collection("users")}),
+		`{"else":{"create_index":{"merge":{"object":{"source":{"collection":"users"}}},"with":{"object":{"name":"by_name"}}}},`+
+			`"if":{"exists":{"index":"by_name"}},`+
+			`"then":{"params":{"merge":{"object":{"source":{"collection":"users"}}},"with":{"object":{"name":"by_name"}}},"update":{"index":"by_name"}}}`,
+	)
+}
+
+func TestDrop(t *testing.T) {
+	assertJSON(t,
+		Drop(Role, "admin"),
+		`{"else":null,"if":{"exists":{"role":"admin"}},"then":{"delete":{"role":"admin"}}}`,
+	)
+}
+
+func TestLoadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrations")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	write("0002_add_index.json", `{
+		"up": [{"ensure": "index", "name": "by_name", "params": {"source": {"@ref": {"id": "users", "collection": {"@ref": {"id": "collections"}}}}}}]
+	}`)
+	write("0001_create_users.json", `{
+		"up": [{"ensure": "collection", "name": "users"}, {"create": {"collection": "users"}, "params": {"object": {}}}],
+		"down": [{"drop": "collection", "name": "users"}]
+	}`)
+	write("README.md", "ignored")
+
+	migrations, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+
+	require.Equal(t, int64(1), migrations[0].Version)
+	require.Equal(t, "create_users", migrations[0].Name)
+	require.Len(t, migrations[0].Up, 2)
+	require.Equal(t, Ensure(Collection, "users", nil), migrations[0].Up[0])
+	require.Equal(t, `Create(Collection("users"), {})`, f.FQL(migrations[0].Up[1]))
+	require.Equal(t, []f.Expr{Drop(Collection, "users")}, migrations[0].Down)
+
+	require.Equal(t, int64(2), migrations[1].Version)
+	require.Equal(t,
+		`If(Exists(Index("by_name")), Update(Index("by_name"), Merge({ source: Collection("users") }, { name: "by_name" })), `+
+			`CreateIndex(Merge({ source: Collection("users") }, { name: "by_name" })))`,
+		f.FQL(migrations[1].Up[0]),
+	)
+	require.Nil(t, migrations[1].Down)
+}
+
+func TestLoadDirFailures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrations")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "1_bad.json"), []byte(`{"up": [{"ensure": "table", "name": "x"}]}`), 0600))
+
+	_, err = LoadDir(dir)
+	require.EqualError(t, err, `migrate: `+filepath.Join(dir, "1_bad.json")+`: unknown schema document kind "table"`)
+}
+
+const okResponse = `{"resource":null}`
+
+type fakeServer struct {
+	sync.Mutex
+	queries   []string
+	responses []string
+}
+
+func (server *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.Lock()
+	defer server.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	server.queries = append(server.queries, string(body))
+
+	response := `{"resource":{"data":[]}}`
+
+	if len(server.responses) > 0 {
+		response, server.responses = server.responses[0], server.responses[1:]
+	}
+
+	if strings.Contains(response, "errors") {
+		w.WriteHeader(400)
+	}
+
+	_, _ = io.WriteString(w, response)
+}
+
+func newFakeServer(responses ...string) (*httptest.Server, *fakeServer, *f.FaunaClient) {
+	fake := &fakeServer{responses: responses}
+	server := httptest.NewServer(fake)
+	return server, fake, f.NewFaunaClient("secret", f.Endpoint(server.URL))
+}
+
+func TestMigrate(t *testing.T) {
+	server, fake, client := newFakeServer()
+	defer server.Close()
+
+	migrations := testMigrations()[:2]
+	migrations[1].Up = append(migrations[1].Up, f.Add(1, 2))
+
+	done, err := newMigrator(t, client, migrations...).Migrate(Latest)
+	require.NoError(t, err)
+	require.Equal(t, []string{"up one", "up two"}, planOf(done))
+
+	// init, lock, applied, lock, one, lock, two (2 steps), unlock
+	require.Len(t, fake.queries, 9)
+	require.Contains(t, fake.queries[0], `"create_collection"`)
+	require.Contains(t, fake.queries[1], `"owner":"test"`)
+	require.Contains(t, fake.queries[4], `"do":[{"else":{"create_collection"`)
+	require.Contains(t, fake.queries[4], `"create":{"id":"1","ref":{"collection":"migrations"}}`)
+	require.NotContains(t, fake.queries[6], `"do"`)
+	require.Contains(t, fake.queries[7], `"do":[{"add":[1,2]}`)
+	require.Contains(t, fake.queries[7], `"create":{"id":"2","ref":{"collection":"migrations"}}`)
+	require.Contains(t, fake.queries[8], `"delete":{"id":"1","ref":{"collection":"migrations_lock"}}`)
+}
+
+func TestMigrateStepFailure(t *testing.T) {
+	failure := `{"errors":[{"position":["do",0],"code":"invalid argument","description":"Bad step."}]}`
+	server, fake, client := newFakeServer(okResponse, okResponse, `{"resource":{"data":[]}}`, okResponse, failure)
+	defer server.Close()
+
+	done, err := newMigrator(t, client, testMigrations()[:2]...).Migrate(Latest)
+	require.Empty(t, done)
+
+	stepErr, ok := err.(StepError)
+	require.True(t, ok)
+	require.Equal(t, int64(1), stepErr.Step.Migration.Version)
+	require.True(t, f.HasErrorCode(err, f.ErrInvalidArgument))
+	require.Contains(t, fake.queries[len(fake.queries)-1], `"migrations_lock"`)
+}
+
+func TestMigrateLocked(t *testing.T) {
+	locked := `{"errors":[{"position":[],"code":"transaction aborted","description":"faunadb-payload:{\"code\":\"migration_locked\",\"owner\":\"other\"}"}]}`
+	server, fake, client := newFakeServer(okResponse, locked)
+	defer server.Close()
+
+	done, err := newMigrator(t, client, testMigrations()...).Migrate(Latest)
+	require.Empty(t, done)
+	require.Equal(t, LockedError{"other"}, err)
+	require.Len(t, fake.queries, 2)
+}
+
+func TestMigrateContended(t *testing.T) {
+	contended := `{"errors":[{"position":[],"code":"contended transaction","description":"Transaction was aborted due to detection of concurrent modification."}]}`
+	server, _, client := newFakeServer(okResponse, contended)
+	defer server.Close()
+
+	_, err := newMigrator(t, client, testMigrations()...).Migrate(Latest)
+	require.Equal(t, LockedError{}, err)
+	require.EqualError(t, err, "migrate: locked by a concurrent runner")
+}
@@ -0,0 +1,150 @@
+package faunadb
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+/*
+UpdateParams returns the fields to update to turn the old data of a document into the new data, to
+be given to Update. The data can be structs, maps or ObjectVs, encoded with the same field names as
+in queries. Fields missing from the new data, such as keys deleted from a map, are set to null so
+that Update removes them. Nested objects are diffed recursively and included only when some of their
+fields changed, while other values, including arrays, are included as a whole when they changed.
+Data that are not objects, such as nil, are treated as empty objects. For example:
+
+	old := user
+	user.Name = "John"
+
+	client.Query(Update(ref, Obj{"data": UpdateParams(old, user)}))
+	// same as Update(ref, Obj{"data": Obj{"name": "John"}})
+
+The result is empty when nothing changed.
+*/
+func UpdateParams(old, new interface{}) Obj {
+	oldFields, _ := objectFields(wrap(old))
+	newFields, _ := objectFields(wrap(new))
+
+	return diffFields(oldFields, newFields)
+}
+
+func diffFields(old, new map[string]Expr) Obj {
+	params := Obj{}
+
+	for key := range old {
+		if _, ok := new[key]; !ok {
+			params[key] = nil
+		}
+	}
+
+	for key, value := range new {
+		previous, ok := old[key]
+
+		if !ok {
+			params[key] = plainExpr(value)
+			continue
+		}
+
+		previousFields, wasObject := objectFields(previous)
+		fields, isObject := objectFields(value)
+
+		if wasObject && isObject {
+			if nested := diffFields(previousFields, fields); len(nested) > 0 {
+				params[key] = nested
+			}
+		} else if !equalExprs(previous, value) {
+			params[key] = plainExpr(value)
+		}
+	}
+
+	return params
+}
+
+// objectFields returns the fields of an encoded object, either an ObjectV or an object literal.
+func objectFields(expr Expr) (map[string]Expr, bool) {
+	switch e := expr.(type) {
+	case ObjectV:
+		fields := make(map[string]Expr, len(e))
+
+		for key, value := range e {
+			fields[key] = value
+		}
+
+		return fields, true
+
+	case unescapedObj:
+		if inner, ok := e["object"].(unescapedObj); ok && len(e) == 1 {
+			return inner, true
+		}
+	}
+
+	return nil, false
+}
+
+// plainExpr converts encoded objects and arrays back to values, or to Obj and Arr when they hold
+// other expressions, so that the result of UpdateParams can be inspected.
+func plainExpr(expr Expr) Expr {
+	if fields, ok := objectFields(expr); ok {
+		if _, isValue := expr.(ObjectV); isValue {
+			return expr
+		}
+
+		obj := make(ObjectV, len(fields))
+		plain := make(Obj, len(fields))
+
+		for key, field := range fields {
+			plain[key] = plainExpr(field)
+
+			if value, ok := plain[key].(Value); ok && obj != nil {
+				obj[key] = value
+			} else {
+				obj = nil
+			}
+		}
+
+		if obj != nil {
+			return obj
+		}
+
+		return plain
+	}
+
+	if arr, ok := expr.(unescapedArr); ok {
+		values := make(ArrayV, len(arr))
+		plain := make(Arr, len(arr))
+
+		for i, elem := range arr {
+			plain[i] = plainExpr(elem)
+
+			if value, ok := plain[i].(Value); ok && values != nil {
+				values[i] = value
+			} else {
+				values = nil
+			}
+		}
+
+		if values != nil {
+			return values
+		}
+
+		return plain
+	}
+
+	return expr
+}
+
+func equalExprs(a, b Expr) bool {
+	a, b = plainExpr(a), plainExpr(b)
+
+	x, aIsValue := a.(Value)
+	y, bIsValue := b.(Value)
+
+	if aIsValue && bIsValue {
+		return Equal(x, y)
+	}
+
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+
+	return errA == nil && errB == nil && bytes.Equal(canonicalJSON(rawA), canonicalJSON(rawB))
+}
@@ -0,0 +1,73 @@
+package faunadb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type patchAddress struct {
+	City   string `fauna:"city"`
+	Street string `fauna:"street"`
+}
+
+type patchUser struct {
+	Name    string       `fauna:"name"`
+	Age     int          `fauna:"age"`
+	Tags    []string     `fauna:"tags"`
+	Address patchAddress `fauna:"address"`
+	Manager *RefV        `fauna:"manager"`
+	Ignored string       `fauna:"-"`
+}
+
+func TestUpdateParamsFromStructs(t *testing.T) {
+	old := patchUser{
+		Name:    "Jane",
+		Age:     42,
+		Tags:    []string{"a", "b"},
+		Address: patchAddress{City: "Paris", Street: "Rivoli"},
+		Manager: &RefV{"1", &RefV{"users", NativeCollections(), nil, nil}, nil, nil},
+	}
+
+	require.Equal(t, Obj{}, UpdateParams(old, old))
+
+	changed := old
+	changed.Name = "John"
+	changed.Tags = []string{"a"}
+	changed.Address.City = "Lyon"
+	changed.Manager = nil
+	changed.Ignored = "ignored"
+
+	params := UpdateParams(old, changed)
+	require.Equal(t, Obj{
+		"name":    StringV("John"),
+		"tags":    ArrayV{StringV("a")},
+		"address": Obj{"city": StringV("Lyon")},
+		"manager": NullV{},
+	}, params)
+
+	assertJSON(t, Update(RefCollection(Collection("users"), "42"), Obj{"data": params}),
+		`{"params":{"object":{"data":{"object":{"address":{"object":{"city":"Lyon"}},"manager":null,"name":"John","tags":["a"]}}}},`+
+			`"update":{"id":"42","ref":{"collection":"users"}}}`)
+}
+
+func TestUpdateParamsFromObjects(t *testing.T) {
+	old := decodedValue(t, `{"name":"Jane","address":{"city":"Paris","zip":"75001"},"tags":["a"]}`)
+
+	params := UpdateParams(old, map[string]interface{}{
+		"name":    "Jane",
+		"address": map[string]interface{}{"city": "Paris"},
+		"tags":    "none",
+		"ts":      Time("now"),
+	})
+
+	require.Equal(t, Obj{
+		"address": Obj{"zip": nil},
+		"tags":    StringV("none"),
+		"ts":      Time("now"),
+	}, params)
+
+	require.Equal(t, Obj{"name": StringV("Jane")}, UpdateParams(nil, ObjectV{"name": StringV("Jane")}))
+	require.Equal(t, Obj{"name": nil}, UpdateParams(ObjectV{"name": StringV("Jane")}, nil))
+	require.Equal(t, Obj{}, UpdateParams(Obj{"ts": Time("now")}, Obj{"ts": Time("now")}))
+}
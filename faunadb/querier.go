@@ -0,0 +1,27 @@
+package faunadb
+
+/*
+Querier is the interface of FaunaClient used to issue queries. Code depending on a Querier, rather
+than on a *FaunaClient, can be tested without a FaunaDB endpoint by giving it another implementation,
+such as the one of the faunadbmock package. For example:
+
+	type UserService struct {
+		db f.Querier
+	}
+
+	service := UserService{db: f.NewFaunaClient(secret)}
+*/
+type Querier interface {
+	Query(expr Expr, configs ...QueryConfig) (Value, error)
+	QueryResult(expr Expr) (Value, map[string][]string, error)
+	QueryResultWithConfig(expr Expr, configs ...QueryConfig) (Value, map[string][]string, error)
+	BatchQuery(exprs []Expr) ([]Value, error)
+	BatchQueryWithConfig(exprs []Expr, configs ...QueryConfig) ([]Value, error)
+	BatchQueryResult(exprs []Expr) ([]Value, map[string][]string, error)
+	BatchQueryResultWithConfig(exprs []Expr, configs ...QueryConfig) ([]Value, map[string][]string, error)
+	QueryAt(ts int64, expr Expr, configs ...QueryConfig) (Value, error)
+	History(ref interface{}, from, to int64, configs ...QueryConfig) *EventIterator
+	SetEvents(set interface{}, options ...OptionalParameter) *EventIterator
+}
+
+var _ Querier = (*FaunaClient)(nil)
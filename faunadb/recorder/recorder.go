@@ -0,0 +1,299 @@
+/*
+Package recorder records the HTTP exchanges of a FaunaClient to a cassette file, and replays them
+without a FaunaDB cluster, so tests issuing queries can run deterministically and offline.
+
+A Recorder is an http.RoundTripper, given to the client through an http.Client. For example:
+
+	rec, err := recorder.New("testdata/users.json", recorder.Auto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Stop()
+
+	client := f.NewFaunaClient(secret, f.HTTP(rec.Client()))
+
+In Record mode, requests are sent to FaunaDB and each request body and response are saved to the
+cassette when Stop is called. In Replay mode, responses are read from the cassette, and no request
+reaches the network. Auto replays the cassette if it exists, and records it otherwise.
+
+Requests are matched with recorded ones by method, path and canonical body: the JSON body is
+compared regardless of whitespace and key order. Identical requests are replayed in the order they
+were recorded, so a query issued twice can return different results. Request headers, including the
+secret, are never saved.
+
+Tests that put random values in their queries, such as the names of the databases they create, get
+those values from Value, which saves them to the cassette when recording and returns the saved values
+when replaying.
+*/
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode selects whether a Recorder records or replays interactions.
+type Mode int
+
+// Recorder modes.
+const (
+	Replay Mode = iota // Replay recorded interactions, failing requests that were not recorded
+	Record             // Send requests to FaunaDB, recording the interactions
+	Auto               // Replay if the cassette exists, record otherwise
+)
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Values       []string      `json:"values,omitempty"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request along with its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request. Body is the canonical form of the request's JSON body.
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// NoInteractionError is returned by a replaying Recorder for requests missing from the cassette, or
+// issued more times than recorded.
+type NoInteractionError struct {
+	Request Request
+}
+
+func (err NoInteractionError) Error() string {
+	return fmt.Sprintf("recorder: no recorded interaction for %s %s %s", err.Request.Method, err.Request.Path, err.Request.Body)
+}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// Transport sets the transport used to send requests while recording. It defaults to
+// http.DefaultTransport.
+func Transport(transport http.RoundTripper) Option {
+	return func(rec *Recorder) { rec.transport = transport }
+}
+
+// Recorder records and replays HTTP interactions. It's safe for concurrent use.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed []bool
+	values   int
+}
+
+// New creates a Recorder for the cassette at the given path. In Replay mode, or in Auto mode when the
+// cassette exists, the cassette is loaded and New fails if it can't be read.
+func New(path string, mode Mode, options ...Option) (*Recorder, error) {
+	rec := &Recorder{path: path, mode: mode, transport: http.DefaultTransport}
+
+	for _, option := range options {
+		option(rec)
+	}
+
+	if rec.mode == Auto {
+		rec.mode = Record
+
+		if _, err := os.Stat(path); err == nil {
+			rec.mode = Replay
+		}
+	}
+
+	if rec.mode == Replay {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(raw, &rec.cassette); err != nil {
+			return nil, fmt.Errorf("recorder: invalid cassette %s: %s", path, err)
+		}
+
+		rec.replayed = make([]bool, len(rec.cassette.Interactions))
+	}
+
+	return rec, nil
+}
+
+// Mode returns whether the recorder is recording or replaying interactions.
+func (rec *Recorder) Mode() Mode { return rec.mode }
+
+// Client returns an http.Client sending its requests through the recorder, to be given to a
+// FaunaClient with f.HTTP.
+func (rec *Recorder) Client() *http.Client { return &http.Client{Transport: rec} }
+
+// Value returns the result of generate when recording, saving it to the cassette. When replaying, it
+// returns the values saved to the cassette instead, in the order they were recorded, and fails once
+// they are exhausted.
+func (rec *Recorder) Value(generate func() string) (string, error) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.mode == Record {
+		value := generate()
+		rec.cassette.Values = append(rec.cassette.Values, value)
+		return value, nil
+	}
+
+	if rec.values >= len(rec.cassette.Values) {
+		return "", fmt.Errorf("recorder: no recorded value left in %s", rec.path)
+	}
+
+	rec.values++
+	return rec.cassette.Values[rec.values-1], nil
+}
+
+// RoundTrip implements http.RoundTripper by recording or replaying the request.
+func (rec *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, body, err := newRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if rec.mode == Replay {
+		return rec.replay(req, recorded)
+	}
+
+	outgoing := req.WithContext(req.Context())
+
+	if req.Body != nil {
+		outgoing.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	res, err := rec.transport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.cassette.Interactions = append(rec.cassette.Interactions, Interaction{
+		Request:  recorded,
+		Response: Response{Status: res.StatusCode, Header: res.Header, Body: string(resBody)},
+	})
+
+	return res, nil
+}
+
+func (rec *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	for i, interaction := range rec.cassette.Interactions {
+		if rec.replayed[i] || interaction.Request != recorded {
+			continue
+		}
+
+		rec.replayed[i] = true
+		res := interaction.Response
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", res.Status, http.StatusText(res.Status)),
+			StatusCode:    res.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        res.Header,
+			Body:          ioutil.NopCloser(strings.NewReader(res.Body)),
+			ContentLength: int64(len(res.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, NoInteractionError{recorded}
+}
+
+// Stop saves the recorded interactions to the cassette when recording. It does nothing when
+// replaying.
+func (rec *Recorder) Stop() error {
+	if rec.mode != Record {
+		return nil
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	raw, err := json.MarshalIndent(rec.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(rec.path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(rec.path, append(raw, '\n'), 0644)
+}
+
+// newRequest returns the recorded form of a request, along with the request's body.
+func newRequest(req *http.Request) (recorded Request, body []byte, err error) {
+	recorded = Request{Method: req.Method, Path: req.URL.RequestURI()}
+
+	if req.Body == nil {
+		return
+	}
+
+	body, err = ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+
+	recorded.Body = Canonical(body)
+	return
+}
+
+// Canonical returns the canonical form of a JSON body, with no insignificant whitespace and with the
+// keys of objects sorted. Bodies that are not valid JSON are returned unchanged.
+func Canonical(body []byte) string {
+	var decoded interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&decoded); err != nil {
+		return string(body)
+	}
+
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(decoded); err != nil {
+		return string(body)
+	}
+
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
@@ -0,0 +1,140 @@
+package recorder
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/stretchr/testify/require"
+)
+
+func tempCassette(t *testing.T) (path string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "recorder")
+	require.NoError(t, err)
+
+	return filepath.Join(dir, "cassettes", "test.json"), func() { _ = os.RemoveAll(dir) }
+}
+
+func fakeServer(requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		body, _ := ioutil.ReadAll(r.Body)
+
+		w.Header().Set("X-Query-Time", "3")
+
+		if string(body) == `{"abort":"boom"}` {
+			w.WriteHeader(400)
+			_, _ = io.WriteString(w, `{"errors":[{"position":[],"code":"transaction aborted","description":"boom"}]}`)
+			return
+		}
+
+		_, _ = io.WriteString(w, `{"resource":"response `+strconv.Itoa(*requests)+`"}`)
+	}))
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path, cleanup := tempCassette(t)
+	defer cleanup()
+
+	requests := 0
+	server := fakeServer(&requests)
+	defer server.Close()
+
+	rec, err := New(path, Auto)
+	require.NoError(t, err)
+	require.Equal(t, Record, rec.Mode())
+
+	client := f.NewFaunaClient("secret", f.Endpoint(server.URL), f.HTTP(rec.Client()))
+
+	first, err := client.Query(f.Add(1, 2))
+	require.NoError(t, err)
+	second, err := client.Query(f.Add(1, 2))
+	require.NoError(t, err)
+	_, err = client.Query(f.Abort("boom"))
+	require.True(t, f.IsAborted(err))
+
+	require.NoError(t, rec.Stop())
+	require.Equal(t, 3, requests)
+
+	raw, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(raw), `"body": "{\"add\":[1,2]}"`)
+	require.NotContains(t, string(raw), "secret")
+
+	rec, err = New(path, Auto)
+	require.NoError(t, err)
+	require.Equal(t, Replay, rec.Mode())
+
+	client = f.NewFaunaClient("other", f.Endpoint("http://localhost:1"), f.HTTP(rec.Client()))
+
+	value, err := client.Query(f.Add(1, 2))
+	require.NoError(t, err)
+	require.Equal(t, first, value)
+
+	value, headers, err := client.QueryResult(f.Add(1, 2))
+	require.NoError(t, err)
+	require.Equal(t, second, value)
+	require.Equal(t, []string{"3"}, headers["X-Query-Time"])
+
+	_, err = client.Query(f.Abort("boom"))
+	require.True(t, f.IsAborted(err))
+
+	_, err = client.Query(f.Add(1, 2))
+	require.Contains(t, err.Error(), `recorder: no recorded interaction for POST / {"add":[1,2]}`)
+
+	require.NoError(t, rec.Stop())
+	require.Equal(t, 3, requests)
+}
+
+func TestRecordAndReplayValues(t *testing.T) {
+	path, cleanup := tempCassette(t)
+	defer cleanup()
+
+	rec, err := New(path, Record)
+	require.NoError(t, err)
+
+	generated := 0
+	generate := func() string { generated++; return "name" + strconv.Itoa(generated) }
+
+	first, err := rec.Value(generate)
+	require.NoError(t, err)
+	second, err := rec.Value(generate)
+	require.NoError(t, err)
+	require.Equal(t, []string{"name1", "name2"}, []string{first, second})
+	require.NoError(t, rec.Stop())
+
+	rec, err = New(path, Replay)
+	require.NoError(t, err)
+
+	value, err := rec.Value(generate)
+	require.NoError(t, err)
+	require.Equal(t, first, value)
+
+	value, err = rec.Value(generate)
+	require.NoError(t, err)
+	require.Equal(t, second, value)
+
+	_, err = rec.Value(generate)
+	require.EqualError(t, err, "recorder: no recorded value left in "+path)
+	require.Equal(t, 2, generated)
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	path, cleanup := tempCassette(t)
+	defer cleanup()
+
+	_, err := New(path, Replay)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestCanonical(t *testing.T) {
+	require.Equal(t, `{"a":[1,2.50],"b":{"c":"<d>","d":null}}`, Canonical([]byte(`{ "b": {"d": null, "c": "<d>"},
+		"a": [1, 2.50] }`)))
+	require.Equal(t, `not json`, Canonical([]byte(`not json`)))
+}
@@ -0,0 +1,269 @@
+package faunadb
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const termTag = "faunaterm"
+
+/*
+Repository maps the documents of a collection to Go values of type T, which is usually a struct or a
+pointer to a struct. Document data are encoded and decoded the same way as other values, using the
+fauna struct tags. Fields can also be marked as terms of indexes with the faunaterm tag, holding a
+comma separated list of index names. For example:
+
+	type User struct {
+		Email string `fauna:"email" faunaterm:"users_by_email"`
+		Name  string `fauna:"name"`
+		Age   int    `fauna:"age"`
+	}
+
+	users := NewRepository[User](client, Collection("users"))
+
+	doc, err := users.Find("42")
+	fmt.Println(doc.Data.Name)
+*/
+type Repository[T any] struct {
+	client     Querier
+	collection Expr
+}
+
+// NewRepository creates a repository for the documents of the given collection. The client is usually
+// a *FaunaClient.
+func NewRepository[T any](client Querier, collection Expr) *Repository[T] {
+	return &Repository[T]{client: client, collection: collection}
+}
+
+// Ref returns a ref to the document with the given id in the repository's collection.
+func (repo *Repository[T]) Ref(id string) Expr { return RefCollection(repo.collection, id) }
+
+// Create creates a document with the given data, which is left unchanged.
+func (repo *Repository[T]) Create(data T) (Document[T], error) {
+	return repo.query(Create(repo.collection, Obj{"data": data}))
+}
+
+// Find returns the document with the given id. It returns a NotFound error if the document doesn't
+// exist.
+func (repo *Repository[T]) Find(id string) (Document[T], error) {
+	return repo.query(Get(repo.Ref(id)))
+}
+
+// FindBy returns the first document matching the given terms of an index. It returns a NotFound error
+// if no document matches.
+func (repo *Repository[T]) FindBy(index string, terms ...interface{}) (Document[T], error) {
+	return repo.query(Get(matchTerms(index, terms)))
+}
+
+/*
+FindByExample returns the first document matching an index, its terms being taken from the fields
+of the example marked as terms of the index, in the order the fields are declared. It returns a
+NotFound error if no document matches. For example:
+
+	doc, err := users.FindByExample("users_by_email", User{Email: "jane@example.com"})
+*/
+func (repo *Repository[T]) FindByExample(index string, example T) (Document[T], error) {
+	terms, err := termsOf(example, index)
+	if err != nil {
+		return Document[T]{}, err
+	}
+
+	return repo.FindBy(index, terms...)
+}
+
+/*
+Update changes the non-zero fields of partial in the document with the given id. Use UpdateFields to
+set fields to zero values or to remove them. For example:
+
+	doc, err := users.Update("42", User{Age: 43})
+*/
+func (repo *Repository[T]) Update(id string, partial T) (Document[T], error) {
+	return repo.UpdateFields(id, nonZeroFields(partial))
+}
+
+/*
+UpdateFields changes the given fields of the document with the given id. A null removes a field. For
+example:
+
+	doc, err := users.UpdateFields("42", Obj{"admin": false, "nickname": nil})
+*/
+func (repo *Repository[T]) UpdateFields(id string, fields interface{}) (Document[T], error) {
+	return repo.query(Update(repo.Ref(id), Obj{"data": fields}))
+}
+
+// Delete deletes the document with the given id.
+func (repo *Repository[T]) Delete(id string) error {
+	_, err := repo.client.Query(Delete(repo.Ref(id)))
+	return err
+}
+
+/*
+List returns an iterator over all documents of the collection. The options are given to Paginate,
+for example Size to set the number of documents fetched per query. For example:
+
+	docs := users.List(Size(100))
+
+	for docs.Next() {
+		doc, err := docs.Document()
+		...
+	}
+
+	if err := docs.Err(); err != nil {
+		...
+	}
+*/
+func (repo *Repository[T]) List(options ...OptionalParameter) *DocumentIterator[T] {
+	query := func(options ...OptionalParameter) Expr {
+		return Map(Paginate(Documents(repo.collection), options...), Lambda("ref", Get(Var("ref"))))
+	}
+
+	return &DocumentIterator[T]{pageIterator{querier: repo.client, query: query, options: options}, nil}
+}
+
+// EnsureIndexes creates the indexes named in the faunaterm tags of the fields of T, if they don't
+// exist yet. Each index has the tagged fields of the document's data as terms, in the order the fields
+// are declared.
+func (repo *Repository[T]) EnsureIndexes() error {
+	indexes := make(map[string]Arr)
+
+	for _, term := range taggedTerms(new(T)) {
+		for _, index := range term.indexes {
+			indexes[index] = append(indexes[index], Obj{"field": Arr{"data", term.name}})
+		}
+	}
+
+	names := make([]string, 0, len(indexes))
+
+	for name := range indexes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		params := Obj{"name": name, "source": repo.collection, "terms": indexes[name]}
+
+		if _, err := repo.client.Query(If(Exists(Index(name)), nil, CreateIndex(params))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *Repository[T]) query(expr Expr) (doc Document[T], err error) {
+	res, err := repo.client.Query(expr)
+	if err != nil {
+		return
+	}
+
+	err = res.Get(&doc)
+	return
+}
+
+// DocumentIterator iterates over documents, fetching pages of documents as needed.
+type DocumentIterator[T any] struct {
+	pages   pageIterator
+	current Value
+}
+
+// Next advances the iterator to the next document. It returns false when there are no more documents
+// or when a query fails, in which case Err returns the error.
+func (it *DocumentIterator[T]) Next() (ok bool) {
+	it.current, ok = it.pages.next()
+	return
+}
+
+// Document decodes the current document.
+func (it *DocumentIterator[T]) Document() (doc Document[T], err error) {
+	err = it.current.Get(&doc)
+	return
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *DocumentIterator[T]) Err() error { return it.pages.err }
+
+func matchTerms(index string, terms []interface{}) Expr {
+	if len(terms) == 1 {
+		return MatchTerm(Index(index), terms[0])
+	}
+
+	return MatchTerm(Index(index), Arr(terms))
+}
+
+type taggedTerm struct {
+	name    string
+	value   interface{}
+	indexes []string
+}
+
+// taggedTerms returns the fields of a struct marked with the faunaterm tag, in declaration order.
+func taggedTerms(model interface{}) (terms []taggedTerm) {
+	value := reflect.ValueOf(model)
+
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value = reflect.New(value.Type().Elem())
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag := field.Tag.Get(termTag)
+
+		if tag == "" || !value.Field(i).CanInterface() {
+			continue
+		}
+
+		terms = append(terms, taggedTerm{fieldName(field), value.Field(i).Interface(), strings.Split(tag, ",")})
+	}
+
+	return
+}
+
+func termsOf(model interface{}, index string) (terms []interface{}, err error) {
+	for _, term := range taggedTerms(model) {
+		for _, name := range term.indexes {
+			if name == index {
+				terms = append(terms, term.value)
+			}
+		}
+	}
+
+	if len(terms) == 0 {
+		err = fmt.Errorf("faunadb: no field of %T is a term of the index %s", model, index)
+	}
+
+	return
+}
+
+// nonZeroFields returns the non-zero fields of a struct, or the value itself if it's not a struct.
+func nonZeroFields(partial interface{}) interface{} {
+	value := reflect.ValueOf(partial)
+
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct || value.Type() == timeType {
+		return partial
+	}
+
+	fields := make(Obj)
+
+	for key, field := range exportedStructFields(value) {
+		if !reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface()) {
+			fields[key] = field.Interface()
+		}
+	}
+
+	return fields
+}
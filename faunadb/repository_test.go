@@ -0,0 +1,127 @@
+package faunadb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type repositoryUser struct {
+	Email   string `fauna:"email" faunaterm:"users_by_email,users_by_email_and_name"`
+	Name    string `fauna:"name" faunaterm:"users_by_email_and_name"`
+	Age     int    `fauna:"age"`
+	Admin   bool   `fauna:"admin"`
+	private string
+}
+
+const repositoryDoc = `{"ref":` + userRef + `,"ts":10,"data":{"email":"jane@example.com","name":"Jane","age":42,"admin":false}}`
+
+func TestRepositoryCreate(t *testing.T) {
+	var queries []string
+
+	server, client := newTestServer(respondWithPages(&queries, repositoryDoc))
+	defer server.Close()
+
+	user := &repositoryUser{Email: "jane@example.com", Name: "Jane", Age: 41}
+	doc, err := NewRepository[*repositoryUser](client, Collection("users")).Create(user)
+	require.NoError(t, err)
+	require.Equal(t, "42", doc.Ref.ID)
+	require.Equal(t, int64(10), doc.TS)
+	require.Equal(t, &repositoryUser{Email: "jane@example.com", Name: "Jane", Age: 42}, doc.Data)
+	require.Equal(t, 41, user.Age)
+	require.Equal(t,
+		`{"create":{"collection":"users"},"params":{"object":{"data":{"object":{"admin":false,"age":41,"email":"jane@example.com","name":"Jane"}}}}}`,
+		queries[0])
+}
+
+func TestRepositoryFind(t *testing.T) {
+	var queries []string
+
+	server, client := newTestServer(respondWithPages(&queries, repositoryDoc, repositoryDoc, repositoryDoc))
+	defer server.Close()
+
+	users := NewRepository[repositoryUser](client, Collection("users"))
+
+	doc, err := users.Find("42")
+	require.NoError(t, err)
+	require.Equal(t, repositoryUser{Email: "jane@example.com", Name: "Jane", Age: 42}, doc.Data)
+	require.Equal(t, `{"get":{"id":"42","ref":{"collection":"users"}}}`, queries[0])
+
+	_, err = users.FindBy("users_by_email", "jane@example.com")
+	require.NoError(t, err)
+	require.Equal(t, `{"get":{"match":{"index":"users_by_email"},"terms":"jane@example.com"}}`, queries[1])
+
+	example := repositoryUser{Email: "jane@example.com", Name: "Jane"}
+	doc, err = users.FindByExample("users_by_email_and_name", example)
+	require.NoError(t, err)
+	require.Equal(t, `{"get":{"match":{"index":"users_by_email_and_name"},"terms":["jane@example.com","Jane"]}}`, queries[2])
+	require.Equal(t, 42, doc.Data.Age)
+
+	_, err = users.FindByExample("users_by_age", example)
+	require.EqualError(t, err, "faunadb: no field of faunadb.repositoryUser is a term of the index users_by_age")
+}
+
+func TestRepositoryUpdateAndDelete(t *testing.T) {
+	var queries []string
+
+	server, client := newTestServer(respondWithPages(&queries, repositoryDoc, repositoryDoc, `null`))
+	defer server.Close()
+
+	users := NewRepository[repositoryUser](client, Collection("users"))
+
+	doc, err := users.Update("42", repositoryUser{Age: 42})
+	require.NoError(t, err)
+	require.Equal(t, `{"params":{"object":{"data":{"object":{"age":42}}}},"update":{"id":"42","ref":{"collection":"users"}}}`, queries[0])
+	require.Equal(t, "Jane", doc.Data.Name)
+
+	_, err = users.UpdateFields("42", Obj{"admin": false, "name": nil})
+	require.NoError(t, err)
+	require.Equal(t, `{"params":{"object":{"data":{"object":{"admin":false,"name":null}}}},"update":{"id":"42","ref":{"collection":"users"}}}`, queries[1])
+
+	require.NoError(t, users.Delete("42"))
+	require.Equal(t, `{"delete":{"id":"42","ref":{"collection":"users"}}}`, queries[2])
+}
+
+func TestRepositoryList(t *testing.T) {
+	var queries []string
+
+	server, client := newTestServer(respondWithPages(&queries,
+		`{"data":[`+repositoryDoc+`],"after":[`+userRef+`]}`,
+		`{"data":[`+repositoryDoc+`]}`,
+	))
+	defer server.Close()
+
+	docs := NewRepository[repositoryUser](client, Collection("users")).List(Size(1))
+	var names []string
+
+	for docs.Next() {
+		doc, err := docs.Document()
+		require.NoError(t, err)
+		require.Equal(t, "42", doc.Ref.ID)
+
+		names = append(names, doc.Data.Name)
+	}
+
+	require.NoError(t, docs.Err())
+	require.Equal(t, []string{"Jane", "Jane"}, names)
+	require.Equal(t,
+		`{"collection":{"paginate":{"documents":{"collection":"users"}},"size":1},"map":{"expr":{"get":{"var":"ref"}},"lambda":"ref"}}`,
+		queries[0])
+	require.Contains(t, queries[1], `"after":[{"@ref"`)
+}
+
+func TestRepositoryEnsureIndexes(t *testing.T) {
+	var queries []string
+
+	server, client := newTestServer(respondWithPages(&queries, `null`, `null`))
+	defer server.Close()
+
+	require.NoError(t, NewRepository[*repositoryUser](client, Collection("users")).EnsureIndexes())
+	require.Equal(t, []string{
+		`{"else":{"create_index":{"object":{"name":"users_by_email","source":{"collection":"users"},` +
+			`"terms":[{"object":{"field":["data","email"]}}]}}},"if":{"exists":{"index":"users_by_email"}},"then":null}`,
+		`{"else":{"create_index":{"object":{"name":"users_by_email_and_name","source":{"collection":"users"},` +
+			`"terms":[{"object":{"field":["data","email"]}},{"object":{"field":["data","name"]}}]}}},` +
+			`"if":{"exists":{"index":"users_by_email_and_name"}},"then":null}`,
+	}, queries)
+}
@@ -0,0 +1,490 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+const pageSize = 1000
+
+var (
+	dataField    = f.ObjKey("data")
+	afterField   = f.ObjKey("after")
+	liveField    = f.ObjKey("live")
+	desiredField = f.ObjKey("desired")
+)
+
+// Index fields that FaunaDB doesn't allow to update. Changing them requires recreating the index.
+var immutableIndexFields = map[string]bool{"source": true, "terms": true, "values": true}
+
+// Action is the kind of change made to a schema document.
+type Action string
+
+// Actions on schema documents.
+const (
+	Create Action = "create"
+	Update Action = "update"
+	Delete Action = "delete"
+)
+
+// Change is a change to a single schema document.
+type Change struct {
+	Action   Action
+	Kind     Kind
+	Name     string
+	Fields   []string // Fields changed by an update, or causing an index to be recreated
+	Expr     f.Expr   // Expression making the change
+	Recreate bool     // Whether the change deletes or creates an index being recreated
+}
+
+func (change Change) String() string {
+	str := fmt.Sprintf("%s %s %s", change.Action, change.Kind, change.Name)
+
+	if change.Recreate {
+		str += " to recreate it"
+	}
+
+	if len(change.Fields) > 0 {
+		str = fmt.Sprintf("%s (%s)", str, strings.Join(change.Fields, ", "))
+	}
+
+	return str
+}
+
+// Plan is the ordered list of changes needed to make a database match a desired schema.
+type Plan []Change
+
+// String describes the plan along with the expression of each change in the query language syntax.
+func (plan Plan) String() string {
+	var buffer bytes.Buffer
+
+	for _, change := range plan {
+		fmt.Fprintf(&buffer, "%s\n\t%s\n", change, f.FQL(change.Expr))
+	}
+
+	return buffer.String()
+}
+
+// Option configures Diff.
+type Option func(*differ)
+
+// Prune makes Diff delete the collections, indexes, functions and roles missing from the desired
+// schema. Deleting a collection deletes all of its documents.
+func Prune() Option {
+	return func(d *differ) { d.prune = true }
+}
+
+// RecreateIndexes makes Diff delete and create again the indexes whose source, terms or values
+// changed, since FaunaDB doesn't allow to update them. Without it, Diff returns an error for such
+// indexes. Recreating an index rebuilds it, and it can't be used until its build completes.
+func RecreateIndexes() Option {
+	return func(d *differ) { d.recreate = true }
+}
+
+type differ struct {
+	client      f.Querier
+	prune       bool
+	recreate    bool
+	plan        Plan
+	newRoles    map[string]bool // Roles created by the plan
+	roleUpdates Plan            // Updates of functions running with roles created by the plan
+}
+
+/*
+Diff compares the desired schema with the database of the client and returns the changes needed to
+make them match, in an order satisfying the dependencies between schema documents:
+
+ 1. Collections are created or updated.
+ 2. Indexes are created or updated. With RecreateIndexes, indexes whose source, terms or values
+    changed are recreated.
+ 3. Functions are created or updated. Functions running with a role created by the plan are
+    first created without a role.
+ 4. Roles are created or updated.
+ 5. Functions running with a role created by the plan are updated to use it.
+ 6. With Prune, roles, functions, indexes and collections missing from the desired schema are
+    deleted, in that order.
+*/
+func Diff(client f.Querier, desired Schema, options ...Option) (Plan, error) {
+	d := &differ{client: client, newRoles: make(map[string]bool)}
+
+	for _, option := range options {
+		option(d)
+	}
+
+	docs, err := desired.documents()
+	if err != nil {
+		return nil, err
+	}
+
+	live, evaluated, err := d.fetch(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, doc := range docs {
+		if doc.kind == KindRole && live[i] == nil {
+			d.newRoles[doc.name] = true
+		}
+	}
+
+	for i, doc := range docs {
+		if live[i] == nil {
+			d.create(doc)
+		} else if err = d.update(doc, live[i], evaluated[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	d.plan = append(d.plan, d.roleUpdates...)
+
+	if d.prune {
+		if err = d.pruneMissing(docs); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.plan, nil
+}
+
+func change(action Action, doc document, fields []string, expr f.Expr) Change {
+	return Change{action, doc.kind, doc.name, fields, expr, false}
+}
+
+func (d *differ) add(action Action, doc document, fields []string, expr f.Expr) {
+	d.plan = append(d.plan, change(action, doc, fields, expr))
+}
+
+func (d *differ) create(doc document) {
+	if doc.kind != KindFunction || !d.newRoles[doc.role] {
+		d.add(Create, doc, nil, doc.kind.create(doc.params))
+		return
+	}
+
+	params := f.Obj{}
+
+	for key, param := range doc.params {
+		if key != "role" {
+			params[key] = param
+		}
+	}
+
+	d.add(Create, doc, nil, doc.kind.create(params))
+	d.roleUpdates = append(d.roleUpdates, change(Update, doc, []string{"role"}, f.Update(doc.ref(), f.Obj{"role": doc.params["role"]})))
+}
+
+func (d *differ) update(doc document, live, desired f.ObjectV) error {
+	var changed []string
+	recreate := false
+
+	for key, value := range desired {
+		if key != "name" && !equal(value, live[key]) {
+			changed = append(changed, key)
+			recreate = recreate || (doc.kind == KindIndex && immutableIndexFields[key])
+		}
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	sort.Strings(changed)
+
+	if recreate {
+		if !d.recreate {
+			return fmt.Errorf("schema: index %s must be recreated to change %s, use RecreateIndexes to allow it",
+				doc.name, strings.Join(changed, ", "))
+		}
+
+		deletion := change(Delete, doc, changed, f.Delete(doc.ref()))
+		creation := change(Create, doc, changed, doc.kind.create(doc.params))
+		deletion.Recreate, creation.Recreate = true, true
+		d.plan = append(d.plan, deletion, creation)
+		return nil
+	}
+
+	params := f.Obj{}
+
+	for _, key := range changed {
+		params[key] = updateParam(desired[key], live[key])
+	}
+
+	if doc.kind == KindFunction && d.newRoles[doc.role] && params["role"] != nil {
+		d.roleUpdates = append(d.roleUpdates, change(Update, doc, []string{"role"}, f.Update(doc.ref(), f.Obj{"role": params["role"]})))
+		delete(params, "role")
+		changed = withoutRole(changed)
+
+		if len(changed) == 0 {
+			return nil
+		}
+	}
+
+	d.add(Update, doc, changed, f.Update(doc.ref(), params))
+	return nil
+}
+
+func withoutRole(fields []string) (others []string) {
+	for _, field := range fields {
+		if field != "role" {
+			others = append(others, field)
+		}
+	}
+
+	return
+}
+
+// updateParam returns the value to update a field with. Since updates merge objects, keys of the live
+// object missing from the desired object are removed by setting them to null.
+func updateParam(desired, live f.Value) f.Value {
+	if isEmpty(desired) {
+		return f.NullV{}
+	}
+
+	desiredObj, isObj := desired.(f.ObjectV)
+	liveObj, liveIsObj := live.(f.ObjectV)
+
+	if !isObj || !liveIsObj {
+		return desired
+	}
+
+	param := f.ObjectV{}
+
+	for key, value := range desiredObj {
+		param[key] = updateParam(value, liveObj[key])
+	}
+
+	for key := range liveObj {
+		if _, ok := desiredObj[key]; !ok {
+			param[key] = f.NullV{}
+		}
+	}
+
+	return param
+}
+
+func (d *differ) pruneMissing(docs []document) error {
+	desired := make(map[Kind]map[string]bool)
+
+	for _, doc := range docs {
+		if desired[doc.kind] == nil {
+			desired[doc.kind] = make(map[string]bool)
+		}
+
+		desired[doc.kind][doc.name] = true
+	}
+
+	for _, kind := range []Kind{KindRole, KindFunction, KindIndex, KindCollection} {
+		names, err := d.list(kind)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			if !desired[kind][name] {
+				doc := document{kind: kind, name: name}
+				d.add(Delete, doc, nil, f.Delete(doc.ref()))
+			}
+		}
+	}
+
+	return nil
+}
+
+// list returns the names of the live schema documents of a kind.
+func (d *differ) list(kind Kind) (names []string, err error) {
+	options := []f.OptionalParameter{f.Size(pageSize)}
+
+	for {
+		var res f.Value
+
+		if res, err = d.client.Query(f.Paginate(kind.set(), options...)); err != nil {
+			return
+		}
+
+		var refs []f.RefV
+
+		if err = res.At(dataField).Get(&refs); err != nil {
+			return
+		}
+
+		for _, ref := range refs {
+			names = append(names, ref.ID)
+		}
+
+		after, afterErr := res.At(afterField).GetValue()
+		if afterErr != nil {
+			break
+		}
+
+		options = []f.OptionalParameter{f.Size(pageSize), f.After(after)}
+	}
+
+	sort.Strings(names)
+	return
+}
+
+// fetch returns the live documents, nil when missing, along with the desired parameters evaluated by
+// FaunaDB.
+func (d *differ) fetch(docs []document) (live []f.ObjectV, evaluated []f.ObjectV, err error) {
+	if len(docs) == 0 {
+		return
+	}
+
+	pairs := make(f.Arr, len(docs))
+
+	for i, doc := range docs {
+		ref := doc.ref()
+		pairs[i] = f.Obj{"live": f.If(f.Exists(ref), f.Get(ref), nil), "desired": doc.params}
+	}
+
+	res, err := d.client.Query(pairs)
+	if err != nil {
+		return
+	}
+
+	var values []f.Value
+
+	if err = res.Get(&values); err != nil {
+		return
+	}
+
+	live = make([]f.ObjectV, len(values))
+	evaluated = make([]f.ObjectV, len(values))
+
+	for i, value := range values {
+		_ = value.At(liveField).Get(&live[i])
+
+		if err = value.At(desiredField).Get(&evaluated[i]); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// equal compares a desired value with a live value with f.Equal, except that null, missing values and
+// empty arrays are equal, and queries are compared by their lambdas regardless of the API version they
+// were created with.
+func equal(desired, live f.Value) bool {
+	if isEmpty(desired) || isEmpty(live) {
+		return isEmpty(desired) && isEmpty(live)
+	}
+
+	switch d := desired.(type) {
+	case f.ObjectV:
+		l, ok := live.(f.ObjectV)
+		if !ok {
+			return false
+		}
+
+		for key, value := range d {
+			if !equal(value, l[key]) {
+				return false
+			}
+		}
+
+		for key, value := range l {
+			if _, ok := d[key]; !ok && !isEmpty(value) {
+				return false
+			}
+		}
+
+		return true
+
+	case f.ArrayV:
+		l, ok := live.(f.ArrayV)
+		if !ok || len(d) != len(l) {
+			return false
+		}
+
+		for i := range d {
+			if !equal(d[i], l[i]) {
+				return false
+			}
+		}
+
+		return true
+
+	case f.QueryV:
+		l, ok := live.(f.QueryV)
+		return ok && f.Equal(lambdaOf(d), lambdaOf(l))
+
+	default:
+		return f.Equal(desired, live)
+	}
+}
+
+func isEmpty(value f.Value) bool {
+	switch v := value.(type) {
+	case nil, f.NullV:
+		return true
+	case f.ArrayV:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// lambdaOf returns the lambda of a query as a value, without its API version.
+func lambdaOf(query f.QueryV) f.Value {
+	encoded, err := query.MarshalJSON()
+	if err != nil {
+		return nil
+	}
+
+	var decoded struct {
+		Lambda map[string]interface{} `json:"@query"`
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+
+	if decoder.Decode(&decoded) != nil {
+		return nil
+	}
+
+	delete(decoded.Lambda, "api_version")
+
+	encoded, err = json.Marshal(decoded.Lambda)
+	if err != nil {
+		return nil
+	}
+
+	var lambda f.Value
+
+	if f.UnmarshalJSON(encoded, &lambda) != nil {
+		return nil
+	}
+
+	return lambda
+}
+
+// ApplyError is returned by Apply when a change fails.
+type ApplyError struct {
+	Change Change
+	Err    error
+}
+
+func (err ApplyError) Error() string {
+	return fmt.Sprintf("schema: %s: %s", err.Change, err.Err)
+}
+
+// Unwrap returns the error returned by FaunaDB.
+func (err ApplyError) Unwrap() error { return err.Err }
+
+// Apply runs the changes of a plan in order, each one in its own query, since FaunaDB doesn't allow
+// using a schema document in the same transaction that creates it. It stops at the first failing
+// change, returning an ApplyError.
+func Apply(client f.Querier, plan Plan) error {
+	for _, change := range plan {
+		if _, err := client.Query(change.Expr); err != nil {
+			return ApplyError{change, err}
+		}
+	}
+
+	return nil
+}
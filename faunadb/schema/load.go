@@ -0,0 +1,273 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"gopkg.in/yaml.v2"
+)
+
+type schemaJSON struct {
+	Collections []struct {
+		Name        string          `json:"name"`
+		HistoryDays *int64          `json:"history_days"`
+		TTLDays     *int64          `json:"ttl_days"`
+		Data        json.RawMessage `json:"data"`
+	} `json:"collections"`
+
+	Indexes []struct {
+		Name       string                     `json:"name"`
+		Source     string                     `json:"source"`
+		Bindings   map[string]json.RawMessage `json:"bindings"`
+		Terms      []fieldJSON                `json:"terms"`
+		Values     []fieldJSON                `json:"values"`
+		Unique     bool                       `json:"unique"`
+		Serialized *bool                      `json:"serialized"`
+		Data       json.RawMessage            `json:"data"`
+	} `json:"indexes"`
+
+	Functions []struct {
+		Name string          `json:"name"`
+		Body json.RawMessage `json:"body"`
+		Role string          `json:"role"`
+		Data json.RawMessage `json:"data"`
+	} `json:"functions"`
+
+	Roles []struct {
+		Name       string `json:"name"`
+		Privileges []struct {
+			Resource json.RawMessage            `json:"resource"`
+			Actions  map[string]json.RawMessage `json:"actions"`
+		} `json:"privileges"`
+		Membership []struct {
+			Resource  json.RawMessage `json:"resource"`
+			Predicate json.RawMessage `json:"predicate"`
+		} `json:"membership"`
+		Data json.RawMessage `json:"data"`
+	} `json:"roles"`
+}
+
+type fieldJSON struct {
+	Field   []string `json:"field"`
+	Binding string   `json:"binding"`
+	Reverse bool     `json:"reverse"`
+}
+
+// Load reads the desired schema from a JSON file, or from a YAML file if its extension is .yaml or
+// .yml. See Parse and ParseYAML for the file formats.
+func Load(path string) (Schema, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Schema{}, err
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return ParseYAML(content)
+	default:
+		return Parse(content)
+	}
+}
+
+/*
+Parse decodes a desired schema from JSON. Document fields use FaunaDB's names, while function bodies,
+index bindings, privilege resources and actions, and membership resources and predicates are
+expressions in FaunaDB's JSON wire format. For example:
+
+	{
+		"collections": [{"name": "users", "history_days": 0}],
+		"indexes": [{
+			"name": "users_by_email",
+			"source": "users",
+			"terms": [{"field": ["data", "email"]}],
+			"unique": true
+		}],
+		"functions": [{
+			"name": "user_by_email",
+			"body": {"query": {"lambda": "email", "expr": {"get": {"match": {"index": "users_by_email"}, "terms": {"var": "email"}}}}},
+			"role": "server"
+		}],
+		"roles": [{
+			"name": "reader",
+			"privileges": [{"resource": {"collection": "users"}, "actions": {"read": true}}],
+			"membership": [{"resource": {"collection": "users"}}]
+		}]
+	}
+*/
+func Parse(content []byte) (schema Schema, err error) {
+	var decoded schemaJSON
+
+	if err = json.Unmarshal(content, &decoded); err != nil {
+		return
+	}
+
+	p := parser{}
+
+	for _, c := range decoded.Collections {
+		schema.Collections = append(schema.Collections, Collection{c.Name, c.HistoryDays, c.TTLDays, p.data(c.Data)})
+	}
+
+	for _, i := range decoded.Indexes {
+		index := Index{Name: i.Name, Source: i.Source, Unique: i.Unique, Serialized: i.Serialized, Data: p.data(i.Data)}
+
+		for name, binding := range i.Bindings {
+			if index.Bindings == nil {
+				index.Bindings = make(map[string]f.Expr)
+			}
+
+			index.Bindings[name] = p.expr(binding)
+		}
+
+		index.Terms, index.Values = fields(i.Terms), fields(i.Values)
+		schema.Indexes = append(schema.Indexes, index)
+	}
+
+	for _, fn := range decoded.Functions {
+		schema.Functions = append(schema.Functions, Function{fn.Name, p.expr(fn.Body), fn.Role, p.data(fn.Data)})
+	}
+
+	for _, r := range decoded.Roles {
+		role := Role{Name: r.Name, Data: p.data(r.Data)}
+
+		for _, privilege := range r.Privileges {
+			actions := make(map[string]interface{}, len(privilege.Actions))
+
+			for action, allowed := range privilege.Actions {
+				actions[action] = p.expr(allowed)
+			}
+
+			role.Privileges = append(role.Privileges, Privilege{p.expr(privilege.Resource), actions})
+		}
+
+		for _, member := range r.Membership {
+			membership := Membership{Resource: p.expr(member.Resource)}
+
+			if len(member.Predicate) > 0 {
+				membership.Predicate = p.expr(member.Predicate)
+			}
+
+			role.Membership = append(role.Membership, membership)
+		}
+
+		schema.Roles = append(schema.Roles, role)
+	}
+
+	return schema, p.err
+}
+
+func fields(decoded []fieldJSON) (fields []f.IndexField) {
+	for _, field := range decoded {
+		fields = append(fields, f.IndexField(field))
+	}
+
+	return
+}
+
+// parser decodes expressions and values, keeping the first error found.
+type parser struct {
+	err error
+}
+
+/*
+ParseYAML decodes a desired schema from YAML. The document has the same structure as the JSON
+document decoded by Parse, expressions being written in FaunaDB's JSON wire format with YAML syntax.
+For example:
+
+	collections:
+	  - name: users
+	indexes:
+	  - name: users_by_email
+	    source: users
+	    terms:
+	      - field: [data, email]
+	    unique: true
+	functions:
+	  - name: user_by_email
+	    body:
+	      query:
+	        lambda: email
+	        expr: {get: {match: {index: users_by_email}, terms: {var: email}}}
+	    role: server
+*/
+func ParseYAML(content []byte) (Schema, error) {
+	var decoded interface{}
+
+	if err := yaml.Unmarshal(content, &decoded); err != nil {
+		return Schema{}, err
+	}
+
+	content, err := json.Marshal(jsonFromYAML(decoded))
+	if err != nil {
+		return Schema{}, err
+	}
+
+	return Parse(content)
+}
+
+// jsonFromYAML converts decoded YAML, whose maps have keys of any type, to values encodable as JSON.
+func jsonFromYAML(decoded interface{}) interface{} {
+	switch v := decoded.(type) {
+	case map[interface{}]interface{}:
+		obj := make(map[string]interface{}, len(v))
+
+		for key, elem := range v {
+			obj[fmt.Sprint(key)] = jsonFromYAML(elem)
+		}
+
+		return obj
+
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+
+		for i, elem := range v {
+			arr[i] = jsonFromYAML(elem)
+		}
+
+		return arr
+
+	default:
+		return v
+	}
+}
+
+func (p *parser) expr(raw json.RawMessage) (expr f.Expr) {
+	if p.err != nil || len(raw) == 0 {
+		return
+	}
+
+	if err := f.UnmarshalJSONExpr(raw, &expr); err != nil {
+		p.err = fmt.Errorf("schema: invalid expression %s: %s", raw, err)
+	}
+
+	return
+}
+
+func (p *parser) data(raw json.RawMessage) f.Obj {
+	if p.err != nil || len(raw) == 0 {
+		return nil
+	}
+
+	var value f.Value
+
+	if err := f.UnmarshalJSON(raw, &value); err != nil {
+		p.err = fmt.Errorf("schema: invalid data %s: %s", raw, err)
+		return nil
+	}
+
+	obj, ok := value.(f.ObjectV)
+	if !ok {
+		p.err = fmt.Errorf("schema: data must be an object, got %s", raw)
+		return nil
+	}
+
+	data := make(f.Obj, len(obj))
+
+	for key, elem := range obj {
+		data[key] = elem
+	}
+
+	return data
+}
@@ -0,0 +1,325 @@
+/*
+Package schema compares a declarative description of a database schema with a live FaunaDB database,
+and applies the changes needed to make them match.
+
+The desired schema is described with Go structs, or loaded from a JSON or YAML file with Load. For
+example:
+
+	desired := schema.Schema{
+		Collections: []schema.Collection{{Name: "users"}},
+		Indexes: []schema.Index{{
+			Name:   "users_by_email",
+			Source: "users",
+			Terms:  []f.IndexField{f.FieldPath("data", "email")},
+			Unique: true,
+		}},
+		Functions: []schema.Function{{
+			Name: "register",
+			Body: f.Query(f.Lambda("email", f.Create(f.Collection("users"), f.Obj{"data": f.Obj{"email": f.Var("email")}}))),
+			Role: "server",
+		}},
+	}
+
+	plan, err := schema.Diff(client, desired)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Print(plan)
+	err = schema.Apply(client, plan)
+
+Desired documents are evaluated by FaunaDB before being compared with the live documents, so refs,
+lambdas and any other expression compare the same way FaunaDB stores them. Function bodies and
+predicates are compared through their QueryV values.
+
+Optional fields left unset, such as Collection.HistoryDays, are not compared. Documents missing from
+the desired schema are left untouched unless the Prune option is given, and indexes are only deleted
+and created again to change their source, terms or values with the RecreateIndexes option.
+*/
+package schema
+
+import (
+	"fmt"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+)
+
+// Schema describes the desired schema documents of a database.
+type Schema struct {
+	Collections []Collection
+	Indexes     []Index
+	Functions   []Function
+	Roles       []Role
+}
+
+// Collection describes a collection.
+type Collection struct {
+	Name        string
+	HistoryDays *int64
+	TTLDays     *int64
+	Data        f.Obj
+}
+
+// Index describes an index over a collection. Bindings maps the names of computed fields, which can
+// be used by terms and values, to their lambdas wrapped with Query.
+type Index struct {
+	Name       string
+	Source     string
+	Bindings   map[string]f.Expr
+	Terms      []f.IndexField
+	Values     []f.IndexField
+	Unique     bool
+	Serialized *bool
+	Data       f.Obj
+}
+
+// Function describes a user defined function. Body is usually a lambda wrapped with Query. Role is
+// either a built-in role, such as "admin" or "server", or the name of a user defined role.
+type Function struct {
+	Name string
+	Body f.Expr
+	Role string
+	Data f.Obj
+}
+
+// Role describes a user defined role.
+type Role struct {
+	Name       string
+	Privileges []Privilege
+	Membership []Membership
+	Data       f.Obj
+}
+
+// Privilege grants actions over a resource, such as f.Collection("users") or f.Indexes(). Actions are
+// either booleans or predicates wrapped with Query.
+type Privilege struct {
+	Resource f.Expr
+	Actions  map[string]interface{}
+}
+
+// Membership makes documents of a collection members of a role, optionally filtered by a predicate
+// wrapped with Query.
+type Membership struct {
+	Resource  f.Expr
+	Predicate f.Expr
+}
+
+// Kind is the kind of a schema document.
+type Kind string
+
+// Kinds of schema documents.
+const (
+	KindCollection Kind = "collection"
+	KindIndex      Kind = "index"
+	KindFunction   Kind = "function"
+	KindRole       Kind = "role"
+)
+
+var builtinRoles = map[string]bool{"admin": true, "server": true, "server-readonly": true, "client": true}
+
+func (kind Kind) ref(name string) f.Expr {
+	switch kind {
+	case KindCollection:
+		return f.Collection(name)
+	case KindIndex:
+		return f.Index(name)
+	case KindFunction:
+		return f.Function(name)
+	default:
+		return f.Role(name)
+	}
+}
+
+func (kind Kind) create(params interface{}) f.Expr {
+	switch kind {
+	case KindCollection:
+		return f.CreateCollection(params)
+	case KindIndex:
+		return f.CreateIndex(params)
+	case KindFunction:
+		return f.CreateFunction(params)
+	default:
+		return f.CreateRole(params)
+	}
+}
+
+func (kind Kind) set() f.Expr {
+	switch kind {
+	case KindCollection:
+		return f.Collections()
+	case KindIndex:
+		return f.Indexes()
+	case KindFunction:
+		return f.Functions()
+	default:
+		return f.Roles()
+	}
+}
+
+// document is a desired schema document along with the parameters used to create it.
+type document struct {
+	kind   Kind
+	name   string
+	params f.Obj
+	role   string // User defined role of a function
+}
+
+func (doc document) ref() f.Expr { return doc.kind.ref(doc.name) }
+
+func (collection Collection) params() f.Obj {
+	params := f.Obj{"name": collection.Name}
+
+	if collection.HistoryDays != nil {
+		params["history_days"] = *collection.HistoryDays
+	}
+
+	if collection.TTLDays != nil {
+		params["ttl_days"] = *collection.TTLDays
+	}
+
+	return withData(params, collection.Data)
+}
+
+func (index Index) params() f.Obj {
+	var source interface{} = f.Collection(index.Source)
+
+	if len(index.Bindings) > 0 {
+		fields := f.Obj{}
+
+		for name, binding := range index.Bindings {
+			fields[name] = binding
+		}
+
+		source = f.Obj{"collection": source, "fields": fields}
+	}
+
+	params := f.Obj{
+		"name":   index.Name,
+		"source": source,
+		"terms":  fieldsParams(index.Terms),
+		"values": fieldsParams(index.Values),
+		"unique": index.Unique,
+	}
+
+	if index.Serialized != nil {
+		params["serialized"] = *index.Serialized
+	}
+
+	return withData(params, index.Data)
+}
+
+func fieldsParams(fields []f.IndexField) f.Arr {
+	params := f.Arr{}
+
+	for _, field := range fields {
+		params = append(params, field.Params())
+	}
+
+	return params
+}
+
+func (function Function) params() f.Obj {
+	params := f.Obj{"name": function.Name, "body": function.Body, "role": function.role()}
+	return withData(params, function.Data)
+}
+
+func (function Function) role() interface{} {
+	switch {
+	case function.Role == "":
+		return nil
+	case builtinRoles[function.Role]:
+		return function.Role
+	default:
+		return f.Role(function.Role)
+	}
+}
+
+func (role Role) params() f.Obj {
+	privileges := f.Arr{}
+
+	for _, privilege := range role.Privileges {
+		actions := f.Obj{}
+
+		for action, allowed := range privilege.Actions {
+			actions[action] = allowed
+		}
+
+		privileges = append(privileges, f.Obj{"resource": privilege.Resource, "actions": actions})
+	}
+
+	membership := f.Arr{}
+
+	for _, member := range role.Membership {
+		param := f.Obj{"resource": member.Resource}
+
+		if member.Predicate != nil {
+			param["predicate"] = member.Predicate
+		}
+
+		membership = append(membership, param)
+	}
+
+	params := f.Obj{"name": role.Name, "privileges": privileges, "membership": membership}
+	return withData(params, role.Data)
+}
+
+func withData(params f.Obj, data f.Obj) f.Obj {
+	if data != nil {
+		params["data"] = data
+	}
+
+	return params
+}
+
+// documents returns the desired schema documents, in dependency order.
+func (schema Schema) documents() (docs []document, err error) {
+	names := make(map[Kind]map[string]bool)
+
+	add := func(kind Kind, name string, params f.Obj, role string) {
+		if names[kind] == nil {
+			names[kind] = make(map[string]bool)
+		}
+
+		switch {
+		case err != nil:
+		case name == "":
+			err = fmt.Errorf("schema: %s without name", kind)
+		case names[kind][name]:
+			err = fmt.Errorf("schema: duplicated %s %s", kind, name)
+		default:
+			names[kind][name] = true
+			docs = append(docs, document{kind, name, params, role})
+		}
+	}
+
+	for _, collection := range schema.Collections {
+		add(KindCollection, collection.Name, collection.params(), "")
+	}
+
+	for _, index := range schema.Indexes {
+		if index.Source == "" && err == nil {
+			err = fmt.Errorf("schema: index %s without source", index.Name)
+		}
+
+		add(KindIndex, index.Name, index.params(), "")
+	}
+
+	for _, function := range schema.Functions {
+		if function.Body == nil && err == nil {
+			err = fmt.Errorf("schema: function %s without body", function.Name)
+		}
+
+		role := function.Role
+		if builtinRoles[role] {
+			role = ""
+		}
+
+		add(KindFunction, function.Name, function.params(), role)
+	}
+
+	for _, role := range schema.Roles {
+		add(KindRole, role.Name, role.params(), "")
+	}
+
+	return
+}
@@ -0,0 +1,342 @@
+package schema
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	f "github.com/fauna/faunadb-go/faunadb"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeServer struct {
+	sync.Mutex
+	queries   []string
+	responses []string
+}
+
+func (server *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.Lock()
+	defer server.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	server.queries = append(server.queries, string(body))
+
+	response := `{"resource":null}`
+
+	if len(server.responses) > 0 {
+		response, server.responses = server.responses[0], server.responses[1:]
+	}
+
+	if strings.Contains(response, "errors") {
+		w.WriteHeader(400)
+	}
+
+	_, _ = io.WriteString(w, response)
+}
+
+func newFakeServer(responses ...string) (*httptest.Server, *fakeServer, *f.FaunaClient) {
+	fake := &fakeServer{responses: responses}
+	server := httptest.NewServer(fake)
+	return server, fake, f.NewFaunaClient("secret", f.Endpoint(server.URL))
+}
+
+func changesOf(plan Plan) (changes []string) {
+	for _, change := range plan {
+		changes = append(changes, change.String())
+	}
+
+	return
+}
+
+const (
+	usersRef  = `{"@ref":{"id":"users","collection":{"@ref":{"id":"collections"}}}}`
+	readerRef = `{"@ref":{"id":"reader","collection":{"@ref":{"id":"roles"}}}}`
+	lambda    = `{"lambda":"x","expr":{"var":"x"}}`
+)
+
+func desiredSchema() Schema {
+	return Schema{
+		Collections: []Collection{{Name: "users"}},
+		Indexes: []Index{{
+			Name:   "users_by_email",
+			Source: "users",
+			Terms:  []f.IndexField{f.FieldPath("data", "email")},
+			Unique: true,
+		}},
+		Functions: []Function{{Name: "identity", Body: f.Query(f.Lambda("x", f.Var("x"))), Role: "reader"}},
+		Roles: []Role{{
+			Name:       "reader",
+			Privileges: []Privilege{{Resource: f.Collection("users"), Actions: map[string]interface{}{"read": true}}},
+			Membership: []Membership{{Resource: f.Collection("users")}},
+		}},
+	}
+}
+
+func TestDiffCreatesMissingDocuments(t *testing.T) {
+	server, fake, client := newFakeServer(`{"resource":[
+		{"live":null,"desired":{}},{"live":null,"desired":{}},{"live":null,"desired":{}},{"live":null,"desired":{}}
+	]}`)
+	defer server.Close()
+
+	plan, err := Diff(client, desiredSchema())
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"create collection users",
+		"create index users_by_email",
+		"create function identity",
+		"create role reader",
+		"update function identity (role)",
+	}, changesOf(plan))
+
+	require.Equal(t, `CreateCollection({ name: "users" })`, f.FQL(plan[0].Expr))
+	require.Equal(t,
+		`CreateIndex({ name: "users_by_email", source: Collection("users"), terms: [{ field: ["data", "email"] }], unique: true, values: [] })`,
+		f.FQL(plan[1].Expr),
+	)
+	require.Equal(t, `CreateFunction({ body: Query(Lambda("x", Var("x"))), name: "identity" })`, f.FQL(plan[2].Expr))
+	require.Equal(t, `Update(Function("identity"), { role: Role("reader") })`, f.FQL(plan[4].Expr))
+
+	require.Len(t, fake.queries, 1)
+	require.Contains(t, fake.queries[0], `{"object":{"desired":{"object":{"name":"users"}},"live":{"else":null,"if":{"exists":{"collection":"users"}},"then":{"get":{"collection":"users"}}}}}`)
+}
+
+func TestDiffUpdatesChangedDocuments(t *testing.T) {
+	server, _, client := newFakeServer(`{"resource":[
+		{"live":{"name":"users","history_days":30,"data":{"owner":"a","old":1}},
+		 "desired":{"name":"users","data":{"owner":"b"}}},
+		{"live":{"name":"users_by_email","source":` + usersRef + `,"terms":[{"field":["data","email"]}],"unique":false},
+		 "desired":{"name":"users_by_email","source":` + usersRef + `,"terms":[{"field":["data","email"]}],"values":[],"unique":true}},
+		{"live":{"name":"identity","body":{"@query":{"api_version":"3","lambda":"x","expr":{"var":"x"}}},"role":` + readerRef + `},
+		 "desired":{"name":"identity","body":{"@query":{"api_version":"4","lambda":"x","expr":{"var":"x"}}},"role":` + readerRef + `}},
+		{"live":{"name":"reader","privileges":[{"resource":` + usersRef + `,"actions":{"read":true,"write":true}}]},
+		 "desired":{"name":"reader","privileges":[{"resource":` + usersRef + `,"actions":{"read":true}}],"membership":[]}}
+	]}`)
+	defer server.Close()
+
+	desired := desiredSchema()
+	desired.Collections[0].Data = f.Obj{"owner": "b"}
+
+	plan, err := Diff(client, desired)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"update collection users (data)",
+		"update index users_by_email (unique)",
+		"update role reader (privileges)",
+	}, changesOf(plan))
+
+	require.Equal(t, `Update(Collection("users"), { data: { old: null, owner: "b" } })`, f.FQL(plan[0].Expr))
+	require.Equal(t, `Update(Index("users_by_email"), { unique: true })`, f.FQL(plan[1].Expr))
+	require.Equal(t, `Update(Role("reader"), { privileges: [{ actions: { read: true }, resource: Collection("users") }] })`, f.FQL(plan[2].Expr))
+}
+
+func TestDiffRecreatesIndexesWithChangedTerms(t *testing.T) {
+	server, _, client := newFakeServer(`{"resource":[
+		{"live":{"name":"users_by_email","source":` + usersRef + `,"terms":[{"field":["data","mail"]}],"unique":true},
+		 "desired":{"name":"users_by_email","source":` + usersRef + `,"terms":[{"field":["data","email"]}],"values":[],"unique":true}}
+	]}`)
+	defer server.Close()
+
+	desired := desiredSchema()
+	desired.Collections, desired.Functions, desired.Roles = nil, nil, nil
+
+	plan, err := Diff(client, desired, RecreateIndexes())
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"delete index users_by_email to recreate it (terms)",
+		"create index users_by_email to recreate it (terms)",
+	}, changesOf(plan))
+	require.Equal(t, `Delete(Index("users_by_email"))`, f.FQL(plan[0].Expr))
+}
+
+func TestDiffRefusesToRecreateIndexesByDefault(t *testing.T) {
+	server, _, client := newFakeServer(`{"resource":[
+		{"live":{"name":"users_by_email","source":` + usersRef + `,"terms":[{"field":["data","mail"]}],"unique":true},
+		 "desired":{"name":"users_by_email","source":` + usersRef + `,"terms":[{"field":["data","email"]}],"values":[],"unique":true}}
+	]}`)
+	defer server.Close()
+
+	desired := desiredSchema()
+	desired.Collections, desired.Functions, desired.Roles = nil, nil, nil
+
+	_, err := Diff(client, desired)
+	require.EqualError(t, err, "schema: index users_by_email must be recreated to change terms, use RecreateIndexes to allow it")
+}
+
+func TestDiffComparesFunctionBodies(t *testing.T) {
+	server, _, client := newFakeServer(`{"resource":[
+		{"live":{"name":"identity","body":{"@query":{"api_version":"4","lambda":"y","expr":{"var":"y"}}},"role":"admin"},
+		 "desired":{"name":"identity","body":{"@query":` + lambda + `},"role":"admin"}}
+	]}`)
+	defer server.Close()
+
+	desired := Schema{Functions: []Function{{Name: "identity", Body: f.Query(f.Lambda("x", f.Var("x"))), Role: "admin"}}}
+
+	plan, err := Diff(client, desired)
+	require.NoError(t, err)
+	require.Equal(t, []string{"update function identity (body)"}, changesOf(plan))
+	require.Equal(t, `Update(Function("identity"), { body: Query(Lambda("x", Var("x"))) })`, f.FQL(plan[0].Expr))
+}
+
+func TestEqual(t *testing.T) {
+	instant := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ref := f.RefV{ID: "users", Collection: f.NativeCollections()}
+
+	require.True(t, equal(f.ArrayV{}, nil))
+	require.True(t, equal(f.ObjectV{"a": f.LongV(1)}, f.ObjectV{"a": f.LongV(1), "b": f.NullV{}}))
+	require.True(t, equal(f.TimeV(instant), f.TimeV(instant.In(time.FixedZone("", 3600)))))
+	require.True(t, equal(ref, f.RefV{ID: "users", Class: f.NativeCollections()}))
+	require.False(t, equal(f.LongV(1), f.DoubleV(1)))
+	require.False(t, equal(f.ObjectV{"a": f.LongV(1)}, f.ObjectV{"a": f.LongV(1), "b": f.LongV(2)}))
+}
+
+func TestDiffPrunesMissingDocuments(t *testing.T) {
+	server, _, client := newFakeServer(
+		`{"resource":[{"live":{"name":"users"},"desired":{"name":"users"}}]}`,
+		`{"resource":{"data":[`+readerRef+`]}}`,
+		`{"resource":{"data":[]}}`,
+		`{"resource":{"data":[],"after":[`+usersRef+`]}}`,
+		`{"resource":{"data":[{"@ref":{"id":"old_index","collection":{"@ref":{"id":"indexes"}}}}]}}`,
+		`{"resource":{"data":[`+usersRef+`]}}`,
+	)
+	defer server.Close()
+
+	plan, err := Diff(client, Schema{Collections: []Collection{{Name: "users"}}}, Prune())
+	require.NoError(t, err)
+	require.Equal(t, []string{"delete role reader", "delete index old_index"}, changesOf(plan))
+}
+
+func TestDiffValidatesSchema(t *testing.T) {
+	_, err := Diff(nil, Schema{Collections: []Collection{{Name: "a"}, {Name: "a"}}})
+	require.EqualError(t, err, "schema: duplicated collection a")
+
+	_, err = Diff(nil, Schema{Indexes: []Index{{Name: "i"}}})
+	require.EqualError(t, err, "schema: index i without source")
+
+	_, err = Diff(nil, Schema{Functions: []Function{{Name: "fn"}}})
+	require.EqualError(t, err, "schema: function fn without body")
+}
+
+func TestApply(t *testing.T) {
+	server, fake, client := newFakeServer(
+		`{"resource":null}`,
+		`{"errors":[{"position":[],"code":"instance already exists","description":"Index already exists."}]}`,
+	)
+	defer server.Close()
+
+	plan := Plan{
+		{Create, KindCollection, "users", nil, f.CreateCollection(f.Obj{"name": "users"}), false},
+		{Create, KindIndex, "users_by_email", nil, f.CreateIndex(f.Obj{"name": "users_by_email"}), false},
+		{Create, KindRole, "reader", nil, f.CreateRole(f.Obj{"name": "reader"}), false},
+	}
+
+	err := Apply(client, plan)
+	require.Len(t, fake.queries, 2)
+	require.EqualError(t, err, "schema: create index users_by_email: Response error 400. Errors: [](instance already exists): Index already exists.")
+	require.Equal(t, plan[1], err.(ApplyError).Change)
+	require.True(t, f.HasErrorCode(err, f.ErrInstanceAlreadyExists))
+}
+
+func TestParse(t *testing.T) {
+	schema, err := Parse([]byte(`{
+		"collections": [{"name": "users", "history_days": 0, "data": {"version": 1}}],
+		"indexes": [{
+			"name": "users_by_email",
+			"source": "users",
+			"bindings": {"domain": {"query": {"lambda": "doc", "expr": {"var": "doc"}}}},
+			"terms": [{"binding": "domain"}],
+			"values": [{"field": ["ts"], "reverse": true}],
+			"unique": true
+		}],
+		"functions": [{"name": "identity", "body": {"@query": ` + lambda + `}, "role": "server"}],
+		"roles": [{
+			"name": "reader",
+			"privileges": [{"resource": {"collection": "users"}, "actions": {"read": true, "write": {"query": ` + lambda + `}}}],
+			"membership": [{"resource": {"collection": "users"}}]
+		}]
+	}`))
+	require.NoError(t, err)
+
+	zero := int64(0)
+	require.Equal(t, []Collection{{Name: "users", HistoryDays: &zero, Data: f.Obj{"version": f.LongV(1)}}}, schema.Collections)
+
+	require.Len(t, schema.Indexes, 1)
+	require.Equal(t, []f.IndexField{f.BindingField("domain")}, schema.Indexes[0].Terms)
+	require.Equal(t, []f.IndexField{f.FieldPath("ts").Reversed()}, schema.Indexes[0].Values)
+	require.Equal(t, `Query(Lambda("doc", Var("doc")))`, f.FQL(schema.Indexes[0].Bindings["domain"]))
+	require.Equal(t,
+		`{ collection: Collection("users"), fields: { domain: Query(Lambda("doc", Var("doc"))) } }`,
+		f.FQL(schema.Indexes[0].params()["source"].(f.Expr)),
+	)
+
+	require.Len(t, schema.Functions, 1)
+	require.Equal(t, `Query(Lambda("x", Var("x")))`, f.FQL(schema.Functions[0].Body))
+	require.Equal(t, "server", schema.Functions[0].Role)
+
+	require.Len(t, schema.Roles, 1)
+	require.Equal(t, `Collection("users")`, f.FQL(schema.Roles[0].Privileges[0].Resource))
+	require.Equal(t, f.BooleanV(true), schema.Roles[0].Privileges[0].Actions["read"])
+	require.Equal(t, `Query(Lambda("x", Var("x")))`, f.FQL(schema.Roles[0].Privileges[0].Actions["write"].(f.Expr)))
+	require.Nil(t, schema.Roles[0].Membership[0].Predicate)
+}
+
+func TestParseYAML(t *testing.T) {
+	schema, err := ParseYAML([]byte(`
+collections:
+  - name: users
+    history_days: 0
+    data: {version: 1}
+indexes:
+  - name: users_by_email
+    source: users
+    terms:
+      - field: [data, email]
+    values:
+      - field: [ts]
+        reverse: true
+    unique: true
+functions:
+  - name: identity
+    body: {"@query": {lambda: x, expr: {var: x}}}
+    role: server
+roles:
+  - name: reader
+    privileges:
+      - resource: {collection: users}
+        actions: {read: true}
+    membership:
+      - resource: {collection: users}
+`))
+	require.NoError(t, err)
+
+	zero := int64(0)
+	require.Equal(t, []Collection{{Name: "users", HistoryDays: &zero, Data: f.Obj{"version": f.LongV(1)}}}, schema.Collections)
+
+	require.Len(t, schema.Indexes, 1)
+	require.Equal(t, []f.IndexField{f.FieldPath("data", "email")}, schema.Indexes[0].Terms)
+	require.Equal(t, []f.IndexField{f.FieldPath("ts").Reversed()}, schema.Indexes[0].Values)
+	require.True(t, schema.Indexes[0].Unique)
+
+	require.Len(t, schema.Functions, 1)
+	require.Equal(t, `Query(Lambda("x", Var("x")))`, f.FQL(schema.Functions[0].Body))
+
+	require.Len(t, schema.Roles, 1)
+	require.Equal(t, `Collection("users")`, f.FQL(schema.Roles[0].Privileges[0].Resource))
+	require.Equal(t, f.BooleanV(true), schema.Roles[0].Privileges[0].Actions["read"])
+
+	_, err = ParseYAML([]byte("collections: [name: users"))
+	require.Error(t, err)
+}
+
+func TestParseFailures(t *testing.T) {
+	_, err := Parse([]byte(`{"functions": [{"name": "fn", "body": {"query":`))
+	require.Error(t, err)
+
+	_, err = Parse([]byte(`{"collections": [{"name": "users", "data": [1]}]}`))
+	require.EqualError(t, err, "schema: data must be an object, got [1]")
+}
@@ -0,0 +1,123 @@
+package faunadb
+
+import (
+	"fmt"
+	"strings"
+)
+
+const unnamedStepPrefix = "txn_step_"
+
+/*
+Txn builds a transaction out of named steps. Each step is evaluated in order, and can reference the
+results of earlier steps through the Expr returned when the step is added, so later steps read the
+writes of earlier ones. The transaction compiles down to a single Let expression, evaluated
+atomically by one query. For example:
+
+	txn := NewTxn()
+
+	from := txn.Step("from", Get(fromRef))
+	to := txn.Step("to", Get(toRef))
+	balance := Select(Arr{"data", "balance"}, from)
+
+	txn.DoIf(LT(balance, amount), Abort("insufficient funds"))
+	txn.Step("debit", Update(fromRef, Obj{"data": Obj{"balance": Subtract(balance, amount)}}))
+	txn.Step("credit", Update(toRef, Obj{"data": Obj{"balance": Add(Select(Arr{"data", "balance"}, to), amount)}}))
+	txn.Output("balance", Select(Arr{"data", "balance"}, Var("debit")))
+
+	value, err := txn.Run(client)
+
+The result of the transaction is an object holding the named outputs, or the result of the last step
+if there are no outputs. Step names must be unique, and names starting with txn_step_ are reserved for
+unnamed steps. Invalid names are reported by Expr and Run.
+*/
+type Txn struct {
+	steps   []txnStep
+	names   map[string]bool
+	outputs Obj
+	err     error
+}
+
+type txnStep struct {
+	name string
+	expr Expr
+}
+
+// NewTxn creates an empty transaction.
+func NewTxn() *Txn { return &Txn{names: map[string]bool{}, outputs: Obj{}} }
+
+// Step adds a step binding the result of expr to name, and returns a reference to that result to be
+// used by later steps and outputs.
+func (txn *Txn) Step(name string, expr interface{}) Expr {
+	if strings.HasPrefix(name, unnamedStepPrefix) && txn.err == nil {
+		txn.err = fmt.Errorf("faunadb: transaction step name %q is reserved", name)
+	}
+
+	return txn.addStep(name, expr)
+}
+
+func (txn *Txn) addStep(name string, expr interface{}) Expr {
+	if txn.names[name] && txn.err == nil {
+		txn.err = fmt.Errorf("faunadb: duplicate transaction step name %q", name)
+	}
+
+	txn.names[name] = true
+	txn.steps = append(txn.steps, txnStep{name, wrap(expr)})
+
+	return Var(name)
+}
+
+// StepIf adds a step binding name to the result of then if cond is true, or to the result of
+// otherwise if it's false.
+func (txn *Txn) StepIf(name string, cond, then, otherwise interface{}) Expr {
+	return txn.Step(name, If(cond, then, otherwise))
+}
+
+// Do adds an unnamed step, evaluated for its side effects.
+func (txn *Txn) Do(expr interface{}) {
+	txn.addStep(fmt.Sprintf("%s%d", unnamedStepPrefix, len(txn.steps)), expr)
+}
+
+// DoIf adds an unnamed step evaluated only if cond is true.
+func (txn *Txn) DoIf(cond, expr interface{}) {
+	txn.Do(If(cond, expr, nil))
+}
+
+// Output adds a field to the result of the transaction, holding the given value. Values can
+// reference steps by the Expr returned by Step, or by Var with the step's name.
+func (txn *Txn) Output(name string, value interface{}) {
+	txn.outputs[name] = value
+}
+
+// Expr compiles the transaction into a single expression. It returns an error if a step name was
+// used twice or is reserved.
+func (txn *Txn) Expr() (Expr, error) {
+	if txn.err != nil {
+		return nil, txn.err
+	}
+
+	if len(txn.steps) == 0 {
+		return txn.outputs, nil
+	}
+
+	let := Let()
+
+	for _, step := range txn.steps {
+		let.Bind(step.name, step.expr)
+	}
+
+	if len(txn.outputs) == 0 {
+		return let.In(Var(txn.steps[len(txn.steps)-1].name)), nil
+	}
+
+	return let.In(txn.outputs), nil
+}
+
+// Run evaluates the transaction with the given client, usually a *FaunaClient.
+func (txn *Txn) Run(client Querier, configs ...QueryConfig) (Value, error) {
+	expr, err := txn.Expr()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Query(expr, configs...)
+}
@@ -0,0 +1,86 @@
+package faunadb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeTxn(t *testing.T) {
+	fromRef := RefCollection(Collection("accounts"), "1")
+	toRef := RefCollection(Collection("accounts"), "2")
+
+	txn := NewTxn()
+	from := txn.Step("from", Get(fromRef))
+	balance := Select(Arr{"data", "balance"}, from)
+
+	txn.DoIf(LT(balance, 10), Abort("insufficient funds"))
+	txn.Step("debit", Update(fromRef, Obj{"data": Obj{"balance": Subtract(balance, 10)}}))
+	txn.StepIf("credit", Exists(toRef), Update(toRef, Obj{"data": Obj{"balance": 10}}), nil)
+	txn.Output("balance", Select(Arr{"data", "balance"}, Var("debit")))
+	txn.Output("credited", Var("credit"))
+
+	expr, err := txn.Expr()
+	require.NoError(t, err)
+	assertJSON(t, expr,
+		`{"in":{"object":{"balance":{"from":{"var":"debit"},"select":["data","balance"]},"credited":{"var":"credit"}}},"let":[`+
+			`{"from":{"get":{"id":"1","ref":{"collection":"accounts"}}}},`+
+			`{"txn_step_1":{"else":null,"if":{"lt":[{"from":{"var":"from"},"select":["data","balance"]},10]},"then":{"abort":"insufficient funds"}}},`+
+			`{"debit":{"params":{"object":{"data":{"object":{"balance":{"subtract":[{"from":{"var":"from"},"select":["data","balance"]},10]}}}}},"update":{"id":"1","ref":{"collection":"accounts"}}}},`+
+			`{"credit":{"else":null,"if":{"exists":{"id":"2","ref":{"collection":"accounts"}}},"then":{"params":{"object":{"data":{"object":{"balance":10}}}},"update":{"id":"2","ref":{"collection":"accounts"}}}}}`+
+			`]}`,
+	)
+}
+
+func TestSerializeTxnWithoutOutputs(t *testing.T) {
+	txn := NewTxn()
+	txn.Do(Create(Collection("logs"), Obj{}))
+	txn.Do(Create(Collection("logs"), Obj{}))
+
+	expr, err := txn.Expr()
+	require.NoError(t, err)
+	assertJSON(t, expr,
+		`{"in":{"var":"txn_step_1"},"let":[`+
+			`{"txn_step_0":{"create":{"collection":"logs"},"params":{"object":{}}}},`+
+			`{"txn_step_1":{"create":{"collection":"logs"},"params":{"object":{}}}}]}`,
+	)
+
+	expr, err = NewTxn().Expr()
+	require.NoError(t, err)
+	assertJSON(t, expr, `{"object":{}}`)
+}
+
+func TestTxnRejectsInvalidStepNames(t *testing.T) {
+	txn := NewTxn()
+	txn.Step("doc", Get(RefCollection(Collection("accounts"), "1")))
+	txn.Step("doc", Get(RefCollection(Collection("accounts"), "2")))
+
+	_, err := txn.Expr()
+	require.EqualError(t, err, `faunadb: duplicate transaction step name "doc"`)
+
+	txn = NewTxn()
+	txn.Step("txn_step_1", Get(RefCollection(Collection("accounts"), "1")))
+
+	_, err = txn.Run(nil)
+	require.EqualError(t, err, `faunadb: transaction step name "txn_step_1" is reserved`)
+}
+
+func TestRunTxn(t *testing.T) {
+	var queries []string
+
+	server, client := newTestServer(respondWithPages(&queries, `{"balance":10}`))
+	defer server.Close()
+
+	txn := NewTxn()
+	txn.Output("balance", Select(Arr{"data", "balance"}, txn.Step("doc", Get(RefCollection(Collection("accounts"), "1")))))
+
+	var result struct {
+		Balance int `fauna:"balance"`
+	}
+
+	value, err := txn.Run(client)
+	require.NoError(t, err)
+	require.NoError(t, value.Get(&result))
+	require.Equal(t, 10, result.Balance)
+	require.Equal(t, `{"in":{"object":{"balance":{"from":{"var":"doc"},"select":["data","balance"]}}},"let":[{"doc":{"get":{"id":"1","ref":{"collection":"accounts"}}}}]}`, queries[0])
+}
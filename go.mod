@@ -1,7 +1,10 @@
 module github.com/fauna/faunadb-go
 
+go 1.18
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/testify v1.2.2
+	gopkg.in/yaml.v2 v2.4.0
 )